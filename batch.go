@@ -0,0 +1,154 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+const (
+
+	// defaultBatchMaxSize is the max number of recipe ids in a batch request if it's not configured.
+	defaultBatchMaxSize = 100
+
+	// defaultBatchConcurrency is the number of recipes processed in parallel if it's not configured.
+	defaultBatchConcurrency = 5
+
+	// batchStatusFound is used for existing recipes in a batch get request.
+	batchStatusFound = "found"
+
+	// batchStatusDeleted is used for recipes deleted by a batch delete request.
+	batchStatusDeleted = "deleted"
+
+	// batchStatusNotFound is used for recipe ids which doesn't exist.
+	batchStatusNotFound = "not-found"
+
+	// batchStatusError is used if a recipe couldn't be processed.
+	batchStatusError = "error"
+)
+
+// parseRequest will extract recipe ids from request body and requested fields from query params.
+func (handler *apiGatewayBatchGetRequestHandler) parseRequest(request events.APIGatewayProxyRequest) error {
+
+	fields, err := parseFields(request.QueryStringParameters)
+	if err != nil {
+		return err
+	}
+	handler.fields = fields
+	return handler.batch.parse(request.Body)
+}
+
+// handle batch get requests by loading all requested recipes in parallel.
+func (handler *apiGatewayBatchGetRequestHandler) handle() (*string, error) {
+
+	return handler.batch.run(handler.batch.concurrency, func(id string) batchResult {
+		recipe, err := handler.recipeService.Get(id)
+		if err != nil {
+			return batchResultForError(id, fromServiceError(err))
+		}
+		body, err := marshalRecipe(*recipe, handler.fields)
+		if err != nil {
+			return batchResultForError(id, err)
+		}
+		return batchResult{Id: id, Status: batchStatusFound, Recipe: json.RawMessage(*body)}
+	})
+}
+
+// parseRequest will extract recipe ids from request body.
+func (handler *apiGatewayBatchDeleteRequestHandler) parseRequest(request events.APIGatewayProxyRequest) error {
+	return handler.batch.parse(request.Body)
+}
+
+// handle batch delete requests by deleting all requested recipes one after another. Recipe repository updates
+// the index of a recipe type without locking, so concurrent deletes of recipes with the same type would lose index updates.
+func (handler *apiGatewayBatchDeleteRequestHandler) handle() (*string, error) {
+
+	return handler.batch.run(1, func(id string) batchResult {
+		recipe, err := handler.recipeService.Get(id)
+		if err != nil {
+			return batchResultForError(id, fromServiceError(err))
+		}
+		if err := handler.recipeService.Delete(*recipe); err != nil {
+			return batchResultForError(id, fromServiceError(err))
+		}
+		handler.logger.Infof("Recipe %s deleted by batch request.", id)
+		return batchResult{Id: id, Status: batchStatusDeleted}
+	})
+}
+
+// parse decodes passed request body, which is a list of recipe ids or an object with ids as property.
+// Duplicate ids are removed. It fails if there're no ids or more ids than allowed.
+func (batch *batchRequest) parse(requestBody string) error {
+
+	ids := []string{}
+	if err := decodeJson(requestBody, &ids); err != nil {
+		body := struct {
+			Ids []string `json:"ids"`
+		}{}
+		if err := decodeJson(requestBody, &body); err != nil {
+			return newBadRequestError("Request body is not a list of recipe ids", err)
+		}
+		ids = body.Ids
+	}
+
+	batch.ids = []string{}
+	for _, id := range ids {
+		if id == "" {
+			return newFieldValidationError([]fieldError{{Field: "ids", Message: "must not contain empty ids"}})
+		}
+		if !containsString(batch.ids, id) {
+			batch.ids = append(batch.ids, id)
+		}
+	}
+	if len(batch.ids) == 0 {
+		return newBadRequestError("Request body doesn't contain any recipe ids.", nil)
+	}
+	if len(batch.ids) > batch.maxSize {
+		return newBadRequestError(fmt.Sprintf("Too many recipe ids: %d, max: %d", len(batch.ids), batch.maxSize), nil)
+	}
+	return nil
+}
+
+// run executes passed action for all recipe ids of a batch request. At most passed number of
+// actions is executed in parallel. It returns a response with the results for all ids, in requested order.
+func (batch *batchRequest) run(concurrency int, action func(id string) batchResult) (*string, error) {
+
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	results := make([]batchResult, len(batch.ids))
+	semaphore := make(chan struct{}, concurrency)
+	wg := sync.WaitGroup{}
+	for idx, id := range batch.ids {
+		wg.Add(1)
+		semaphore <- struct{}{}
+		go func(idx int, id string) {
+			defer wg.Done()
+			defer func() { <-semaphore }()
+			results[idx] = action(id)
+		}(idx, id)
+	}
+	wg.Wait()
+
+	b, err := json.Marshal(batchResponse{Items: results})
+	jsonStr := string(b)
+	return &jsonStr, err
+}
+
+// batchResultForError returns a not found result for not found errors, otherwise an error result.
+// Messages of internal errors are replaced by a generic message, like in problem details.
+func batchResultForError(id string, err error) batchResult {
+
+	if _, ok := err.(*notFoundError); ok {
+		return batchResult{Id: id, Status: batchStatusNotFound}
+	}
+	return batchResult{Id: id, Status: batchStatusError, Error: errorDetail(err, statusCodeForError(err, http.StatusInternalServerError))}
+}
+
+// newBatchRequest returns a batch request with given limits.
+func newBatchRequest(maxSize, concurrency int) batchRequest {
+	return batchRequest{maxSize: maxSize, concurrency: concurrency}
+}
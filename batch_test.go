@@ -0,0 +1,149 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/stretchr/testify/suite"
+	"github.com/tommzn/recipeboard-core/mock"
+	model "github.com/tommzn/recipeboard-core/model"
+)
+
+// Test suite for batch requests.
+type BatchTestSuite struct {
+	suite.Suite
+	repo    *mock.RepositoryMock
+	service *recipeServiceMock
+	router  LambdaRequestHandler
+}
+
+func TestBatchTestSuite(t *testing.T) {
+	suite.Run(t, new(BatchTestSuite))
+}
+
+// Setup test.
+func (suite *BatchTestSuite) SetupTest() {
+
+	suite.repo = repositoryForTest()
+	factory := factoryForTest(suite.repo, publisherForTest(), loggerForTest())
+	suite.service = &recipeServiceMock{RecipeService: factory.recipeService, delay: 5 * time.Millisecond}
+	factory.recipeService = suite.service
+	factory.config = configForTest("batch:\n  maxsize: 5\n  concurrency: 2\n")
+	suite.router = routerWithFactoryForTest(factory, loggerForTest())
+}
+
+// Test getting multiple recipes at once.
+func (suite *BatchTestSuite) TestBatchGet() {
+
+	recipe1 := suite.recipeInRepository()
+	recipe2 := suite.recipeInRepository()
+
+	request := batchRequestForTest("/recipes:batchGet", `{"ids": ["`+recipe2.Id+`", "xxx", "`+recipe1.Id+`", "`+recipe2.Id+`"]}`)
+	request.QueryStringParameters["fields"] = "id,title"
+	response, err := suite.router.handle(context.Background(), request)
+	suite.Nil(err)
+	suite.Equal(http.StatusOK, response.StatusCode)
+
+	results := suite.batchResultsFromResponse(response)
+	suite.Len(results, 3)
+	suite.Equal(batchResult{Id: recipe2.Id, Status: batchStatusFound, Recipe: json.RawMessage(`{"Id":"` + recipe2.Id + `","Title":"Bake a Cake"}`)}, results[0])
+	suite.Equal(batchResult{Id: "xxx", Status: batchStatusNotFound}, results[1])
+	suite.Equal(recipe1.Id, results[2].Id)
+	suite.Equal(batchStatusFound, results[2].Status)
+	suite.Len(suite.repo.Recipes, 2)
+	suite.Equal(2, suite.service.maxInFlight)
+}
+
+// Test deleting multiple recipes at once. Access to the recipe service isn't serialized, like in production,
+// so recipes have to be deleted one after another.
+func (suite *BatchTestSuite) TestBatchDelete() {
+
+	suite.service.unserialized = true
+	ids := []string{}
+	for i := 0; i < 4; i++ {
+		ids = append(ids, suite.recipeInRepository().Id)
+	}
+	keptRecipe := suite.recipeInRepository()
+
+	response, err := suite.router.handle(context.Background(), batchRequestForTest("/recipes:batchDelete", `["`+strings.Join(ids, `", "`)+`", "xxx"]`))
+	suite.Nil(err)
+	suite.Equal(http.StatusOK, response.StatusCode)
+
+	results := suite.batchResultsFromResponse(response)
+	suite.Len(results, 5)
+	for idx, id := range ids {
+		suite.Equal(batchResult{Id: id, Status: batchStatusDeleted}, results[idx])
+	}
+	suite.Equal(batchResult{Id: "xxx", Status: batchStatusNotFound}, results[4])
+	suite.Len(suite.repo.Recipes, 1)
+	_, ok := suite.repo.Recipes[keptRecipe.Id]
+	suite.True(ok)
+	suite.Equal(1, suite.service.maxInFlight)
+}
+
+// Test hiding messages of internal errors in batch results.
+func (suite *BatchTestSuite) TestBatchResultForError() {
+
+	suite.Equal(batchResult{Id: "1", Status: batchStatusNotFound}, batchResultForError("1", newNotFoundError("Recipe not found.", nil)))
+	suite.Equal(batchResult{Id: "1", Status: batchStatusError, Error: "Unable to process request."}, batchResultForError("1", errors.New("SQS: AccessDenied for arn:aws:sqs:eu-west-1:123456789012:recipes")))
+	suite.Equal(batchResult{Id: "1", Status: batchStatusError, Error: "Recipe has been changed."}, batchResultForError("1", newPreconditionFailedError("Recipe has been changed.")))
+}
+
+// Test batch requests with invalid recipe ids.
+func (suite *BatchTestSuite) TestBatchRequestWithInvalidIds() {
+
+	expectedStatusCodes := map[string]int{
+		``:                                    http.StatusBadRequest,
+		`[]`:                                  http.StatusBadRequest,
+		`{"ids": []}`:                         http.StatusBadRequest,
+		`{"ids": "123"}`:                      http.StatusBadRequest,
+		`["1", ""]`:                           http.StatusUnprocessableEntity,
+		`["1", "2", "3", "4", "5", "6"]`:      http.StatusBadRequest,
+		`["1", "2", "3", "4", "5", "5", "5"]`: http.StatusOK,
+		`{"ids": ["1", "2", "3", "4", "5", "1"]}`: http.StatusOK,
+	}
+	for body, expectedStatusCode := range expectedStatusCodes {
+		for _, path := range []string{"/recipes:batchGet", "/recipes:batchDelete"} {
+			response, err := suite.router.handle(context.Background(), batchRequestForTest(path, body))
+			suite.Nil(err)
+			suite.Equal(expectedStatusCode, response.StatusCode, path+" "+body)
+		}
+	}
+}
+
+// Test batch limits from config.
+func (suite *BatchTestSuite) TestBatchLimitsFromConfig() {
+
+	suite.Equal(newBatchRequest(defaultBatchMaxSize, defaultBatchConcurrency), mockedFactoryForTest(loggerForTest()).newBatchRequest())
+
+	factory := mockedFactoryForTest(loggerForTest())
+	factory.config = configForTest("batch:\n  maxsize: 10\n  concurrency: 3\n")
+	suite.Equal(newBatchRequest(10, 3), factory.newBatchRequest())
+}
+
+// recipeInRepository adds a new recipe to the repository mock.
+func (suite *BatchTestSuite) recipeInRepository() model.Recipe {
+	recipe := recipeForTest()
+	suite.repo.Recipes[recipe.Id] = recipe
+	return recipe
+}
+
+// batchResultsFromResponse extracts all results from a batch response.
+func (suite *BatchTestSuite) batchResultsFromResponse(response events.APIGatewayProxyResponse) []batchResult {
+	var batchResponse batchResponse
+	suite.Nil(json.Unmarshal([]byte(response.Body), &batchResponse))
+	return batchResponse.Items
+}
+
+// batchRequestForTest returns a POST request for passed batch path and body.
+func batchRequestForTest(path, body string) events.APIGatewayProxyRequest {
+	request := apiGatewayRequestWithPathForTest(http.MethodPost, path)
+	request.Body = body
+	return request
+}
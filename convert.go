@@ -0,0 +1,287 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+
+	model "github.com/tommzn/recipeboard-core/model"
+)
+
+const (
+
+	// metricUnitSystem converts ingredients to grams and milliliters and temperatures to Celsius.
+	metricUnitSystem = "metric"
+
+	// usUnitSystem converts ingredients to cups, spoons, ounces and pounds and temperatures to Fahrenheit.
+	usUnitSystem = "us"
+
+	// cupSize is the volume of a US cup in milliliters.
+	cupSize = 236.588
+
+	// maxConversionError is the maximal relative error caused by rounding a converted quantity.
+	maxConversionError = 0.06
+)
+
+// temperaturePattern matches temperatures in recipe texts, e.g. "180°C", "350 °F", "180 Grad" or "350 degrees Fahrenheit".
+var temperaturePattern = regexp.MustCompile(`(?i)(\d+(?:[.,]\d+)?)\s*(°|degrees?|grad)(?:\s*(celsius|fahrenheit|c|f)\b)?`)
+
+// volumeUnits contains the size of all volume units in milliliters.
+var volumeUnits = map[string]float64{
+	"ml": 1, "cl": 10, "dl": 100, "l": 1000,
+	"tsp": 4.92892, "tbsp": 14.7868, "cup": cupSize, "fl oz": 29.5735, "pint": 473.176, "quart": 946.353, "gallon": 3785.41,
+}
+
+// massUnits contains the size of all mass units in grams.
+var massUnits = map[string]float64{
+	"mg": 0.001, "g": 1, "kg": 1000, "oz": 28.3495, "lb": 453.592,
+}
+
+// usUnits contains all US customary units. Spoons are missing, because they're used in metric recipes as well.
+var usUnits = map[string]bool{"cup": true, "fl oz": true, "pint": true, "quart": true, "gallon": true, "oz": true, "lb": true}
+
+// metricVolumeUnits are used to convert volumes to metric units, largest unit first.
+var metricVolumeUnits = []conversionUnit{
+	{name: "l", size: 1000, minQuantity: 1, step: 0.01},
+	{name: "ml", size: 1, minQuantity: 0, step: 5},
+}
+
+// metricMassUnits are used to convert masses to metric units, largest unit first.
+var metricMassUnits = []conversionUnit{
+	{name: "kg", size: 1000, minQuantity: 1, step: 0.01},
+	{name: "g", size: 1, minQuantity: 0, step: 5},
+}
+
+// usVolumeUnits are used to convert volumes to US customary units, largest unit first.
+var usVolumeUnits = []conversionUnit{
+	{name: "cup", size: cupSize, minQuantity: 0.25, step: 1.0 / 8},
+	{name: "tbsp", size: 14.7868, minQuantity: 1, step: 0.5},
+	{name: "tsp", size: 4.92892, minQuantity: 0, step: 1.0 / 8},
+}
+
+// usMassUnits are used to convert masses to US customary units, largest unit first.
+var usMassUnits = []conversionUnit{
+	{name: "lb", size: 453.592, minQuantity: 1, step: 0.25},
+	{name: "oz", size: 28.3495, minQuantity: 0, step: 0.5},
+}
+
+// ingredientDensities contains the weight of a cup in grams for common baking ingredients, keyed by lower case
+// english and german names. A density of zero marks liquids, which are always converted by volume.
+var ingredientDensities = map[string]float64{
+	"flour": 125, "mehl": 125, "all-purpose flour": 125, "bread flour": 130, "whole wheat flour": 120, "vollkornmehl": 120,
+	"sugar": 200, "zucker": 200, "brown sugar": 220, "brauner zucker": 220,
+	"powdered sugar": 120, "icing sugar": 120, "confectioners sugar": 120, "puderzucker": 120,
+	"butter": 227, "buttermilk": 0, "buttermilch": 0, "peanut butter": 258, "erdnussbutter": 258,
+	"cocoa": 85, "cocoa powder": 85, "kakao": 85, "kakaopulver": 85,
+	"oats": 90, "rolled oats": 90, "haferflocken": 90,
+	"rice": 185, "reis": 185, "salt": 292, "salz": 292,
+	"baking powder": 192, "backpulver": 192, "baking soda": 220, "natron": 220,
+	"cornstarch": 128, "corn starch": 128, "speisestärke": 128, "stärke": 128,
+	"chocolate chips": 170, "schokotropfen": 170, "raisins": 150, "rosinen": 150,
+	"ground almonds": 96, "almond flour": 96, "gemahlene mandeln": 96,
+	"honey": 340, "honig": 340, "shredded coconut": 85, "kokosraspeln": 85,
+}
+
+// compoundIngredientNames are german ingredient names of the density table, which are found at the end of
+// compound words as well, e.g. Weizenmehl or Rohrzucker.
+var compoundIngredientNames = map[string]bool{
+	"mehl": true, "zucker": true, "salz": true, "reis": true, "stärke": true, "kakao": true, "haferflocken": true,
+}
+
+// parseUnitSystem reads the units query param. It's empty if ingredients shouldn't be converted.
+func parseUnitSystem(queryParams map[string]string) (string, error) {
+
+	unitsParam, ok := queryParams["units"]
+	if !ok {
+		return "", nil
+	}
+	switch units := strings.ToLower(strings.TrimSpace(unitsParam)); units {
+	case metricUnitSystem, usUnitSystem:
+		return units, nil
+	default:
+		return "", newBadRequestError(fmt.Sprintf("Unsupported units: %s, supported: %s, %s", unitsParam, metricUnitSystem, usUnitSystem), nil)
+	}
+}
+
+// convertRecipe converts passed ingredients and all temperatures in the description of given recipe to passed
+// unit system. It returns the recipe with converted ingredients text and all converted ingredients.
+func convertRecipe(recipe model.Recipe, ingredients []ingredient, units string) (model.Recipe, []ingredient) {
+
+	converted := []ingredient{}
+	lines := []string{}
+	for _, parsed := range ingredients {
+		parsed = convertIngredient(parsed, units)
+		converted = append(converted, parsed)
+		lines = append(lines, parsed.Text)
+	}
+	recipe.Ingredients = strings.Join(lines, "\n")
+	recipe.Description = convertTemperatures(recipe.Description, units)
+	return recipe, converted
+}
+
+// convertIngredient converts quantity and unit of passed ingredient to given unit system. Volumes of baking
+// ingredients are converted to grams and vice versa if their density is known. Ingredients without quantity,
+// with units of the requested unit system or without a volume or mass unit are returned unchanged.
+func convertIngredient(parsed ingredient, units string) ingredient {
+
+	if parsed.Quantity == nil {
+		return parsed
+	}
+	density, hasDensity := ingredientDensity(parsed.Name)
+	var targetUnits []conversionUnit
+	var factor float64
+	switch {
+	case units == metricUnitSystem && usUnits[parsed.Unit] && volumeUnits[parsed.Unit] > 0:
+		factor = volumeUnits[parsed.Unit]
+		targetUnits = metricVolumeUnits
+		if hasDensity {
+			factor = factor * density / cupSize
+			targetUnits = metricMassUnits
+		}
+	case units == metricUnitSystem && usUnits[parsed.Unit] && massUnits[parsed.Unit] > 0:
+		factor = massUnits[parsed.Unit]
+		targetUnits = metricMassUnits
+	case units == usUnitSystem && !usUnits[parsed.Unit] && massUnits[parsed.Unit] > 0:
+		factor = massUnits[parsed.Unit]
+		targetUnits = usMassUnits
+		if hasDensity {
+			factor = factor * cupSize / density
+			targetUnits = usVolumeUnits
+		}
+	case units == usUnitSystem && metricUnits[parsed.Unit] && volumeUnits[parsed.Unit] > 0:
+		factor = volumeUnits[parsed.Unit]
+		targetUnits = usVolumeUnits
+	default:
+		return parsed
+	}
+
+	unit := selectConversionUnit(*parsed.Quantity*factor, targetUnits)
+	quantity := roundConvertedQuantity(*parsed.Quantity*factor/unit.size, unit)
+	parsed.Quantity = &quantity
+	if parsed.QuantityMax != nil {
+		quantityMax := roundConvertedQuantity(*parsed.QuantityMax*factor/unit.size, unit)
+		parsed.QuantityMax = &quantityMax
+	}
+	parsed.Unit = unit.name
+	parsed.Text = formatIngredient(parsed)
+	return parsed
+}
+
+// ingredientDensity returns the weight of a cup of passed ingredient in grams. Ingredients of the density table
+// have to match the last words of the name, so "rice" matches "basmati rice", but not "rice vinegar".
+// If multiple ingredients match, the longest one is used, e.g. brown sugar instead of sugar.
+// Alphabetical order is used for names of the same length to get stable results.
+// It returns false if the density is unknown or the ingredient is a liquid.
+func ingredientDensity(name string) (float64, bool) {
+
+	words := ingredientWords(name)
+	match := ""
+	for ingredientName := range ingredientDensities {
+		if !endsWithIngredientName(words, ingredientName) {
+			continue
+		}
+		if len(ingredientName) > len(match) || (len(ingredientName) == len(match) && ingredientName < match) {
+			match = ingredientName
+		}
+	}
+	density := ingredientDensities[match]
+	return density, density > 0
+}
+
+// ingredientWords splits passed ingredient name into lower case words of letters and digits.
+func ingredientWords(name string) []string {
+	return strings.FieldsFunc(strings.ToLower(name), func(char rune) bool {
+		return !unicode.IsLetter(char) && !unicode.IsDigit(char)
+	})
+}
+
+// endsWithIngredientName returns true if passed words end with all words of given ingredient name.
+// Compound ingredient names can be the end of the last word, e.g. mehl in weizenmehl.
+func endsWithIngredientName(words []string, ingredientName string) bool {
+
+	nameWords := ingredientWords(ingredientName)
+	if len(nameWords) == 0 || len(nameWords) > len(words) {
+		return false
+	}
+	lastWord := words[len(words)-1]
+	if len(nameWords) == 1 && compoundIngredientNames[ingredientName] && strings.HasSuffix(lastWord, ingredientName) {
+		return true
+	}
+	offset := len(words) - len(nameWords)
+	for idx, nameWord := range nameWords {
+		if words[offset+idx] != nameWord {
+			return false
+		}
+	}
+	return true
+}
+
+// selectConversionUnit returns the largest of passed units which can express given amount with a small rounding error.
+// Amount has to be passed in the smallest unit, the smallest unit is used if no other unit fits.
+func selectConversionUnit(amount float64, units []conversionUnit) conversionUnit {
+
+	for _, unit := range units[:len(units)-1] {
+		quantity := amount / unit.size
+		if quantity < unit.minQuantity {
+			continue
+		}
+		if math.Abs(roundConvertedQuantity(quantity, unit)-quantity)/quantity <= maxConversionError {
+			return unit
+		}
+	}
+	return units[len(units)-1]
+}
+
+// roundConvertedQuantity rounds passed quantity to the step of given unit. Small quantities are
+// rounded to whole numbers for steps greater than one, and to two decimal places if they would be zero.
+func roundConvertedQuantity(quantity float64, unit conversionUnit) float64 {
+
+	step := unit.step
+	if step > 1 && quantity < 10*step {
+		step = 1
+	}
+	rounded := math.Round(quantity/step) * step
+	if rounded == 0 {
+		rounded = math.Round(quantity*100) / 100
+	}
+	return rounded
+}
+
+// convertTemperatures converts all temperatures in passed text to Celsius or Fahrenheit, depending on given
+// unit system. Oven temperatures are rounded to the usual dial steps of 10°C or 25°F. Temperatures
+// in german "Grad" without scale are Celsius, all other temperatures without scale are kept.
+func convertTemperatures(text string, units string) string {
+
+	return temperaturePattern.ReplaceAllStringFunc(text, func(match string) string {
+
+		parts := temperaturePattern.FindStringSubmatch(match)
+		value, err := strconv.ParseFloat(strings.Replace(parts[1], ",", ".", 1), 64)
+		if err != nil {
+			return match
+		}
+		scale := strings.ToLower(parts[3])
+		if scale == "" && strings.HasPrefix(strings.ToLower(parts[2]), "grad") {
+			scale = "c"
+		}
+		switch {
+		case units == usUnitSystem && (scale == "c" || scale == "celsius"):
+			return fmt.Sprintf("%d°F", roundTemperature(value*9/5+32, 200, 25))
+		case units == metricUnitSystem && (scale == "f" || scale == "fahrenheit"):
+			return fmt.Sprintf("%d°C", roundTemperature((value-32)*5/9, 100, 10))
+		default:
+			return match
+		}
+	})
+}
+
+// roundTemperature rounds passed temperature to given step if it's at least an oven temperature,
+// all other temperatures are rounded to whole degrees.
+func roundTemperature(temperature, ovenTemperature, step float64) int {
+	if temperature < ovenTemperature {
+		return int(math.Round(temperature))
+	}
+	return int(math.Round(temperature/step) * step)
+}
@@ -0,0 +1,150 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/stretchr/testify/suite"
+	"github.com/tommzn/recipeboard-core/mock"
+)
+
+// Test suite for unit conversion.
+type ConvertTestSuite struct {
+	suite.Suite
+	repo   *mock.RepositoryMock
+	router LambdaRequestHandler
+}
+
+func TestConvertTestSuite(t *testing.T) {
+	suite.Run(t, new(ConvertTestSuite))
+}
+
+// Setup test.
+func (suite *ConvertTestSuite) SetupTest() {
+	suite.repo = repositoryForTest()
+	suite.router = routerWithFactoryForTest(factoryForTest(suite.repo, publisherForTest(), loggerForTest()), loggerForTest())
+}
+
+// Test converting ingredients to metric units.
+func (suite *ConvertTestSuite) TestConvertToMetric() {
+
+	suite.Equal("250 g flour, sifted", convertIngredient(parseIngredient("2 cups flour, sifted"), metricUnitSystem).Text)
+	suite.Equal("110 g brown sugar", convertIngredient(parseIngredient("1/2 cup brown sugar"), metricUnitSystem).Text)
+	suite.Equal("475 ml milk", convertIngredient(parseIngredient("2 cups milk"), metricUnitSystem).Text)
+	suite.Equal("235 ml buttermilk", convertIngredient(parseIngredient("1 cup buttermilk"), metricUnitSystem).Text)
+	suite.Equal("1.42 l water", convertIngredient(parseIngredient("6 cups water"), metricUnitSystem).Text)
+	suite.Equal("225 g beef", convertIngredient(parseIngredient("8 oz beef"), metricUnitSystem).Text)
+	suite.Equal("1.36 kg pork", convertIngredient(parseIngredient("3 lb pork"), metricUnitSystem).Text)
+	suite.Equal("28 g cheese", convertIngredient(parseIngredient("1 oz cheese"), metricUnitSystem).Text)
+
+	for _, line := range []string{"1 tsp salt", "2 tbsp oil", "100g Mehl", "2 eggs", "Salt, to taste"} {
+		suite.Equal(line, convertIngredient(parseIngredient(line), metricUnitSystem).Text)
+	}
+}
+
+// Test converting ingredients to US customary units.
+func (suite *ConvertTestSuite) TestConvertToUs() {
+
+	suite.Equal("2 cups Mehl", convertIngredient(parseIngredient("250g Mehl"), usUnitSystem).Text)
+	suite.Equal("1/2 cup Zucker", convertIngredient(parseIngredient("100g Zucker"), usUnitSystem).Text)
+	suite.Equal("3 1/2 tbsp Wasser", convertIngredient(parseIngredient("50ml Wasser"), usUnitSystem).Text)
+	suite.Equal("1 cup Milch", convertIngredient(parseIngredient("250 ml Milch"), usUnitSystem).Text)
+	suite.Equal("2 1/4 lb Kartoffeln", convertIngredient(parseIngredient("1 kg Kartoffeln"), usUnitSystem).Text)
+	suite.Equal("17 1/2 oz Hackfleisch", convertIngredient(parseIngredient("500 g Hackfleisch"), usUnitSystem).Text)
+
+	max := 3.0
+	quantity := 2.0
+	converted := convertIngredient(ingredient{Quantity: &quantity, QuantityMax: &max, Unit: "dl", Name: "Sahne"}, usUnitSystem)
+	suite.Equal("cup", converted.Unit)
+	suite.Equal("7/8-1 1/4 cups Sahne", converted.Text)
+
+	for _, line := range []string{"1 cup flour", "2 tbsp oil", "2 eggs", "Salz"} {
+		suite.Equal(line, convertIngredient(parseIngredient(line), usUnitSystem).Text)
+	}
+}
+
+// Test looking up densities of baking ingredients.
+func (suite *ConvertTestSuite) TestIngredientDensity() {
+
+	density, ok := ingredientDensity("Brown Sugar")
+	suite.True(ok)
+	suite.Equal(220.0, density)
+
+	density, ok = ingredientDensity("unsalted butter")
+	suite.True(ok)
+	suite.Equal(227.0, density)
+
+	_, ok = ingredientDensity("buttermilk")
+	suite.False(ok)
+
+	_, ok = ingredientDensity("Kartoffeln")
+	suite.False(ok)
+
+	density, ok = ingredientDensity("Weizenmehl")
+	suite.True(ok)
+	suite.Equal(125.0, density)
+
+	density, ok = ingredientDensity("all-purpose flour")
+	suite.True(ok)
+	suite.Equal(125.0, density)
+
+	density, ok = ingredientDensity("Basmati Rice")
+	suite.True(ok)
+	suite.Equal(185.0, density)
+
+	for _, name := range []string{"Preiselbeeren", "rice vinegar", "sugar snap peas", "goats cheese", "licorice", "Reisessig"} {
+		_, ok = ingredientDensity(name)
+		suite.False(ok, name)
+	}
+}
+
+// Test converting temperatures in recipe texts.
+func (suite *ConvertTestSuite) TestConvertTemperatures() {
+
+	suite.Equal("Bei 350°F backen.", convertTemperatures("Bei 180°C backen.", usUnitSystem))
+	suite.Equal("Bei 400°F Umluft backen.", convertTemperatures("Bei 200 Grad Umluft backen.", usUnitSystem))
+	suite.Equal("Bake at 180°C for 30 minutes.", convertTemperatures("Bake at 350 degrees F for 30 minutes.", metricUnitSystem))
+	suite.Equal("Preheat to 220°C.", convertTemperatures("Preheat to 425 °Fahrenheit.", metricUnitSystem))
+	suite.Equal("Bake at 180°C.", convertTemperatures("Bake at 180°C.", metricUnitSystem))
+	suite.Equal("Warm milk to 104°F.", convertTemperatures("Warm milk to 40 °C.", usUnitSystem))
+	suite.Equal("Turn by 90 degrees for 5 minutes.", convertTemperatures("Turn by 90 degrees for 5 minutes.", usUnitSystem))
+}
+
+// Test converting recipes in GET requests.
+func (suite *ConvertTestSuite) TestGetConvertedRecipe() {
+
+	recipe := recipeForTest()
+	recipe.Ingredients = "250g Mehl\n50ml Wasser\nSalz"
+	recipe.Description = "Bei 180°C backen."
+	suite.repo.Recipes[recipe.Id] = recipe
+
+	request := apiGatewayRequestForTest(http.MethodGet, nil, &recipe.Id)
+	request.QueryStringParameters["units"] = "US"
+	request.QueryStringParameters["scale"] = "2"
+	response := suite.handle(request)
+	suite.Equal(http.StatusOK, response.StatusCode)
+
+	values := struct {
+		Ingredients       string
+		Description       string
+		IngredientsParsed []ingredient `json:"ingredientsParsed"`
+	}{}
+	suite.Nil(json.Unmarshal([]byte(response.Body), &values))
+	suite.Equal("4 cups Mehl\n7 tbsp Wasser\nSalz", values.Ingredients)
+	suite.Equal("Bei 350°F backen.", values.Description)
+	suite.Equal("cup", values.IngredientsParsed[0].Unit)
+	suite.True(values.IngredientsParsed[2].Unscaled)
+
+	request.QueryStringParameters = map[string]string{"units": "imperial"}
+	suite.Equal(http.StatusBadRequest, suite.handle(request).StatusCode)
+}
+
+// handle passes a request to the router.
+func (suite *ConvertTestSuite) handle(request events.APIGatewayProxyRequest) events.APIGatewayProxyResponse {
+	response, err := suite.router.handle(context.Background(), request)
+	suite.Nil(err)
+	return response
+}
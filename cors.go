@@ -0,0 +1,168 @@
+package main
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	config "github.com/tommzn/go-config"
+)
+
+const (
+
+	// defaultCorsAllowedHeaders are request headers browsers are allowed to send if no headers are configured.
+	defaultCorsAllowedHeaders = "Content-Type, Authorization, X-Amz-Date, X-Api-Key, X-Amz-Security-Token, If-Match, If-None-Match"
+
+	// defaultCorsExposedHeaders are response headers browsers are allowed to read if no headers are configured.
+	defaultCorsExposedHeaders = "ETag, Link"
+
+	// defaultCorsMaxAge is the time browsers can cache preflight responses if no max age is configured.
+	defaultCorsMaxAge = 10 * time.Minute
+
+	// anyOrigin allows requests from all origins.
+	anyOrigin = "*"
+)
+
+// newCorsConfig creates CORS settings from passed config. Origins, methods and headers are defined
+// as comma separated lists in cors.allowedorigins, cors.allowedmethods, cors.allowedheaders and cors.exposedheaders.
+// Methods are derived from route table if they're not configured.
+// It returns nil if there're no allowed origins, so CORS is disabled.
+func newCorsConfig(conf config.Config) *corsConfig {
+
+	if conf == nil {
+		return nil
+	}
+	allowedOrigins := splitConfigList(conf.Get("cors.allowedorigins", nil))
+	if len(allowedOrigins) == 0 {
+		return nil
+	}
+	allowedHeaders := defaultCorsAllowedHeaders
+	exposedHeaders := defaultCorsExposedHeaders
+	maxAge := defaultCorsMaxAge
+	return &corsConfig{
+		allowedOrigins: allowedOrigins,
+		allowedMethods: splitConfigList(conf.Get("cors.allowedmethods", nil)),
+		allowedHeaders: splitConfigList(conf.Get("cors.allowedheaders", &allowedHeaders)),
+		exposedHeaders: splitConfigList(conf.Get("cors.exposedheaders", &exposedHeaders)),
+		maxAge:         *conf.GetAsDuration("cors.maxage", &maxAge),
+	}
+}
+
+// responseHeaders returns CORS headers for a response to passed request.
+// No headers are returned if the request has no or a not allowed origin.
+func (cors *corsConfig) responseHeaders(request events.APIGatewayProxyRequest) map[string]string {
+
+	origin, ok := cors.allowedOrigin(headerValue(request, "Origin"))
+	if !ok {
+		return nil
+	}
+	headers := map[string]string{"Access-Control-Allow-Origin": origin}
+	if origin != anyOrigin {
+		headers["Vary"] = "Origin"
+	}
+	if len(cors.exposedHeaders) > 0 {
+		headers["Access-Control-Expose-Headers"] = strings.Join(cors.exposedHeaders, ", ")
+	}
+	return headers
+}
+
+// preflightHeaders returns CORS headers for a preflight request. Passed methods are allowed
+// if there're no methods defined in config.
+func (cors *corsConfig) preflightHeaders(request events.APIGatewayProxyRequest, routeMethods []string) map[string]string {
+
+	headers := cors.responseHeaders(request)
+	if headers == nil || headerValue(request, "Access-Control-Request-Method") == "" {
+		return headers
+	}
+	delete(headers, "Access-Control-Expose-Headers")
+
+	allowedMethods := cors.allowedMethods
+	if len(allowedMethods) == 0 {
+		allowedMethods = routeMethods
+	}
+	headers["Access-Control-Allow-Methods"] = strings.Join(allowedMethods, ", ")
+	if len(cors.allowedHeaders) > 0 {
+		headers["Access-Control-Allow-Headers"] = strings.Join(cors.allowedHeaders, ", ")
+	}
+	headers["Access-Control-Max-Age"] = strconv.Itoa(int(cors.maxAge.Seconds()))
+	return headers
+}
+
+// allowedOrigin checks if passed origin is allowed and returns the value for Access-Control-Allow-Origin header.
+func (cors *corsConfig) allowedOrigin(origin string) (string, bool) {
+
+	if origin == "" {
+		return "", false
+	}
+	for _, allowedOrigin := range cors.allowedOrigins {
+		if allowedOrigin == anyOrigin {
+			return anyOrigin, true
+		}
+		if strings.EqualFold(strings.TrimSuffix(allowedOrigin, "/"), origin) {
+			return origin, true
+		}
+	}
+	return "", false
+}
+
+// preflightResponse answers OPTIONS requests with all methods available for requested path.
+// CORS headers are added if the request comes from an allowed origin.
+func preflightResponse(request events.APIGatewayProxyRequest, cors *corsConfig) events.APIGatewayProxyResponse {
+
+	methods, err := allowedMethodsForRequest(request)
+	if err != nil {
+		return withCorsHeaders(responseWithError(err, http.StatusNotFound, request), request, cors)
+	}
+	methods = append(methods, http.MethodOptions)
+
+	response := responseWithStatus(http.StatusNoContent)
+	response.Headers = map[string]string{"Allow": strings.Join(methods, ", ")}
+	if cors != nil {
+		for name, value := range cors.preflightHeaders(request, methods) {
+			response.Headers[name] = value
+		}
+	}
+	return response
+}
+
+// withCorsHeaders adds CORS headers for passed request to given response.
+func withCorsHeaders(response events.APIGatewayProxyResponse, request events.APIGatewayProxyRequest, cors *corsConfig) events.APIGatewayProxyResponse {
+
+	if cors == nil {
+		return response
+	}
+	headers := cors.responseHeaders(request)
+	if len(headers) == 0 {
+		return response
+	}
+	if response.Headers == nil {
+		response.Headers = make(map[string]string)
+	}
+	for name, value := range headers {
+		existingValue, ok := response.Headers[name]
+		switch {
+		case !ok:
+			response.Headers[name] = value
+		case name == "Vary":
+			response.Headers[name] = existingValue + ", " + value
+		}
+	}
+	return response
+}
+
+// splitConfigList splits a comma separated config value into a list.
+func splitConfigList(value *string) []string {
+
+	list := []string{}
+	if value == nil {
+		return list
+	}
+	for _, element := range strings.Split(*value, ",") {
+		if element = strings.TrimSpace(element); element != "" {
+			list = append(list, element)
+		}
+	}
+	return list
+}
@@ -0,0 +1,136 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+)
+
+// Test suite for cross-origin resource sharing.
+type CorsTestSuite struct {
+	suite.Suite
+}
+
+func TestCorsTestSuite(t *testing.T) {
+	suite.Run(t, new(CorsTestSuite))
+}
+
+// Test creating CORS settings from config.
+func (suite *CorsTestSuite) TestNewCorsConfig() {
+
+	suite.Nil(newCorsConfig(nil))
+	suite.Nil(newCorsConfig(configForTest("log:\n  loglevel: error\n")))
+
+	cors := newCorsConfig(configForTest("cors:\n  allowedorigins: \"https://example.com, https://recipes.example.com\"\n  maxage: 1h\n"))
+	suite.NotNil(cors)
+	suite.Equal([]string{"https://example.com", "https://recipes.example.com"}, cors.allowedOrigins)
+	suite.Len(cors.allowedMethods, 0)
+	suite.Contains(cors.allowedHeaders, "If-Match")
+	suite.Equal([]string{"ETag", "Link"}, cors.exposedHeaders)
+	suite.Equal(time.Hour, cors.maxAge)
+
+	cors = newCorsConfig(configForTest("cors:\n  allowedorigins: \"*\"\n  allowedmethods: GET\n  allowedheaders: Content-Type\n"))
+	suite.NotNil(cors)
+	suite.Equal([]string{"GET"}, cors.allowedMethods)
+	suite.Equal([]string{"Content-Type"}, cors.allowedHeaders)
+	suite.Equal(defaultCorsMaxAge, cors.maxAge)
+}
+
+// Test matching request origins.
+func (suite *CorsTestSuite) TestAllowedOrigin() {
+
+	cors := &corsConfig{allowedOrigins: []string{"https://example.com"}}
+	origin, ok := cors.allowedOrigin("https://example.com")
+	suite.True(ok)
+	suite.Equal("https://example.com", origin)
+
+	_, ok = cors.allowedOrigin("HTTPS://EXAMPLE.COM")
+	suite.True(ok)
+
+	_, ok = cors.allowedOrigin("https://example.org")
+	suite.False(ok)
+
+	_, ok = cors.allowedOrigin("")
+	suite.False(ok)
+
+	cors = &corsConfig{allowedOrigins: []string{"*"}}
+	origin, ok = cors.allowedOrigin("https://example.org")
+	suite.True(ok)
+	suite.Equal("*", origin)
+}
+
+// Test answering preflight requests.
+func (suite *CorsTestSuite) TestPreflightRequest() {
+
+	router := corsRouterForTest("cors:\n  allowedorigins: https://example.com\n  maxage: 5m\n")
+
+	recipeId := "123"
+	request := apiGatewayRequestForTest(http.MethodOptions, nil, &recipeId)
+	request.Headers = map[string]string{"Origin": "https://example.com", "Access-Control-Request-Method": "PUT"}
+	response, err := router.handle(context.Background(), request)
+	suite.Nil(err)
+	suite.Equal(http.StatusNoContent, response.StatusCode)
+	suite.Equal("DELETE, GET, PATCH, PUT, OPTIONS", response.Headers["Allow"])
+	suite.Equal("https://example.com", response.Headers["Access-Control-Allow-Origin"])
+	suite.Equal("DELETE, GET, PATCH, PUT, OPTIONS", response.Headers["Access-Control-Allow-Methods"])
+	suite.Contains(response.Headers["Access-Control-Allow-Headers"], "Content-Type")
+	suite.Equal("300", response.Headers["Access-Control-Max-Age"])
+	suite.Equal("Origin", response.Headers["Vary"])
+	suite.Equal("", response.Body)
+
+	request.Headers["Origin"] = "https://example.org"
+	response, err = router.handle(context.Background(), request)
+	suite.Nil(err)
+	suite.Equal(http.StatusNoContent, response.StatusCode)
+	suite.Equal("DELETE, GET, PATCH, PUT, OPTIONS", response.Headers["Allow"])
+	suite.Equal("", response.Headers["Access-Control-Allow-Origin"])
+
+	request = apiGatewayRequestWithPathForTest(http.MethodOptions, "/recipes/123/images")
+	request.Headers = map[string]string{"Origin": "https://example.com", "Access-Control-Request-Method": "GET"}
+	response, err = router.handle(context.Background(), request)
+	suite.Nil(err)
+	suite.Equal(http.StatusNotFound, response.StatusCode)
+	suite.Equal("https://example.com", response.Headers["Access-Control-Allow-Origin"])
+}
+
+// Test CORS headers are added to successful and error responses.
+func (suite *CorsTestSuite) TestCorsHeadersInResponses() {
+
+	router := corsRouterForTest("cors:\n  allowedorigins: \"*\"\n")
+
+	request := apiGatewayRequestForTest(http.MethodGet, nil, nil)
+	request.Headers = map[string]string{"origin": "https://example.com"}
+	response, err := router.handle(context.Background(), request)
+	suite.Nil(err)
+	suite.Equal(http.StatusOK, response.StatusCode)
+	suite.Equal("*", response.Headers["Access-Control-Allow-Origin"])
+	suite.Equal("ETag, Link", response.Headers["Access-Control-Expose-Headers"])
+	suite.Equal("", response.Headers["Access-Control-Allow-Methods"])
+
+	recipeId := "not-existing"
+	request = apiGatewayRequestForTest(http.MethodGet, nil, &recipeId)
+	request.Headers = map[string]string{"Origin": "https://example.com"}
+	response, err = router.handle(context.Background(), request)
+	suite.Nil(err)
+	suite.Equal(http.StatusNotFound, response.StatusCode)
+	suite.Equal("application/problem+json", response.Headers["Content-Type"])
+	suite.Equal("*", response.Headers["Access-Control-Allow-Origin"])
+
+	request = apiGatewayRequestForTest(http.MethodGet, nil, nil)
+	response, err = router.handle(context.Background(), request)
+	suite.Nil(err)
+	suite.Equal("", response.Headers["Access-Control-Allow-Origin"])
+}
+
+// corsRouterForTest returns a router with mocked dependencies and CORS settings from passed YAML config.
+func corsRouterForTest(yamlConfig string) LambdaRequestHandler {
+	logger := loggerForTest()
+	return &requestRouter{
+		factory: mockedFactoryForTest(logger),
+		cors:    newCorsConfig(configForTest(yamlConfig)),
+		logger:  logger,
+	}
+}
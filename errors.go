@@ -0,0 +1,269 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// problemTypePrefix is used to build problem type URIs for error responses.
+const problemTypePrefix = "urn:recipemanager:problem:"
+
+// newBadRequestError returns an error for malformed requests.
+func newBadRequestError(message string, cause error) error {
+	return &badRequestError{message: message, cause: cause}
+}
+
+// newNotFoundError returns an error for requests to not existing recipes.
+func newNotFoundError(message string, cause error) error {
+	return &notFoundError{message: message, cause: cause}
+}
+
+// newValidationError returns an error for requests with invalid values.
+func newValidationError(message string, cause error) error {
+	return &validationError{message: message, cause: cause}
+}
+
+// newFieldValidationError returns an error for request bodies with invalid fields.
+func newFieldValidationError(fieldErrors []fieldError) error {
+	return &validationError{message: "Request body contains invalid fields.", fieldErrors: fieldErrors}
+}
+
+// newConflictError returns an error for requests which conflicts with the current state of a recipe.
+func newConflictError(message string, cause error) error {
+	return &conflictError{message: message, cause: cause}
+}
+
+// newPreconditionFailedError returns an error for conditional requests which doesn't match the current recipe.
+func newPreconditionFailedError(message string) error {
+	return &preconditionFailedError{message: message}
+}
+
+// newPreconditionRequiredError returns an error for requests without a mandatory condition.
+func newPreconditionRequiredError(message string) error {
+	return &preconditionRequiredError{message: message}
+}
+
+// newForbiddenError returns an error for requests which aren't allowed for the caller.
+func newForbiddenError(message string) error {
+	return &forbiddenError{message: message}
+}
+
+// newPayloadTooLargeError returns an error for responses which exceed the max response size.
+func newPayloadTooLargeError(message string) error {
+	return &payloadTooLargeError{message: message}
+}
+
+// fromServiceError converts errors returned by the recipe service into an error with a suitable status code.
+// Persistence layer reports missing recipes only by a "not found" error message.
+func fromServiceError(err error) error {
+
+	if err == nil {
+		return nil
+	}
+	if strings.Contains(strings.ToLower(err.Error()), "not found") {
+		return newNotFoundError("", err)
+	}
+	return err
+}
+
+// statusCodeForError returns the HTTP status code defined by passed error or given default status code
+// if it's an untyped error.
+func statusCodeForError(err error, defaultStatusCode int) int {
+
+	var statusError httpStatusError
+	if errors.As(err, &statusError) {
+		return statusError.statusCode()
+	}
+	return defaultStatusCode
+}
+
+// newProblemDetails creates a problem details response body for passed error and status code.
+// Details of internal errors are not exposed to clients.
+func newProblemDetails(err error, statusCode int, path, requestId string) problemDetails {
+
+	problem := problemDetails{
+		Type:      problemTypeForStatus(statusCode),
+		Title:     http.StatusText(statusCode),
+		Status:    statusCode,
+		Detail:    errorDetail(err, statusCode),
+		Instance:  path,
+		RequestId: requestId,
+	}
+	var validationErr *validationError
+	if errors.As(err, &validationErr) {
+		problem.Errors = validationErr.fieldErrors
+	}
+	return problem
+}
+
+// errorDetail returns the message of passed error which can be returned to a client. Messages of internal errors
+// can contain details of underlying services, so they're replaced by a generic message.
+func errorDetail(err error, statusCode int) string {
+	if statusCode >= http.StatusInternalServerError {
+		return "Unable to process request."
+	}
+	return err.Error()
+}
+
+// problemTypeForStatus returns a problem type URI derived from passed HTTP status code,
+// e.g. urn:recipemanager:problem:not-found.
+func problemTypeForStatus(statusCode int) string {
+	statusText := strings.ToLower(http.StatusText(statusCode))
+	if statusText == "" {
+		return "about:blank"
+	}
+	return problemTypePrefix + strings.Replace(statusText, " ", "-", -1)
+}
+
+// Error returns the error message.
+func (err *badRequestError) Error() string {
+	return errorMessage(err.message, err.cause)
+}
+
+// Unwrap returns the underlying error.
+func (err *badRequestError) Unwrap() error {
+	return err.cause
+}
+
+// statusCode returns HTTP status 400.
+func (err *badRequestError) statusCode() int {
+	return http.StatusBadRequest
+}
+
+// Error returns the error message.
+func (err *notFoundError) Error() string {
+	return errorMessage(err.message, err.cause)
+}
+
+// Unwrap returns the underlying error.
+func (err *notFoundError) Unwrap() error {
+	return err.cause
+}
+
+// statusCode returns HTTP status 404.
+func (err *notFoundError) statusCode() int {
+	return http.StatusNotFound
+}
+
+// Error returns the error message including all field errors.
+func (err *validationError) Error() string {
+
+	message := errorMessage(err.message, err.cause)
+	if len(err.fieldErrors) == 0 {
+		return message
+	}
+	fieldMessages := []string{}
+	for _, fieldErr := range err.fieldErrors {
+		fieldMessages = append(fieldMessages, fieldErr.Field+": "+fieldErr.Message)
+	}
+	return fmt.Sprintf("%s %s", message, strings.Join(fieldMessages, ", "))
+}
+
+// Unwrap returns the underlying error.
+func (err *validationError) Unwrap() error {
+	return err.cause
+}
+
+// statusCode returns HTTP status 422.
+func (err *validationError) statusCode() int {
+	return http.StatusUnprocessableEntity
+}
+
+// Error returns the error message.
+func (err *conflictError) Error() string {
+	return errorMessage(err.message, err.cause)
+}
+
+// Unwrap returns the underlying error.
+func (err *conflictError) Unwrap() error {
+	return err.cause
+}
+
+// statusCode returns HTTP status 409.
+func (err *conflictError) statusCode() int {
+	return http.StatusConflict
+}
+
+// errorMessage combines passed message with the message of given cause.
+func errorMessage(message string, cause error) string {
+
+	if cause == nil {
+		return message
+	}
+	if message == "" {
+		return strings.TrimSpace(cause.Error())
+	}
+	return fmt.Sprintf("%s: %s", message, strings.TrimSpace(cause.Error()))
+}
+
+// Error returns the error message.
+func (err *preconditionFailedError) Error() string {
+	return err.message
+}
+
+// statusCode returns HTTP status 412.
+func (err *preconditionFailedError) statusCode() int {
+	return http.StatusPreconditionFailed
+}
+
+// Error returns the error message.
+func (err *forbiddenError) Error() string {
+	return err.message
+}
+
+// statusCode returns HTTP status 403.
+func (err *forbiddenError) statusCode() int {
+	return http.StatusForbidden
+}
+
+// Error returns the error message.
+func (err *payloadTooLargeError) Error() string {
+	return err.message
+}
+
+// statusCode returns HTTP status 413.
+func (err *payloadTooLargeError) statusCode() int {
+	return http.StatusRequestEntityTooLarge
+}
+
+// Error returns the error message.
+func (err *preconditionRequiredError) Error() string {
+	return err.message
+}
+
+// statusCode returns HTTP status 428.
+func (err *preconditionRequiredError) statusCode() int {
+	return http.StatusPreconditionRequired
+}
+
+// Error returns a message with all media types a response can be rendered in.
+func (err *notAcceptableError) Error() string {
+	return fmt.Sprintf("Not acceptable: %s, available: %s", err.accept, strings.Join(err.supportedTypes, ", "))
+}
+
+// statusCode returns HTTP status 406.
+func (err *notAcceptableError) statusCode() int {
+	return http.StatusNotAcceptable
+}
+
+// Error returns a message with the entity tag of a not modified recipe.
+func (err *notModifiedError) Error() string {
+	return "Not modified: " + err.etag
+}
+
+// statusCode returns HTTP status 304.
+func (err *notModifiedError) statusCode() int {
+	return http.StatusNotModified
+}
+
+// Error returns a message with the content type of a request.
+func (err *unsupportedMediaTypeError) Error() string {
+	return fmt.Sprintf("Unsupported content type: %s, supported: %s", err.contentType, strings.Join(err.supportedTypes, ", "))
+}
+
+// statusCode returns HTTP status 415.
+func (err *unsupportedMediaTypeError) statusCode() int {
+	return http.StatusUnsupportedMediaType
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+)
+
+// Test suite for error handling.
+type ErrorsTestSuite struct {
+	suite.Suite
+}
+
+func TestErrorsTestSuite(t *testing.T) {
+	suite.Run(t, new(ErrorsTestSuite))
+}
+
+// Test converting recipe service errors.
+func (suite *ErrorsTestSuite) TestFromServiceError() {
+
+	suite.Nil(fromServiceError(nil))
+
+	serviceError := errors.New("Not found: RECIPEBOARD_RECIPE/123\n")
+	err := fromServiceError(serviceError)
+	suite.IsType(&notFoundError{}, err)
+	suite.Equal("Not found: RECIPEBOARD_RECIPE/123", err.Error())
+	suite.True(errors.Is(err, serviceError))
+
+	otherError := errors.New("Connection refused.")
+	suite.Equal(otherError, fromServiceError(otherError))
+}
+
+// Test status codes of typed and untyped errors.
+func (suite *ErrorsTestSuite) TestStatusCodeForError() {
+
+	suite.Equal(http.StatusUnprocessableEntity, statusCodeForError(newValidationError("Invalid title.", nil), http.StatusBadRequest))
+	suite.Equal(http.StatusBadRequest, statusCodeForError(errors.New("Invalid title."), http.StatusBadRequest))
+	suite.Equal(http.StatusNotFound, statusCodeForError(&routeNotFoundError{path: "/xxx"}, http.StatusInternalServerError))
+	suite.Equal(http.StatusPreconditionFailed, statusCodeForError(newPreconditionFailedError("Recipe has been changed."), http.StatusInternalServerError))
+	suite.Equal(http.StatusNotAcceptable, statusCodeForError(&notAcceptableError{accept: "text/html", supportedTypes: []string{jsonMediaType}}, http.StatusInternalServerError))
+	suite.Equal(http.StatusPreconditionRequired, statusCodeForError(newPreconditionRequiredError("If-Match is required."), http.StatusInternalServerError))
+	suite.Equal(http.StatusNotModified, statusCodeForError(&notModifiedError{etag: `"123"`}, http.StatusInternalServerError))
+	suite.Equal(http.StatusForbidden, statusCodeForError(newForbiddenError("Not allowed."), http.StatusInternalServerError))
+	suite.Equal(http.StatusRequestEntityTooLarge, statusCodeForError(newPayloadTooLargeError("Export is too large."), http.StatusInternalServerError))
+}
+
+// Test creating problem details for errors.
+func (suite *ErrorsTestSuite) TestNewProblemDetails() {
+
+	problem := newProblemDetails(newConflictError("Recipe 123 already exists.", nil), http.StatusConflict, "/recipes", "req-1")
+	suite.Equal("urn:recipemanager:problem:conflict", problem.Type)
+	suite.Equal("Conflict", problem.Title)
+	suite.Equal(http.StatusConflict, problem.Status)
+	suite.Equal("Recipe 123 already exists.", problem.Detail)
+	suite.Equal("/recipes", problem.Instance)
+	suite.Equal("req-1", problem.RequestId)
+
+	internalProblem := newProblemDetails(errors.New("Secret connection details."), http.StatusInternalServerError, "/recipes", "req-2")
+	suite.Equal("urn:recipemanager:problem:internal-server-error", internalProblem.Type)
+	suite.NotContains(internalProblem.Detail, "Secret")
+}
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+
+	"github.com/aws/aws-lambda-go/events"
+	model "github.com/tommzn/recipeboard-core/model"
+)
+
+// anyETag is used in conditional headers to match any current representation of a recipe.
+const anyETag = "*"
+
+// weakETagPrefix marks weak entity tags, which never match in a strong comparison.
+const weakETagPrefix = "W/"
+
+// etagForBody returns a strong entity tag for passed response body.
+func etagForBody(body string) string {
+	hash := sha256.Sum256([]byte(body))
+	return `"` + hex.EncodeToString(hash[:16]) + `"`
+}
+
+// recipeETag returns the entity tag of the full JSON representation of passed recipe.
+func recipeETag(recipe model.Recipe) (string, error) {
+	body, err := marshalRecipe(recipe, nil)
+	if err != nil {
+		return "", err
+	}
+	return etagForBody(*body), nil
+}
+
+// parse extracts If-Match and If-None-Match headers from passed request.
+func (conditions *conditionalRequest) parse(request events.APIGatewayProxyRequest) {
+	conditions.ifMatch = headerValue(request, "If-Match")
+	conditions.ifNoneMatch = headerValue(request, "If-None-Match")
+}
+
+// variantETag returns a weak entity tag derived from the entity tag of passed recipe and given variant, which describes
+// a representation by media type, fields, scaling and unit system. It's used for all representations of a recipe except
+// the full JSON representation, so each representation has it's own entity tag.
+// Because it's weak it's not accepted in If-Match headers.
+func variantETag(recipe model.Recipe, variant string) (string, error) {
+	etag, err := recipeETag(recipe)
+	if err != nil {
+		return "", err
+	}
+	return weakETagPrefix + etagForBody(etag+"\n"+variant), nil
+}
+
+// checkIfMatch compares the If-Match header with the entity tag of passed recipe as defined in RFC 7232.
+// Only the strong entity tag of the full JSON representation matches, weak entity tags of other representations never match.
+// It returns a precondition failed error if none of passed entity tags matches and a precondition required error
+// if If-Match header is missing, but it's mandatory for changes.
+// Passed recipe has to be loaded right before it's changed. The check isn't atomic, a concurrent change
+// between loading and updating a recipe can't be detected.
+func (conditions conditionalRequest) checkIfMatch(recipe model.Recipe) error {
+
+	if conditions.ifMatch == "" {
+		if conditions.ifMatchRequired {
+			return newPreconditionRequiredError("If-Match header is required to change a recipe.")
+		}
+		return nil
+	}
+	etag, err := recipeETag(recipe)
+	if err != nil {
+		return err
+	}
+	for _, candidate := range etagList(conditions.ifMatch) {
+		if candidate == anyETag || candidate == etag {
+			return nil
+		}
+	}
+	return newPreconditionFailedError("Recipe has been changed, If-Match doesn't match current ETag " + etag)
+}
+
+// checkIfNoneMatch compares the If-None-Match header with passed entity tag using weak comparison.
+// It returns a not modified error if one of passed entity tags matches.
+func (conditions conditionalRequest) checkIfNoneMatch(etag string) error {
+
+	for _, candidate := range etagList(conditions.ifNoneMatch) {
+		if candidate == anyETag || strings.TrimPrefix(candidate, weakETagPrefix) == strings.TrimPrefix(etag, weakETagPrefix) {
+			return &notModifiedError{etag: etag}
+		}
+	}
+	return nil
+}
+
+// etagList splits passed header value into a list of entity tags.
+func etagList(headerValue string) []string {
+
+	etags := []string{}
+	for _, etag := range strings.Split(headerValue, ",") {
+		if etag = strings.TrimSpace(etag); etag != "" {
+			etags = append(etags, etag)
+		}
+	}
+	return etags
+}
+
+// marshalRecipeWithETag returns JSON string of passed recipe, reduced to given fields, and the entity tag of it.
+func marshalRecipeWithETag(recipe model.Recipe, fields []string) (*string, string, error) {
+	body, err := marshalRecipe(recipe, fields)
+	if err != nil {
+		return nil, "", err
+	}
+	return body, etagForBody(*body), nil
+}
+
+// etagHeaders returns response headers for passed entity tag.
+func etagHeaders(etag string) map[string]string {
+	if etag == "" {
+		return nil
+	}
+	return map[string]string{"ETag": etag}
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+)
+
+// Test suite for entity tags and conditional requests.
+type ETagTestSuite struct {
+	suite.Suite
+}
+
+func TestETagTestSuite(t *testing.T) {
+	suite.Run(t, new(ETagTestSuite))
+}
+
+// Test entity tags of recipes.
+func (suite *ETagTestSuite) TestRecipeETag() {
+
+	recipe := recipeForTest()
+	etag, err := recipeETag(recipe)
+	suite.Nil(err)
+	suite.Len(etag, 34)
+	suite.Regexp(`^"[0-9a-f]{32}"$`, etag)
+
+	etag2, _ := recipeETag(recipe)
+	suite.Equal(etag, etag2)
+
+	recipe.Title = "New Title"
+	etag3, _ := recipeETag(recipe)
+	suite.NotEqual(etag, etag3)
+
+	body, etag4, err := marshalRecipeWithETag(recipe, nil)
+	suite.Nil(err)
+	suite.Equal(etag3, etag4)
+	suite.Equal(etagForBody(*body), etag4)
+
+	etag5, err := variantETag(recipe, "text/markdown;;;")
+	suite.Nil(err)
+	suite.Regexp(`^W/"[0-9a-f]{32}"$`, etag5)
+	etag6, _ := variantETag(recipe, "text/markdown;;;")
+	suite.Equal(etag5, etag6)
+	etag7, _ := variantETag(recipe, "text/html;;;")
+	suite.NotEqual(etag5, etag7)
+	suite.NotEqual(strings.TrimPrefix(etag5, weakETagPrefix), etag3)
+}
+
+// Test evaluating If-Match headers.
+func (suite *ETagTestSuite) TestCheckIfMatch() {
+
+	recipe := recipeForTest()
+	etag, _ := recipeETag(recipe)
+
+	suite.Nil(conditionalRequest{}.checkIfMatch(recipe))
+	suite.Nil(conditionalRequest{ifMatch: etag}.checkIfMatch(recipe))
+	suite.Nil(conditionalRequest{ifMatch: `"xxx", ` + etag}.checkIfMatch(recipe))
+	suite.Nil(conditionalRequest{ifMatch: "*"}.checkIfMatch(recipe))
+
+	suite.IsType(&preconditionFailedError{}, conditionalRequest{ifMatch: `"xxx"`}.checkIfMatch(recipe))
+	suite.IsType(&preconditionFailedError{}, conditionalRequest{ifMatch: weakETagPrefix + etag}.checkIfMatch(recipe))
+	suite.IsType(&preconditionRequiredError{}, conditionalRequest{ifMatchRequired: true}.checkIfMatch(recipe))
+}
+
+// Test evaluating If-None-Match headers.
+func (suite *ETagTestSuite) TestCheckIfNoneMatch() {
+
+	etag := etagForBody("{}")
+	suite.Nil(conditionalRequest{}.checkIfNoneMatch(etag))
+	suite.Nil(conditionalRequest{ifNoneMatch: `"xxx"`}.checkIfNoneMatch(etag))
+
+	for _, ifNoneMatch := range []string{etag, weakETagPrefix + etag, `"xxx",` + etag, "*"} {
+		err := conditionalRequest{ifNoneMatch: ifNoneMatch}.checkIfNoneMatch(etag)
+		suite.IsType(&notModifiedError{}, err, ifNoneMatch)
+		suite.Equal(etag, err.(*notModifiedError).etag)
+	}
+	suite.IsType(&notModifiedError{}, conditionalRequest{ifNoneMatch: etag}.checkIfNoneMatch(weakETagPrefix+etag))
+}
@@ -0,0 +1,245 @@
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/base64"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/aws/aws-lambda-go/events"
+	model "github.com/tommzn/recipeboard-core/model"
+)
+
+// zipMediaType is the content type of zip archives.
+const zipMediaType = "application/zip"
+
+// maxExportSize is the max size of an export in a response, as JSON encoded string. Response of a Lambda function is limited
+// to 6 MB, some space is left for headers.
+const maxExportSize = 5 * 1024 * 1024
+
+// exportMediaTypes contains all media types recipes can be exported in. NDJSON is used by default.
+var exportMediaTypes = []string{ndjsonMediaType, csvMediaType, zipMediaType}
+
+// exportFormats maps values of format query param to export media types.
+var exportFormats = map[string]string{
+	"ndjson":   ndjsonMediaType,
+	"csv":      csvMediaType,
+	"markdown": zipMediaType,
+	"zip":      zipMediaType,
+}
+
+// exportFileExtensions defines the file extension of exports for each media type.
+var exportFileExtensions = map[string]string{
+	ndjsonMediaType: "ndjson",
+	csvMediaType:    "csv",
+	zipMediaType:    "zip",
+}
+
+// parseRequest will extract recipe types, filters, fields and the export format from passed request.
+// Export format can be defined by format query param, which takes precedence over the Accept header,
+// so exports can be downloaded by simple links.
+func (handler *apiGatewayExportRequestHandler) parseRequest(request events.APIGatewayProxyRequest) error {
+
+	recipeTypes, err := parseRecipeTypes(request)
+	if err != nil {
+		return err
+	}
+	handler.recipeTypes = recipeTypes
+
+	filter, err := parseRecipeFilter(request.QueryStringParameters)
+	if err != nil {
+		return err
+	}
+	handler.filter = filter
+
+	fields, err := parseFields(request.QueryStringParameters)
+	if err != nil {
+		return err
+	}
+	handler.fields = fields
+
+	handler.representations = exportMediaTypes
+	if format, ok := request.QueryStringParameters["format"]; ok {
+		mediaType, ok := exportFormats[strings.ToLower(format)]
+		if !ok {
+			return newBadRequestError(fmt.Sprintf("Unsupported export format: %s, supported: ndjson, csv, markdown", format), nil)
+		}
+		handler.representations = []string{mediaType}
+	}
+	return nil
+}
+
+// handle GET requests to export all matching recipes, ordered by creation time.
+func (handler *apiGatewayExportRequestHandler) handle() (*string, error) {
+
+	recipes, err := listRecipes(handler.recipeService, handler.recipeTypes)
+	if err != nil {
+		return nil, err
+	}
+	recipes = filterRecipes(recipes, handler.filter)
+	sortRecipes(recipes, defaultSortOrder)
+
+	var body *string
+	switch handler.mediaType {
+	case csvMediaType:
+		body, err = exportCsv(recipes, handler.fields)
+	case zipMediaType:
+		body, err = exportMarkdownZip(recipes)
+	default:
+		body, err = exportNdjson(recipes, handler.fields)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return body, checkExportSize(*body, maxExportSize)
+}
+
+// checkExportSize returns a payload too large error if passed export exceeds given max size. Size is calculated
+// after JSON encoding, because escaped characters increase the size of a Lambda response.
+func checkExportSize(export string, maxSize int) error {
+
+	encoded, err := json.Marshal(export)
+	if err != nil {
+		return err
+	}
+	if len(encoded) > maxSize {
+		return newPayloadTooLargeError(fmt.Sprintf("Export is too large: %d bytes, max: %d. Use filters like recipetype, createdafter or createdbefore to export recipes in parts.", len(encoded), maxSize))
+	}
+	return nil
+}
+
+// mediaTypes returns all media types recipes can be exported in.
+func (handler *apiGatewayExportRequestHandler) mediaTypes() []string {
+	return handler.representations
+}
+
+// useMediaType defines the media type recipes are exported in.
+func (handler *apiGatewayExportRequestHandler) useMediaType(mediaType string) {
+	handler.mediaType = mediaType
+}
+
+// responseHeaders returns a Content-Disposition header, so exports are downloaded as file.
+func (handler *apiGatewayExportRequestHandler) responseHeaders() map[string]string {
+	return map[string]string{
+		"Content-Disposition": fmt.Sprintf("attachment; filename=\"recipes.%s\"", exportFileExtensions[handler.mediaType]),
+	}
+}
+
+// isBase64Encoded returns true for zip archives.
+func (handler *apiGatewayExportRequestHandler) isBase64Encoded() bool {
+	return handler.mediaType == zipMediaType
+}
+
+// exportNdjson returns passed recipes as newline delimited JSON, reduced to given fields.
+// Each line has the same format as a recipe returned by GET requests.
+func exportNdjson(recipes []model.Recipe, fields []string) (*string, error) {
+
+	export := strings.Builder{}
+	for _, recipe := range recipes {
+		line, err := marshalRecipe(recipe, fields)
+		if err != nil {
+			return nil, err
+		}
+		export.WriteString(*line + "\n")
+	}
+	body := export.String()
+	return &body, nil
+}
+
+// exportCsv returns passed recipes as CSV document with a header row of all given fields.
+// Values have the same format as in request bodies, so an export can be imported again.
+// Derived fields, e.g. parsed ingredients, are not exported.
+func exportCsv(recipes []model.Recipe, fields []string) (*string, error) {
+
+	if fields == nil {
+		fields = recipeFields
+	}
+	csvFields := []string{}
+	for _, field := range fields {
+		if !containsString(derivedRecipeFields, field) {
+			csvFields = append(csvFields, field)
+		}
+	}
+	fields = csvFields
+	export := bytes.Buffer{}
+	writer := csv.NewWriter(&export)
+	if err := writer.Write(fields); err != nil {
+		return nil, err
+	}
+	for _, recipe := range recipes {
+		values := recipeToValues(recipe)
+		record := []string{}
+		for _, field := range fields {
+			record = append(record, fmt.Sprintf("%v", values[field]))
+		}
+		if err := writer.Write(record); err != nil {
+			return nil, err
+		}
+	}
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return nil, err
+	}
+	body := export.String()
+	return &body, nil
+}
+
+// exportMarkdownZip returns a base64 encoded zip archive with a Markdown file for each passed recipe.
+func exportMarkdownZip(recipes []model.Recipe) (*string, error) {
+
+	archive := bytes.Buffer{}
+	writer := zip.NewWriter(&archive)
+	fileNames := make(map[string]bool)
+	for _, recipe := range recipes {
+		header := &zip.FileHeader{Name: exportFileName(recipe, fileNames), Method: zip.Deflate}
+		if !recipe.CreatedAt.IsZero() {
+			header.Modified = recipe.CreatedAt
+		}
+		file, err := writer.CreateHeader(header)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := file.Write([]byte(*renderRecipeMarkdown(recipe))); err != nil {
+			return nil, err
+		}
+	}
+	if err := writer.Close(); err != nil {
+		return nil, err
+	}
+	body := base64.StdEncoding.EncodeToString(archive.Bytes())
+	return &body, nil
+}
+
+// exportFileName returns a unique Markdown file name for passed recipe, derived from it's title.
+// All file names already in use have to be passed, new file name is added.
+func exportFileName(recipe model.Recipe, fileNames map[string]bool) string {
+
+	slug := strings.Builder{}
+	separator := false
+	for _, char := range strings.ToLower(recipe.Title) {
+		if unicode.IsLetter(char) || unicode.IsDigit(char) {
+			if separator && slug.Len() > 0 {
+				slug.WriteRune('-')
+			}
+			slug.WriteRune(char)
+			separator = false
+		} else {
+			separator = true
+		}
+	}
+	baseName := slug.String()
+	if baseName == "" {
+		baseName = "recipe"
+	}
+
+	fileName := baseName + ".md"
+	for count := 2; fileNames[fileName]; count++ {
+		fileName = fmt.Sprintf("%s-%d.md", baseName, count)
+	}
+	fileNames[fileName] = true
+	return fileName
+}
@@ -0,0 +1,204 @@
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/base64"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/stretchr/testify/suite"
+	"github.com/tommzn/recipeboard-core/mock"
+	model "github.com/tommzn/recipeboard-core/model"
+)
+
+// Test suite for recipe exports.
+type ExportTestSuite struct {
+	suite.Suite
+	repo   *mock.RepositoryMock
+	router LambdaRequestHandler
+}
+
+func TestExportTestSuite(t *testing.T) {
+	suite.Run(t, new(ExportTestSuite))
+}
+
+// Setup test with a cake, a cooking recipe and a cake with the same title.
+func (suite *ExportTestSuite) SetupTest() {
+
+	suite.repo = repositoryForTest()
+	suite.router = routerForTest(suite.repo, publisherForTest(), loggerForTest())
+
+	createdAt := time.Date(2021, 6, 1, 12, 0, 0, 0, time.UTC)
+	for idx, recipe := range []model.Recipe{recipeForTest(), recipeForTest(), recipeForTest()} {
+		recipe.CreatedAt = createdAt.AddDate(0, 0, idx)
+		if idx == 1 {
+			recipe.Type = model.CookingRecipe
+			recipe.Title = "Pasta, \"al dente\""
+		}
+		suite.repo.Recipes[recipe.Id] = recipe
+	}
+}
+
+// Test exporting recipes as newline delimited JSON.
+func (suite *ExportTestSuite) TestExportNdjson() {
+
+	response, err := suite.router.handle(context.Background(), exportRequestForTest(nil))
+	suite.Nil(err)
+	suite.Equal(http.StatusOK, response.StatusCode)
+	suite.Equal("application/x-ndjson; charset=utf-8", response.Headers["Content-Type"])
+	suite.Equal(`attachment; filename="recipes.ndjson"`, response.Headers["Content-Disposition"])
+	suite.False(response.IsBase64Encoded)
+
+	lines := strings.Split(strings.TrimSuffix(response.Body, "\n"), "\n")
+	suite.Len(lines, 3)
+	for _, line := range lines {
+		recipe, err := getRecipeFromResponse(events.APIGatewayProxyResponse{Body: line})
+		suite.Nil(err)
+		suite.Equal(suite.repo.Recipes[recipe.Id].Title, recipe.Title)
+	}
+	suite.Contains(lines[1], "Pasta")
+
+	response, err = suite.router.handle(context.Background(), exportRequestForTest(map[string]string{"recipetype": "baking", "createdafter": "2021-06-03", "fields": "title"}))
+	suite.Nil(err)
+	suite.Equal("{\"Title\":\"Bake a Cake\"}\n", response.Body)
+
+	response, err = suite.router.handle(context.Background(), exportRequestForTest(map[string]string{"createdafter": "2022-01-01"}))
+	suite.Nil(err)
+	suite.Equal(http.StatusOK, response.StatusCode)
+	suite.Equal("", response.Body)
+}
+
+// Test exporting recipes as CSV, which can be imported again.
+func (suite *ExportTestSuite) TestExportCsv() {
+
+	request := exportRequestForTest(nil)
+	request.Headers = map[string]string{"Accept": "text/csv"}
+	response, err := suite.router.handle(context.Background(), request)
+	suite.Nil(err)
+	suite.Equal(http.StatusOK, response.StatusCode)
+	suite.Equal("text/csv; charset=utf-8", response.Headers["Content-Type"])
+	suite.True(strings.HasPrefix(response.Body, "id,type,title,ingredients,description,createdat\n"))
+	suite.Contains(response.Body, ",cooking,\"Pasta, \"\"al dente\"\"\",\"100g Mehl\n100g Zucker\n50ml Wasser\",")
+	suite.Contains(response.Body, ",2021-06-02T12:00:00Z\n")
+
+	items, err := parseCsvImportItems(response.Body)
+	suite.Nil(err)
+	suite.Len(items, 3)
+	for _, item := range items {
+		recipe, err := recipeFromRequestBody(item.values, newRecipeSchema)
+		suite.Nil(err)
+		suite.Equal(suite.repo.Recipes[recipe.Id], *recipe)
+	}
+
+	response, err = suite.router.handle(context.Background(), exportRequestForTest(map[string]string{"format": "csv", "fields": "title,type", "recipetype": "cooking"}))
+	suite.Nil(err)
+	suite.Equal("title,type\n\"Pasta, \"\"al dente\"\"\",cooking\n", response.Body)
+}
+
+// Test exporting recipes as zip archive of Markdown files.
+func (suite *ExportTestSuite) TestExportMarkdownZip() {
+
+	response, err := suite.router.handle(context.Background(), exportRequestForTest(map[string]string{"format": "markdown"}))
+	suite.Nil(err)
+	suite.Equal(http.StatusOK, response.StatusCode)
+	suite.Equal("application/zip", response.Headers["Content-Type"])
+	suite.Equal(`attachment; filename="recipes.zip"`, response.Headers["Content-Disposition"])
+	suite.True(response.IsBase64Encoded)
+
+	content, err := base64.StdEncoding.DecodeString(response.Body)
+	suite.Nil(err)
+	archive, err := zip.NewReader(bytes.NewReader(content), int64(len(content)))
+	suite.Nil(err)
+	suite.Len(archive.File, 3)
+	suite.Equal([]string{"bake-a-cake.md", "pasta-al-dente.md", "bake-a-cake-2.md"}, []string{archive.File[0].Name, archive.File[1].Name, archive.File[2].Name})
+
+	file, err := archive.File[0].Open()
+	suite.Nil(err)
+	markdown, err := ioutil.ReadAll(file)
+	suite.Nil(err)
+	suite.True(strings.HasPrefix(string(markdown), "# Bake a Cake\n"))
+}
+
+// Test invalid export requests.
+func (suite *ExportTestSuite) TestInvalidExportRequests() {
+
+	response, err := suite.router.handle(context.Background(), exportRequestForTest(map[string]string{"format": "pdf"}))
+	suite.Nil(err)
+	suite.Equal(http.StatusBadRequest, response.StatusCode)
+
+	response, err = suite.router.handle(context.Background(), exportRequestForTest(map[string]string{"recipetype": "frying"}))
+	suite.Nil(err)
+	suite.Equal(http.StatusBadRequest, response.StatusCode)
+
+	request := exportRequestForTest(map[string]string{"format": "csv"})
+	request.Headers = map[string]string{"Accept": "application/x-ndjson"}
+	response, err = suite.router.handle(context.Background(), request)
+	suite.Nil(err)
+	suite.Equal(http.StatusNotAcceptable, response.StatusCode)
+}
+
+// Test rejecting exports which exceed the max response size.
+func (suite *ExportTestSuite) TestExportSizeLimit() {
+
+	suite.Nil(checkExportSize(strings.Repeat("x", 10), 12))
+	suite.IsType(&payloadTooLargeError{}, checkExportSize(strings.Repeat("x", 11), 12))
+	suite.IsType(&payloadTooLargeError{}, checkExportSize(strings.Repeat("\"", 6), 12))
+
+	for i := 0; i < 60; i++ {
+		recipe := recipeForTest()
+		recipe.Description = strings.Repeat("Stir well. ", 10000)
+		suite.repo.Recipes[recipe.Id] = recipe
+	}
+	response, err := suite.router.handle(context.Background(), exportRequestForTest(map[string]string{"format": "ndjson"}))
+	suite.Nil(err)
+	suite.Equal(http.StatusRequestEntityTooLarge, response.StatusCode)
+}
+
+// Test file names of exported recipes.
+func (suite *ExportTestSuite) TestExportFileName() {
+
+	fileNames := make(map[string]bool)
+	suite.Equal("apfelstrudel-für-4.md", exportFileName(model.Recipe{Title: " Apfelstrudel für 4! "}, fileNames))
+	suite.Equal("apfelstrudel-für-4-2.md", exportFileName(model.Recipe{Title: "Apfelstrudel (für 4)"}, fileNames))
+	suite.Equal("recipe.md", exportFileName(model.Recipe{Title: "???"}, fileNames))
+}
+
+// Test exported recipes can be imported again with their id and creation time.
+func (suite *ExportTestSuite) TestExportImportRoundTrip() {
+
+	for format, contentType := range map[string]string{"ndjson": ndjsonMediaType, "csv": csvMediaType} {
+
+		response, err := suite.router.handle(context.Background(), exportRequestForTest(map[string]string{"format": format}))
+		suite.Nil(err)
+		suite.Equal(http.StatusOK, response.StatusCode)
+
+		repo := repositoryForTest()
+		router := routerForTest(repo, publisherForTest(), loggerForTest())
+		response, err = router.handle(context.Background(), importRequestForTest(contentType, response.Body))
+		suite.Nil(err)
+		suite.Equal(http.StatusOK, response.StatusCode)
+
+		suite.Len(repo.Recipes, len(suite.repo.Recipes), format)
+		for id, recipe := range suite.repo.Recipes {
+			imported, ok := repo.Recipes[id]
+			suite.True(ok, format)
+			suite.Equal(recipe.Title, imported.Title, format)
+			suite.True(recipe.CreatedAt.Equal(imported.CreatedAt), format)
+		}
+	}
+}
+
+// exportRequestForTest returns a request to export recipes with given query params.
+func exportRequestForTest(queryParams map[string]string) events.APIGatewayProxyRequest {
+	request := apiGatewayRequestWithPathForTest(http.MethodGet, "/recipes:export")
+	for key, value := range queryParams {
+		request.QueryStringParameters[key] = value
+	}
+	return request
+}
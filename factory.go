@@ -1,8 +1,7 @@
 package main
 
 import (
-	"fmt"
-	"net/http"
+	"time"
 
 	"github.com/aws/aws-lambda-go/events"
 	config "github.com/tommzn/go-config"
@@ -18,40 +17,339 @@ func newRequestHandlerFactory(config config.Config, logger log.Logger) handlerFa
 	}
 }
 
-// handlerForRequest returns a handler depenending on passed request.
+// handlerForRequest returns a handler for the route matching passed request.
+// Caller of passed request is used as author for all recipe revisions written by this handler.
 func (factory *requestHandlerFactory) handlerForRequest(request events.APIGatewayProxyRequest) (apiGatewayRequestHandler, error) {
 
-	switch request.HTTPMethod {
-	case http.MethodGet:
-		return &apiGatewayGetRequestHandler{
-			recipeService: factory.getRecipeService(),
-			logger:        factory.logger,
-		}, nil
-	case http.MethodPost:
-		return &apiGatewayPostRequestHandler{
-			recipeService: factory.getRecipeService(),
-			logger:        factory.logger,
-		}, nil
-	case http.MethodPut:
-		return &apiGatewayPutRequestHandler{
-			recipeService: factory.getRecipeService(),
-			logger:        factory.logger,
-		}, nil
-	case http.MethodDelete:
-		return &apiGatewayDeleteRequestHandler{
-			recipeService: factory.getRecipeService(),
-			logger:        factory.logger,
-		}, nil
-	default:
-		return nil, fmt.Errorf("Unsupported HTTP method: %s", request.HTTPMethod)
-	}
-}
-
-// getRecipeService returns the core recipe service.
+	route, pathParams, err := matchRoute(request)
+	if err != nil {
+		return nil, err
+	}
+	factory.author = requestAuthor(request)
+	return &routedRequestHandler{
+		apiGatewayRequestHandler: route.newHandler(factory),
+		pathParams:               pathParams,
+	}, nil
+}
+
+// newGetRequestHandler returns a handler to get a single recipe.
+func (factory *requestHandlerFactory) newGetRequestHandler() apiGatewayRequestHandler {
+	return &apiGatewayGetRequestHandler{
+		representations: recipeMediaTypes,
+		recipeService:   factory.getRecipeService(),
+		logger:          factory.logger,
+	}
+}
+
+// newImportRequestHandler returns a handler to import recipes from documents of other formats.
+func (factory *requestHandlerFactory) newImportRequestHandler() apiGatewayRequestHandler {
+	return &apiGatewayImportRequestHandler{
+		maxSize:       factory.importMaxSize(),
+		recipeService: factory.getRecipeService(),
+		logger:        factory.logger,
+	}
+}
+
+// newExportRequestHandler returns a handler to export recipes.
+func (factory *requestHandlerFactory) newExportRequestHandler() apiGatewayRequestHandler {
+	return &apiGatewayExportRequestHandler{
+		recipeService: factory.getRecipeService(),
+		logger:        factory.logger,
+	}
+}
+
+// newBatchGetRequestHandler returns a handler to get multiple recipes at once.
+func (factory *requestHandlerFactory) newBatchGetRequestHandler() apiGatewayRequestHandler {
+	return &apiGatewayBatchGetRequestHandler{
+		batch:         factory.newBatchRequest(),
+		recipeService: factory.getRecipeService(),
+		logger:        factory.logger,
+	}
+}
+
+// newBatchDeleteRequestHandler returns a handler to delete multiple recipes at once.
+func (factory *requestHandlerFactory) newBatchDeleteRequestHandler() apiGatewayRequestHandler {
+	return &apiGatewayBatchDeleteRequestHandler{
+		batch:         factory.newBatchRequest(),
+		recipeService: factory.getRecipeService(),
+		logger:        factory.logger,
+	}
+}
+
+// newPrintRequestHandler returns a handler to get a single recipe as printable HTML page.
+func (factory *requestHandlerFactory) newPrintRequestHandler() apiGatewayRequestHandler {
+	return &apiGatewayGetRequestHandler{
+		representations: printMediaTypes,
+		recipeService:   factory.getRecipeService(),
+		logger:          factory.logger,
+	}
+}
+
+// newShoppingListRequestHandler returns a handler to create a shopping list for multiple recipes.
+// Max number of recipes is limited by batch.maxsize.
+func (factory *requestHandlerFactory) newShoppingListRequestHandler() apiGatewayRequestHandler {
+	return &apiGatewayShoppingListRequestHandler{
+		maxRecipes:    factory.newBatchRequest().maxSize,
+		recipeService: factory.getRecipeService(),
+		logger:        factory.logger,
+	}
+}
+
+// newMealPlanListRequestHandler returns a handler to list all meal plans.
+func (factory *requestHandlerFactory) newMealPlanListRequestHandler() apiGatewayRequestHandler {
+	return &apiGatewayMealPlanListRequestHandler{
+		mealPlans: factory.getMealPlanStore(),
+		logger:    factory.logger,
+	}
+}
+
+// newMealPlanPostRequestHandler returns a handler to create a meal plan.
+func (factory *requestHandlerFactory) newMealPlanPostRequestHandler() apiGatewayRequestHandler {
+	return &apiGatewayMealPlanPostRequestHandler{
+		maxMeals:      factory.newBatchRequest().maxSize,
+		mealPlans:     factory.getMealPlanStore(),
+		recipeService: factory.getRecipeService(),
+		logger:        factory.logger,
+	}
+}
+
+// newMealPlanGetRequestHandler returns a handler to get the meal plan of a week.
+func (factory *requestHandlerFactory) newMealPlanGetRequestHandler() apiGatewayRequestHandler {
+	return &apiGatewayMealPlanGetRequestHandler{
+		mealPlans: factory.getMealPlanStore(),
+		logger:    factory.logger,
+	}
+}
+
+// newMealPlanPutRequestHandler returns a handler to update the meal plan of a week.
+func (factory *requestHandlerFactory) newMealPlanPutRequestHandler() apiGatewayRequestHandler {
+	return &apiGatewayMealPlanPutRequestHandler{
+		maxMeals:      factory.newBatchRequest().maxSize,
+		mealPlans:     factory.getMealPlanStore(),
+		recipeService: factory.getRecipeService(),
+		logger:        factory.logger,
+	}
+}
+
+// newMealPlanDeleteRequestHandler returns a handler to delete the meal plan of a week.
+func (factory *requestHandlerFactory) newMealPlanDeleteRequestHandler() apiGatewayRequestHandler {
+	return &apiGatewayMealPlanDeleteRequestHandler{
+		mealPlans: factory.getMealPlanStore(),
+		logger:    factory.logger,
+	}
+}
+
+// newMealPlanShoppingListRequestHandler returns a handler to get a shopping list for all meals of a week.
+func (factory *requestHandlerFactory) newMealPlanShoppingListRequestHandler() apiGatewayRequestHandler {
+	return &apiGatewayMealPlanShoppingListRequestHandler{
+		maxRecipes:    factory.newBatchRequest().maxSize,
+		mealPlans:     factory.getMealPlanStore(),
+		recipeService: factory.getRecipeService(),
+		logger:        factory.logger,
+	}
+}
+
+// newListRequestHandler returns a handler to get a list of recipes.
+func (factory *requestHandlerFactory) newListRequestHandler() apiGatewayRequestHandler {
+	return &apiGatewayListRequestHandler{
+		recipeService: factory.getRecipeService(),
+		logger:        factory.logger,
+	}
+}
+
+// newPostRequestHandler returns a handler to create new recipes.
+func (factory *requestHandlerFactory) newPostRequestHandler() apiGatewayRequestHandler {
+	return &apiGatewayPostRequestHandler{
+		recipeService: factory.getRecipeService(),
+		logger:        factory.logger,
+	}
+}
+
+// newPutRequestHandler returns a handler to update existing recipes.
+func (factory *requestHandlerFactory) newPutRequestHandler() apiGatewayRequestHandler {
+	return &apiGatewayPutRequestHandler{
+		conditions:    conditionalRequest{ifMatchRequired: factory.ifMatchRequired()},
+		recipeService: factory.getRecipeService(),
+		logger:        factory.logger,
+	}
+}
+
+// newPatchRequestHandler returns a handler to update single values of existing recipes.
+func (factory *requestHandlerFactory) newPatchRequestHandler() apiGatewayRequestHandler {
+	return &apiGatewayPatchRequestHandler{
+		conditions:    conditionalRequest{ifMatchRequired: factory.ifMatchRequired()},
+		recipeService: factory.getRecipeService(),
+		logger:        factory.logger,
+	}
+}
+
+// newDeleteRequestHandler returns a handler to delete recipes.
+func (factory *requestHandlerFactory) newDeleteRequestHandler() apiGatewayRequestHandler {
+	return &apiGatewayDeleteRequestHandler{
+		conditions:    conditionalRequest{ifMatchRequired: factory.ifMatchRequired()},
+		recipeService: factory.getRecipeService(),
+		logger:        factory.logger,
+	}
+}
+
+// newSearchRequestHandler returns a handler to search for recipes.
+func (factory *requestHandlerFactory) newSearchRequestHandler() apiGatewayRequestHandler {
+	return &apiGatewaySearchRequestHandler{
+		searchIndex:   factory.getSearchIndex(),
+		recipeService: factory.getRecipeService(),
+		logger:        factory.logger,
+	}
+}
+
+// newRestoreRequestHandler returns a handler to restore recipes from the trash.
+func (factory *requestHandlerFactory) newRestoreRequestHandler() apiGatewayRequestHandler {
+	return &apiGatewayRestoreRequestHandler{
+		trash:         factory.getRecipeTrash(),
+		recipeService: factory.getRecipeService(),
+		logger:        factory.logger,
+	}
+}
+
+// newTrashListRequestHandler returns a handler to list all recipes in the trash.
+func (factory *requestHandlerFactory) newTrashListRequestHandler() apiGatewayRequestHandler {
+	return &apiGatewayTrashListRequestHandler{
+		retention: factory.trashRetention(),
+		trash:     factory.getRecipeTrash(),
+		logger:    factory.logger,
+	}
+}
+
+// newTrashPurgeRequestHandler returns a handler to purge expired recipes from the trash.
+func (factory *requestHandlerFactory) newTrashPurgeRequestHandler() apiGatewayRequestHandler {
+	return &apiGatewayTrashPurgeRequestHandler{
+		retention: factory.trashRetention(),
+		trash:     factory.getRecipeTrash(),
+		logger:    factory.logger,
+	}
+}
+
+// newRevisionListRequestHandler returns a handler to list all revisions of a recipe.
+func (factory *requestHandlerFactory) newRevisionListRequestHandler() apiGatewayRequestHandler {
+	return &apiGatewayRevisionListRequestHandler{
+		revisions:     factory.getRevisionLog(),
+		recipeService: factory.getRecipeService(),
+		logger:        factory.logger,
+	}
+}
+
+// newRevisionGetRequestHandler returns a handler to get a single revision of a recipe.
+func (factory *requestHandlerFactory) newRevisionGetRequestHandler() apiGatewayRequestHandler {
+	return &apiGatewayRevisionGetRequestHandler{
+		revisions: factory.getRevisionLog(),
+		logger:    factory.logger,
+	}
+}
+
+// newRollbackRequestHandler returns a handler to roll back a recipe to an older revision.
+func (factory *requestHandlerFactory) newRollbackRequestHandler() apiGatewayRequestHandler {
+	return &apiGatewayRollbackRequestHandler{
+		conditions:    conditionalRequest{ifMatchRequired: factory.ifMatchRequired()},
+		revisions:     factory.getRevisionLog(),
+		recipeService: factory.getRecipeService(),
+		logger:        factory.logger,
+	}
+}
+
+// getRecipeService returns the core recipe service. It's wrapped to keep the search index up to date,
+// to move deleted recipes to the trash and to write revisions of all changed recipes. Revisions are written
+// by a wrapper created on each call, with the author of the request a handler is created for.
 func (factory *requestHandlerFactory) getRecipeService() core.RecipeService {
 
 	if factory.recipeService == nil {
-		factory.recipeService = core.NewRecipeServiceFromConfig(factory.config, factory.logger)
+		recipeService := newCreationTimeRecipeService(core.NewRecipeServiceFromConfig(factory.config, factory.logger))
+		recipeService = newIndexingRecipeService(recipeService, factory.getSearchIndex(), factory.logger)
+		factory.recipeService = newTrashingRecipeService(recipeService, factory.getRecipeTrash(), factory.logger)
+	}
+	return newRevisioningRecipeService(factory.recipeService, factory.getRevisionLog(), factory.author, factory.logger)
+}
+
+// ifMatchRequired returns true if changes of recipes require an If-Match header, defined by etag.requireifmatch in config.
+func (factory *requestHandlerFactory) ifMatchRequired() bool {
+
+	if factory.config == nil {
+		return false
+	}
+	required := false
+	return *factory.config.GetAsBool("etag.requireifmatch", &required)
+}
+
+// newBatchRequest returns a batch request with limits defined by batch.maxsize and batch.concurrency in config.
+func (factory *requestHandlerFactory) newBatchRequest() batchRequest {
+
+	if factory.config == nil {
+		return newBatchRequest(defaultBatchMaxSize, defaultBatchConcurrency)
+	}
+	maxSize := defaultBatchMaxSize
+	concurrency := defaultBatchConcurrency
+	return newBatchRequest(*factory.config.GetAsInt("batch.maxsize", &maxSize), *factory.config.GetAsInt("batch.concurrency", &concurrency))
+}
+
+// importMaxSize returns the max number of recipes in a single import request, defined by import.maxsize in config.
+func (factory *requestHandlerFactory) importMaxSize() int {
+
+	maxSize := defaultImportMaxSize
+	if factory.config == nil {
+		return maxSize
+	}
+	return *factory.config.GetAsInt("import.maxsize", &maxSize)
+}
+
+// trashRetention returns the time deleted recipes are kept in the trash, defined by trash.retention in config.
+func (factory *requestHandlerFactory) trashRetention() time.Duration {
+
+	retention := defaultTrashRetention
+	if factory.config == nil {
+		return retention
+	}
+	return *factory.config.GetAsDuration("trash.retention", &retention)
+}
+
+// getDocumentStore returns the document store defined in config.
+func (factory *requestHandlerFactory) getDocumentStore() documentStore {
+
+	if factory.documentStore == nil {
+		factory.documentStore = newDocumentStoreFromConfig(factory.config, factory.logger)
+	}
+	return factory.documentStore
+}
+
+// getSearchIndex returns the search index for recipes.
+func (factory *requestHandlerFactory) getSearchIndex() searchIndex {
+
+	if factory.searchIndex == nil {
+		factory.searchIndex = newDocumentSearchIndex(factory.getDocumentStore())
+	}
+	return factory.searchIndex
+}
+
+// getRecipeTrash returns the trash for deleted recipes.
+func (factory *requestHandlerFactory) getRecipeTrash() recipeTrash {
+
+	if factory.trash == nil {
+		factory.trash = newDocumentTrash(factory.getDocumentStore())
+	}
+	return factory.trash
+}
+
+// getRevisionLog returns the log of all recipe revisions.
+func (factory *requestHandlerFactory) getRevisionLog() revisionLog {
+
+	if factory.revisions == nil {
+		factory.revisions = newDocumentRevisionLog(factory.getDocumentStore())
+	}
+	return factory.revisions
+}
+
+// getMealPlanStore returns the store for weekly meal plans.
+func (factory *requestHandlerFactory) getMealPlanStore() mealPlanStore {
+
+	if factory.mealPlans == nil {
+		factory.mealPlans = newDocumentMealPlanStore(factory.getDocumentStore())
 	}
-	return factory.recipeService
+	return factory.mealPlans
 }
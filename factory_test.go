@@ -26,11 +26,33 @@ func (suite *FactoryTestSuite) SetupTest() {
 // Test create request handler.
 func (suite *FactoryTestSuite) TestCreateRequestHandler() {
 
+	recipeId := "123"
 	requests := []events.APIGatewayProxyRequest{
 		apiGatewayRequestForTest(http.MethodGet, nil, nil),
 		apiGatewayRequestForTest(http.MethodPost, nil, nil),
-		apiGatewayRequestForTest(http.MethodPut, nil, nil),
-		apiGatewayRequestForTest(http.MethodDelete, nil, nil),
+		apiGatewayRequestForTest(http.MethodGet, nil, &recipeId),
+		apiGatewayRequestForTest(http.MethodPut, nil, &recipeId),
+		apiGatewayRequestForTest(http.MethodPatch, nil, &recipeId),
+		apiGatewayRequestForTest(http.MethodDelete, nil, &recipeId),
+		apiGatewayRequestWithPathForTest(http.MethodGet, "/recipes/search"),
+		apiGatewayRequestWithPathForTest(http.MethodGet, "/recipes/123/print"),
+		apiGatewayRequestWithPathForTest(http.MethodPost, "/recipes:import"),
+		apiGatewayRequestWithPathForTest(http.MethodGet, "/recipes:export"),
+		apiGatewayRequestWithPathForTest(http.MethodPost, "/recipes:batchGet"),
+		apiGatewayRequestWithPathForTest(http.MethodPost, "/recipes:batchDelete"),
+		apiGatewayRequestWithPathForTest(http.MethodPost, "/recipes/123:restore"),
+		apiGatewayRequestWithPathForTest(http.MethodPost, "/recipes/123:rollback"),
+		apiGatewayRequestWithPathForTest(http.MethodGet, "/recipes/123/revisions"),
+		apiGatewayRequestWithPathForTest(http.MethodGet, "/recipes/123/revisions/1"),
+		apiGatewayRequestWithPathForTest(http.MethodGet, "/trash"),
+		apiGatewayRequestWithPathForTest(http.MethodPost, "/trash:purge"),
+		apiGatewayRequestWithPathForTest(http.MethodPost, "/shopping-lists"),
+		apiGatewayRequestWithPathForTest(http.MethodGet, "/mealplans"),
+		apiGatewayRequestWithPathForTest(http.MethodPost, "/mealplans"),
+		apiGatewayRequestWithPathForTest(http.MethodGet, "/mealplans/2021-W07"),
+		apiGatewayRequestWithPathForTest(http.MethodPut, "/mealplans/2021-W07"),
+		apiGatewayRequestWithPathForTest(http.MethodDelete, "/mealplans/2021-W07"),
+		apiGatewayRequestWithPathForTest(http.MethodGet, "/mealplans/2021-W07/shopping-list"),
 	}
 	for _, request := range requests {
 		handler, err := suite.factory.handlerForRequest(request)
@@ -40,5 +62,26 @@ func (suite *FactoryTestSuite) TestCreateRequestHandler() {
 
 	handlerPatch, errPatch := suite.factory.handlerForRequest(apiGatewayRequestForTest(http.MethodPatch, nil, nil))
 	suite.NotNil(errPatch)
+	suite.IsType(&methodNotAllowedError{}, errPatch)
 	suite.Nil(handlerPatch)
+
+	handlerUnknown, errUnknown := suite.factory.handlerForRequest(apiGatewayRequestWithPathForTest(http.MethodGet, "/ingredients"))
+	suite.NotNil(errUnknown)
+	suite.IsType(&routeNotFoundError{}, errUnknown)
+	suite.Nil(handlerUnknown)
+}
+
+// Test create request handler for a request without API Gateway resource.
+func (suite *FactoryTestSuite) TestCreateRequestHandlerByPath() {
+
+	handler, err := suite.factory.handlerForRequest(apiGatewayRequestWithPathForTest(http.MethodDelete, "/recipes/123"))
+	suite.Nil(err)
+	suite.NotNil(handler)
+
+	suite.Nil(handler.parseRequest(apiGatewayRequestWithPathForTest(http.MethodDelete, "/recipes/123")))
+	routedHandler, ok := handler.(*routedRequestHandler)
+	suite.True(ok)
+	deleteHandler, ok := routedHandler.apiGatewayRequestHandler.(*apiGatewayDeleteRequestHandler)
+	suite.True(ok)
+	suite.Equal("123", *deleteHandler.recipeId)
 }
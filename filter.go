@@ -0,0 +1,70 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	model "github.com/tommzn/recipeboard-core/model"
+)
+
+// filterDateFormat is used for filter values which contain a date only.
+const filterDateFormat = "2006-01-02"
+
+// parseRecipeFilter extracts creation time and title filters from passed query params.
+func parseRecipeFilter(queryParams map[string]string) (recipeFilter, error) {
+
+	filter := recipeFilter{titleContains: strings.ToLower(strings.TrimSpace(queryParams["title"]))}
+	var err error
+	if filter.createdAfter, err = parseFilterTime(queryParams, "createdafter"); err != nil {
+		return filter, err
+	}
+	if filter.createdBefore, err = parseFilterTime(queryParams, "createdbefore"); err != nil {
+		return filter, err
+	}
+	if filter.createdAfter != nil && filter.createdBefore != nil && !filter.createdAfter.Before(*filter.createdBefore) {
+		return filter, newBadRequestError("Createdafter has to be before createdbefore.", nil)
+	}
+	return filter, nil
+}
+
+// parseFilterTime converts the query param with passed name to a time. It can be a RFC 3339 date-time
+// or a date, which is the beginning of this day in UTC. It returns nil if there's no such query param.
+func parseFilterTime(queryParams map[string]string, name string) (*time.Time, error) {
+
+	value, ok := queryParams[name]
+	if !ok {
+		return nil, nil
+	}
+	for _, layout := range []string{time.RFC3339, filterDateFormat} {
+		if filterTime, err := time.Parse(layout, value); err == nil {
+			return &filterTime, nil
+		}
+	}
+	return nil, newBadRequestError(fmt.Sprintf("Invalid %s: %s, expected a RFC 3339 date-time or a date.", name, value), nil)
+}
+
+// matches returns true if passed recipe has been created in filter time range and it's title contains
+// the filter text, ignoring case. Time range includes createdafter and excludes createdbefore.
+func (filter recipeFilter) matches(recipe model.Recipe) bool {
+
+	if filter.createdAfter != nil && recipe.CreatedAt.Before(*filter.createdAfter) {
+		return false
+	}
+	if filter.createdBefore != nil && !recipe.CreatedAt.Before(*filter.createdBefore) {
+		return false
+	}
+	return strings.Contains(strings.ToLower(recipe.Title), filter.titleContains)
+}
+
+// filterRecipes returns all recipes matching passed filter, keeping their order.
+func filterRecipes(recipes []model.Recipe, filter recipeFilter) []model.Recipe {
+
+	filtered := []model.Recipe{}
+	for _, recipe := range recipes {
+		if filter.matches(recipe) {
+			filtered = append(filtered, recipe)
+		}
+	}
+	return filtered
+}
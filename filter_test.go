@@ -0,0 +1,67 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+	model "github.com/tommzn/recipeboard-core/model"
+)
+
+// Test suite for recipe list filters.
+type FilterTestSuite struct {
+	suite.Suite
+}
+
+func TestFilterTestSuite(t *testing.T) {
+	suite.Run(t, new(FilterTestSuite))
+}
+
+// Test parsing filters from query params.
+func (suite *FilterTestSuite) TestParseRecipeFilter() {
+
+	filter, err := parseRecipeFilter(map[string]string{})
+	suite.Nil(err)
+	suite.Nil(filter.createdAfter)
+	suite.Nil(filter.createdBefore)
+	suite.Equal("", filter.titleContains)
+
+	filter, err = parseRecipeFilter(map[string]string{"createdafter": "2021-06-01", "createdbefore": "2021-06-02T12:00:00+02:00", "title": " Cake "})
+	suite.Nil(err)
+	suite.Equal(time.Date(2021, 6, 1, 0, 0, 0, 0, time.UTC), filter.createdAfter.UTC())
+	suite.Equal(time.Date(2021, 6, 2, 10, 0, 0, 0, time.UTC), filter.createdBefore.UTC())
+	suite.Equal("cake", filter.titleContains)
+
+	invalidParams := []map[string]string{
+		{"createdafter": "yesterday"},
+		{"createdbefore": "2021-13-01"},
+		{"createdafter": "2021-06-02", "createdbefore": "2021-06-01"},
+	}
+	for _, queryParams := range invalidParams {
+		_, err := parseRecipeFilter(queryParams)
+		suite.IsType(&badRequestError{}, err, queryParams)
+	}
+}
+
+// Test filtering recipes by creation time and title.
+func (suite *FilterTestSuite) TestFilterRecipes() {
+
+	createdAt := time.Date(2021, 6, 1, 12, 0, 0, 0, time.UTC)
+	recipes := []model.Recipe{
+		{Id: "1", Title: "Apple Cake", CreatedAt: createdAt.Add(-24 * time.Hour)},
+		{Id: "2", Title: "Cheesecake", CreatedAt: createdAt},
+		{Id: "3", Title: "Leek Soup", CreatedAt: createdAt.Add(24 * time.Hour)},
+	}
+
+	filter, _ := parseRecipeFilter(map[string]string{"title": "CAKE"})
+	suite.Equal([]string{"1", "2"}, recipeIds(filterRecipes(recipes, filter)))
+
+	filter, _ = parseRecipeFilter(map[string]string{"createdafter": "2021-06-01T12:00:00Z"})
+	suite.Equal([]string{"2", "3"}, recipeIds(filterRecipes(recipes, filter)))
+
+	filter, _ = parseRecipeFilter(map[string]string{"createdbefore": "2021-06-01T12:00:00Z"})
+	suite.Equal([]string{"1"}, recipeIds(filterRecipes(recipes, filter)))
+
+	filter, _ = parseRecipeFilter(map[string]string{"createdafter": "2021-06-01", "createdbefore": "2021-06-02", "title": "soup"})
+	suite.Len(filterRecipes(recipes, filter), 0)
+}
@@ -4,72 +4,252 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/aws/aws-lambda-go/events"
+	utils "github.com/tommzn/go-utils"
+	core "github.com/tommzn/recipeboard-core"
 	model "github.com/tommzn/recipeboard-core/model"
 )
 
-// parseRequest will analyze passed GET request and extract recipe id or recipe type if available.
+// parseRequest will analyze passed GET request and extract the recipe id, requested fields, scaling, units and conditional headers.
 func (handler *apiGatewayGetRequestHandler) parseRequest(request events.APIGatewayProxyRequest) error {
 
-	if recipeTypeStr, ok := request.QueryStringParameters["recipetype"]; ok {
-		if recipeType, err := toRecipeType(recipeTypeStr); err == nil {
-			handler.recipeType = recipeType
-		} else {
-			return err
-		}
+	fields, err := parseFields(request.QueryStringParameters)
+	if err != nil {
+		return err
+	}
+	handler.fields = fields
+
+	scaling, err := parseScaling(request.QueryStringParameters)
+	if err != nil {
+		return err
+	}
+	handler.scaling = scaling
+
+	units, err := parseUnitSystem(request.QueryStringParameters)
+	if err != nil {
+		return err
 	}
+	handler.units = units
+	handler.conditions.parse(request)
 
 	if recipeId, ok := request.PathParameters["id"]; ok {
 		handler.recipeId = &recipeId
+		return nil
 	}
+	return errors.New("Missing recipe id.")
+}
+
+// handle GET requests from API Gateway to return a single recipe, rendered in the negotiated media type.
+// Ingredient quantities are scaled if a scale factor or number of servings has been requested
+// and converted if a unit system has been requested.
+// The full JSON representation is returned with the strong entity tag of a recipe, which can be used in If-Match headers.
+// All other representations get a weak entity tag derived from it.
+// If the recipe matches an entity tag passed in If-None-Match header a not modified error is returned.
+func (handler *apiGatewayGetRequestHandler) handle() (*string, error) {
 
-	if handler.recipeType == nil && handler.recipeId == nil {
-		return errors.New("Missing id or request type as query param.")
+	if handler.recipeId != nil {
+		if recipe, err := handler.recipeService.Get(*handler.recipeId); err == nil {
+			var body *string
+			if handler.scaling != nil || handler.units != "" {
+				body, err = renderAdjustedRecipe(*recipe, handler.scaling, handler.units, handler.fields, handler.mediaType)
+			} else {
+				body, err = renderRecipe(*recipe, handler.fields, handler.mediaType)
+			}
+			if err != nil {
+				return nil, err
+			}
+			if handler.isFullRepresentation() {
+				handler.etag, err = recipeETag(*recipe)
+			} else {
+				handler.etag, err = variantETag(*recipe, handler.variant())
+			}
+			if err != nil {
+				return nil, err
+			}
+			return body, handler.conditions.checkIfNoneMatch(handler.etag)
+		} else {
+			return nil, fromServiceError(err)
+		}
 	}
-	return nil
+	return nil, errors.New("Bad request")
 }
 
-// handle GET requests from API Gateway to return a single recipe or a list of recipes for a passed recipe type.
-func (handler *apiGatewayGetRequestHandler) handle() (*string, error) {
+// isFullRepresentation returns true if all fields of a recipe are requested as JSON, without scaling or unit conversion.
+func (handler *apiGatewayGetRequestHandler) isFullRepresentation() bool {
+	return handler.fields == nil && handler.scaling == nil && handler.units == "" &&
+		(handler.mediaType == "" || handler.mediaType == jsonMediaType)
+}
+
+// variant describes the requested representation of a recipe by media type, fields, scaling and unit system.
+func (handler *apiGatewayGetRequestHandler) variant() string {
 
-	if handler.recipeType != nil {
-		if recipes, err := handler.recipeService.List(*handler.recipeType); err == nil {
-			return marshalRecipes(recipes)
+	scaling := ""
+	if handler.scaling != nil {
+		if handler.scaling.factor != nil {
+			scaling = strconv.FormatFloat(*handler.scaling.factor, 'g', -1, 64)
 		} else {
+			scaling = fmt.Sprintf("%d/%d", handler.scaling.servings, handler.scaling.baseServings)
+		}
+	}
+	return strings.Join([]string{handler.mediaType, strings.Join(handler.fields, ","), scaling, handler.units}, ";")
+}
+
+// responseHeaders returns the ETag of the returned recipe.
+func (handler *apiGatewayGetRequestHandler) responseHeaders() map[string]string {
+	return etagHeaders(handler.etag)
+}
+
+// mediaTypes returns all media types a recipe can be rendered in.
+func (handler *apiGatewayGetRequestHandler) mediaTypes() []string {
+	return handler.representations
+}
+
+// useMediaType defines the media type the recipe is rendered in.
+func (handler *apiGatewayGetRequestHandler) useMediaType(mediaType string) {
+	handler.mediaType = mediaType
+}
+
+// parseRequest will analyze passed GET request and extract recipe types, sort order, filters, fields and paging params.
+// Multiple recipe types can be passed as multi value query param.
+func (handler *apiGatewayListRequestHandler) parseRequest(request events.APIGatewayProxyRequest) error {
+
+	recipeTypes, err := parseRecipeTypes(request)
+	if err != nil {
+		return err
+	}
+	handler.recipeTypes = recipeTypes
+
+	sortOrder, err := parseSortOrder(request.QueryStringParameters)
+	if err != nil {
+		return err
+	}
+	handler.sortOrder = sortOrder
+
+	filter, err := parseRecipeFilter(request.QueryStringParameters)
+	if err != nil {
+		return err
+	}
+	handler.filter = filter
+
+	fields, err := parseFields(request.QueryStringParameters)
+	if err != nil {
+		return err
+	}
+	handler.fields = fields
+
+	page, err := parsePageRequest(request.QueryStringParameters, handler.sortOrder)
+	if err != nil {
+		return err
+	}
+	handler.page = page
+	handler.path = requestPath(request)
+	handler.queryParams = queryValues(request)
+	return nil
+}
+
+// handle GET requests from API Gateway to return a list of recipes for passed recipe types, sorted by requested
+// sort order. All recipes are returned if there's no recipe type filter. Creation time and title filters are applied
+// after recipes have been loaded, so they work the same way for all recipe sources.
+// If a limit or cursor has been passed recipes are returned page by page, with a link to the next page in
+// response body and as Link header. Otherwise all recipes are returned as plain list.
+func (handler *apiGatewayListRequestHandler) handle() (*string, error) {
+
+	recipes, err := listRecipes(handler.recipeService, handler.recipeTypes)
+	if err != nil {
+		return nil, err
+	}
+	recipes = filterRecipes(recipes, handler.filter)
+	sortRecipes(recipes, handler.sortOrder)
+
+	if !handler.page.paged {
+		return marshalRecipes(recipes, handler.fields)
+	}
+	pageRecipes, nextCursor := paginate(recipes, handler.page, handler.sortOrder)
+	if nextCursor != nil {
+		nextLink := nextPageLink(handler.path, handler.queryParams, *nextCursor)
+		handler.next = &nextLink
+	}
+	return marshalRecipePage(pageRecipes, handler.next, handler.fields)
+}
+
+// responseHeaders returns a Link header with the next page, if there're more recipes available.
+func (handler *apiGatewayListRequestHandler) responseHeaders() map[string]string {
+	if handler.next == nil {
+		return nil
+	}
+	return map[string]string{"Link": fmt.Sprintf("<%s>; rel=\"next\"", *handler.next)}
+}
+
+// parseRecipeTypes returns all recipe types passed in recipetype query param, without duplicates.
+func parseRecipeTypes(request events.APIGatewayProxyRequest) ([]model.RecipeType, error) {
+
+	recipeTypes := []model.RecipeType{}
+	for _, recipeTypeStr := range queryParamValues(request, "recipetype") {
+		recipeType, err := toRecipeType(recipeTypeStr)
+		if err != nil {
 			return nil, err
 		}
+		if !containsRecipeType(recipeTypes, *recipeType) {
+			recipeTypes = append(recipeTypes, *recipeType)
+		}
 	}
+	return recipeTypes, nil
+}
 
-	if handler.recipeId != nil {
-		if recipe, err := handler.recipeService.Get(*handler.recipeId); err == nil {
-			return marshalRecipe(*recipe)
-		} else {
+// listRecipes returns all recipes of passed types, or of all types if no type is given.
+// Result list will be empty if there're no recipes for passed types, regardless of the number of types.
+func listRecipes(recipeService core.RecipeService, recipeTypes []model.RecipeType) ([]model.Recipe, error) {
+
+	if len(recipeTypes) == 0 {
+		recipeTypes = allRecipeTypes()
+	}
+	recipes := []model.Recipe{}
+	for _, recipeType := range recipeTypes {
+		recipesForType, err := recipeService.List(recipeType)
+		if err != nil {
+			if _, ok := fromServiceError(err).(*notFoundError); ok {
+				continue
+			}
 			return nil, err
 		}
+		recipes = append(recipes, recipesForType...)
 	}
-	return nil, errors.New("Bad request")
+	return recipes, nil
 }
 
 // parseRequest will try to convert request body to a recipe.
+// Request body is validated against the NewRecipe schema. A passed creation time is ignored.
 func (handler *apiGatewayPostRequestHandler) parseRequest(request events.APIGatewayProxyRequest) error {
 
-	recipe, err := unmarshalFromRequestBody(request.Body)
+	values, err := decodeRequestBody(request.Body)
 	if err != nil {
 		return err
 	}
+	recipe, err := recipeFromRequestBody(values, newRecipeSchema)
+	if err != nil {
+		return err
+	}
+	if recipe.Id != "" && !utils.IsId(recipe.Id) {
+		return newFieldValidationError([]fieldError{{Field: "id", Message: "must be a valid recipe id"}})
+	}
+	recipe.CreatedAt = time.Time{}
 	handler.recipe = recipe
 	return nil
 }
 
 // Handle POST requests from API Gateway to create new recipes.
+// Creating a recipe with an id which is already in use will fail with a conflict.
 func (handler *apiGatewayPostRequestHandler) handle() (*string, error) {
 
 	if handler.recipe != nil {
-		if newRecipe, err := handler.recipeService.Create(*handler.recipe); err == nil {
-			return marshalRecipe(newRecipe)
+		if newRecipe, err := createRecipe(handler.recipeService, *handler.recipe); err == nil {
+			body, etag, err := marshalRecipeWithETag(newRecipe, nil)
+			handler.etag = etag
+			return body, err
 		} else {
 			return nil, err
 		}
@@ -77,40 +257,159 @@ func (handler *apiGatewayPostRequestHandler) handle() (*string, error) {
 	return nil, errors.New("Bad request")
 }
 
+// responseHeaders returns the ETag of the created recipe.
+func (handler *apiGatewayPostRequestHandler) responseHeaders() map[string]string {
+	return etagHeaders(handler.etag)
+}
+
 // parseRequest will try to convert request body to a recipe and extrace recipe id from path.
+// Request body is validated against the Recipe schema. Recipe id from path is used if request body
+// doesn't contain an id, but if both are available they have to be equal.
 func (handler *apiGatewayPutRequestHandler) parseRequest(request events.APIGatewayProxyRequest) error {
 
-	if recipe, err := unmarshalFromRequestBody(request.Body); err == nil {
-		handler.recipe = recipe
-	} else {
+	recipeId, ok := request.PathParameters["id"]
+	if !ok {
+		return errors.New("Missing recipe id.")
+	}
+	handler.recipeId = &recipeId
+	handler.conditions.parse(request)
+
+	values, err := decodeRequestBody(request.Body)
+	if err != nil {
 		return err
 	}
+	if bodyId, ok := values["id"]; !ok || bodyId == "" {
+		values["id"] = recipeId
+	} else if bodyId != recipeId {
+		return newFieldValidationError([]fieldError{{Field: "id", Message: "must match recipe id in path"}})
+	}
 
-	if recipeId, ok := request.PathParameters["id"]; ok {
-		handler.recipeId = &recipeId
-		return nil
-	} else {
-		return errors.New("Missing recipe id.")
+	recipe, err := recipeFromRequestBody(values, existingRecipeSchema)
+	if err != nil {
+		return err
 	}
+	handler.recipe = recipe
+	return nil
 }
 
 // handle PUT requests from API Gateway to update existing recipes.
+// If conditional headers are passed, current recipe is loaded to compare it with passed entity tags.
 func (handler *apiGatewayPutRequestHandler) handle() (*string, error) {
 
 	if handler.recipeId != nil && handler.recipe != nil {
 		handler.recipe.Id = *handler.recipeId
+		if handler.conditions.ifMatch != "" || handler.conditions.ifMatchRequired {
+			currentRecipe, err := handler.recipeService.Get(*handler.recipeId)
+			if err != nil {
+				return nil, fromServiceError(err)
+			}
+			if err := handler.conditions.checkIfMatch(*currentRecipe); err != nil {
+				return nil, err
+			}
+		}
 		if err := handler.recipeService.Update(*handler.recipe); err == nil {
-			return marshalRecipe(*handler.recipe)
+			body, etag, err := marshalRecipeWithETag(*handler.recipe, nil)
+			handler.etag = etag
+			return body, err
 		} else {
-			return nil, err
+			return nil, fromServiceError(err)
 		}
 	}
 	return nil, errors.New("Bad Request")
 }
 
-// parseRequest will try extract recipe id from path.
+// responseHeaders returns the ETag of the updated recipe.
+func (handler *apiGatewayPutRequestHandler) responseHeaders() map[string]string {
+	return etagHeaders(handler.etag)
+}
+
+// parseRequest will extract recipe id from path and decode the request body depending on it's content type
+// as JSON merge patch or JSON patch.
+func (handler *apiGatewayPatchRequestHandler) parseRequest(request events.APIGatewayProxyRequest) error {
+
+	recipeId, ok := request.PathParameters["id"]
+	if !ok {
+		return errors.New("Missing recipe id.")
+	}
+	handler.recipeId = &recipeId
+	handler.conditions.parse(request)
+
+	var err error
+	switch contentType := mediaType(headerValue(request, "Content-Type")); contentType {
+	case contentTypeMergePatch:
+		handler.mergePatch, err = parseMergePatch(request.Body)
+	case contentTypeJsonPatch:
+		handler.jsonPatch, err = parseJsonPatch(request.Body)
+	default:
+		err = &unsupportedMediaTypeError{
+			contentType:    contentType,
+			supportedTypes: []string{contentTypeMergePatch, contentTypeJsonPatch},
+		}
+	}
+	return err
+}
+
+// handle PATCH requests from API Gateway to update single values of an existing recipe.
+// Patch is applied to current recipe, which is validated against the Recipe schema afterwards.
+// If an If-Match header is passed, current recipe has to match one of it's entity tags.
+func (handler *apiGatewayPatchRequestHandler) handle() (*string, error) {
+
+	if handler.recipeId == nil {
+		return nil, errors.New("Missing recipe id.")
+	}
+
+	recipe, err := handler.recipeService.Get(*handler.recipeId)
+	if err != nil {
+		return nil, fromServiceError(err)
+	}
+	if err := handler.conditions.checkIfMatch(*recipe); err != nil {
+		return nil, err
+	}
+
+	var document interface{} = recipeToValues(*recipe)
+	if handler.jsonPatch != nil {
+		if document, err = applyJsonPatch(document, handler.jsonPatch); err != nil {
+			return nil, err
+		}
+	} else {
+		document = applyMergePatch(document, handler.mergePatch)
+	}
+
+	patchedValues, ok := document.(map[string]interface{})
+	if !ok {
+		return nil, newValidationError("Patched recipe is not a JSON object.", nil)
+	}
+	values := make(map[string]interface{})
+	for name, value := range patchedValues {
+		if value != nil {
+			values[name] = value
+		}
+	}
+	if values["id"] != *handler.recipeId {
+		return nil, newFieldValidationError([]fieldError{{Field: "id", Message: "must not be changed"}})
+	}
+
+	patchedRecipe, err := recipeFromRequestBody(values, existingRecipeSchema)
+	if err != nil {
+		return nil, err
+	}
+	if err := handler.recipeService.Update(*patchedRecipe); err != nil {
+		return nil, fromServiceError(err)
+	}
+	body, etag, err := marshalRecipeWithETag(*patchedRecipe, nil)
+	handler.etag = etag
+	return body, err
+}
+
+// responseHeaders returns the ETag of the patched recipe.
+func (handler *apiGatewayPatchRequestHandler) responseHeaders() map[string]string {
+	return etagHeaders(handler.etag)
+}
+
+// parseRequest will try extract recipe id from path and conditional headers.
 func (handler *apiGatewayDeleteRequestHandler) parseRequest(request events.APIGatewayProxyRequest) error {
 
+	handler.conditions.parse(request)
 	if recipeId, ok := request.PathParameters["id"]; ok {
 		handler.recipeId = &recipeId
 		return nil
@@ -119,41 +418,118 @@ func (handler *apiGatewayDeleteRequestHandler) parseRequest(request events.APIGa
 	}
 }
 
-// Handle DELETE requests from API Gateway to delete a single recipe.
+// Handle DELETE requests from API Gateway to delete a single recipe. Recipe service moves deleted recipes
+// to the trash, so they can be restored until they're purged. Recipe is loaded before, because persistence layer
+// needs it's type to maintain recipe indexes and it's compared with entity tags passed in If-Match header.
 func (handler *apiGatewayDeleteRequestHandler) handle() (*string, error) {
 
 	if handler.recipeId != nil {
-		err := handler.recipeService.Delete(model.Recipe{Id: *handler.recipeId})
-		return nil, err
+		recipe, err := handler.recipeService.Get(*handler.recipeId)
+		if err != nil {
+			return nil, fromServiceError(err)
+		}
+		if err := handler.conditions.checkIfMatch(*recipe); err != nil {
+			return nil, err
+		}
+		return nil, handler.recipeService.Delete(*recipe)
 	}
 	return nil, errors.New("Missing recipe id.")
 }
 
-// Unmarshal given request body to a recipe.
-func unmarshalFromRequestBody(requestBody string) (*model.Recipe, error) {
-	recipe := &model.Recipe{}
-	err := json.Unmarshal([]byte(requestBody), recipe)
-	return recipe, err
+// parseRequest will extract search query and max number of results from query params.
+func (handler *apiGatewaySearchRequestHandler) parseRequest(request events.APIGatewayProxyRequest) error {
+
+	handler.query = strings.TrimSpace(request.QueryStringParameters["q"])
+	if handler.query == "" {
+		return newBadRequestError("Missing search query.", nil)
+	}
+
+	handler.limit = defaultPageSize
+	if limitStr, ok := request.QueryStringParameters["limit"]; ok {
+		limit, err := strconv.Atoi(limitStr)
+		if err != nil || limit < 1 || limit > maxPageSize {
+			return newBadRequestError(fmt.Sprintf("Limit has to be a number between 1 and %d.", maxPageSize), nil)
+		}
+		handler.limit = limit
+	}
+	return nil
+}
+
+// handle search requests from API Gateway to return all recipes matching a query, ordered by their relevance.
+// Search index is built from all existing recipes if it hasn't been built with current index version. Recipes which are still indexed,
+// but doesn't exist anymore are removed from the index.
+func (handler *apiGatewaySearchRequestHandler) handle() (*string, error) {
+
+	if err := rebuildSearchIndex(handler.searchIndex, handler.recipeService); err != nil {
+		return nil, err
+	}
+	hits, err := handler.searchIndex.search(handler.query, handler.limit)
+	if err != nil {
+		return nil, err
+	}
+
+	response := searchResponse{Query: handler.query, Items: []searchResult{}}
+	for _, hit := range hits {
+		recipe, err := handler.recipeService.Get(hit.id)
+		if err != nil {
+			if _, ok := fromServiceError(err).(*notFoundError); ok {
+				handler.searchIndex.remove(hit.id)
+				continue
+			}
+			return nil, err
+		}
+		recipe.CreatedAt = recipe.CreatedAt.Round(1 * time.Second)
+		highlights := make(map[string]string)
+		for field, text := range searchableFields(*recipe) {
+			if snippet := highlightMatches(text, hit.terms); snippet != "" {
+				highlights[field] = snippet
+			}
+		}
+		response.Items = append(response.Items, searchResult{Recipe: *recipe, Score: hit.score, Highlights: highlights})
+	}
+	b, err := json.Marshal(response)
+	jsonStr := string(b)
+	return &jsonStr, err
 }
 
-// marshalRecipe a single recipe to JSON string.
-func marshalRecipe(recipe model.Recipe) (*string, error) {
+// marshalRecipe a single recipe to JSON string. If fields are passed, only these fields are included.
+func marshalRecipe(recipe model.Recipe, fields []string) (*string, error) {
 	recipe.CreatedAt = recipe.CreatedAt.Round(1 * time.Second)
-	b, err := json.Marshal(recipe)
+	projection, err := projectRecipe(recipe, fields)
+	if err != nil {
+		return nil, err
+	}
+	b, err := json.Marshal(projection)
 	jsonStr := string(b)
 	return &jsonStr, err
 }
 
-// marshalRecipes returns JSON string of passed recipes.
-func marshalRecipes(recipes []model.Recipe) (*string, error) {
+// marshalRecipes returns JSON string of passed recipes. If fields are passed, only these fields are included.
+func marshalRecipes(recipes []model.Recipe, fields []string) (*string, error) {
 	for idx, recipe := range recipes {
 		recipes[idx].CreatedAt = recipe.CreatedAt.Round(1 * time.Second)
 	}
-	b, err := json.Marshal(recipes)
+	projections, err := projectRecipes(recipes, fields)
+	if err != nil {
+		return nil, err
+	}
+	b, err := json.Marshal(projections)
 	jsonStr := string(b)
 	return &jsonStr, err
 }
 
+// recipeToValues converts passed recipe into JSON object values, as used in request bodies.
+func recipeToValues(recipe model.Recipe) map[string]interface{} {
+	return map[string]interface{}{
+		"id":          recipe.Id,
+		"type":        fromRecipeType(recipe.Type),
+		"title":       recipe.Title,
+		"ingredients": recipe.Ingredients,
+		"description": recipe.Description,
+		"createdat":   recipe.CreatedAt.Round(1 * time.Second).Format(time.RFC3339),
+	}
+}
+
 // toRecipeType will try to convert query param for recipe type to the suitable enum value.
 func toRecipeType(recipeTypeStr string) (*model.RecipeType, error) {
 
@@ -168,3 +544,30 @@ func toRecipeType(recipeTypeStr string) (*model.RecipeType, error) {
 		return nil, fmt.Errorf("Unsupported recipe type: %s", recipeTypeStr)
 	}
 }
+
+// allRecipeTypes returns all available recipe types.
+func allRecipeTypes() []model.RecipeType {
+	recipeTypes := []model.RecipeType{}
+	for idx := range recipeTypeEnum {
+		recipeTypes = append(recipeTypes, model.RecipeType(idx))
+	}
+	return recipeTypes
+}
+
+// containsRecipeType returns true if passed recipe type is an element of given list.
+func containsRecipeType(recipeTypes []model.RecipeType, recipeType model.RecipeType) bool {
+	for _, element := range recipeTypes {
+		if element == recipeType {
+			return true
+		}
+	}
+	return false
+}
+
+// fromRecipeType returns the name of passed recipe type, as used in query params and request bodies.
+func fromRecipeType(recipeType model.RecipeType) string {
+	if int(recipeType) >= 0 && int(recipeType) < len(recipeTypeEnum) {
+		return recipeTypeEnum[recipeType]
+	}
+	return strconv.Itoa(int(recipeType))
+}
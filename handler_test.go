@@ -3,8 +3,12 @@ package main
 import (
 	"context"
 	"encoding/json"
+	"fmt"
 	"net/http"
+	"net/url"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/aws/aws-lambda-go/events"
 	"github.com/stretchr/testify/suite"
@@ -50,8 +54,26 @@ func (suite *HandlerTestSuite) TestCreateRecipe() {
 
 	invalidBody := "xxx"
 	request2 := apiGatewayRequestForTest(http.MethodPost, &invalidBody, nil)
-	_, err2 := suite.handler.handle(context.Background(), request2)
-	suite.NotNil(err2)
+	response2, err2 := suite.handler.handle(context.Background(), request2)
+	suite.Nil(err2)
+	suite.assertProblemResponse(response2, http.StatusBadRequest)
+
+	emptyBody := "{}"
+	request2_1 := apiGatewayRequestForTest(http.MethodPost, &emptyBody, nil)
+	response2_1, err2_1 := suite.handler.handle(context.Background(), request2_1)
+	suite.Nil(err2_1)
+	suite.assertProblemResponse(response2_1, http.StatusUnprocessableEntity)
+	problem, err := getProblemDetailsFromResponse(response2_1)
+	suite.Nil(err)
+	suite.Len(problem.Errors, 2)
+	suite.Len(suite.repo.Recipes, 1)
+
+	existingBody, err := toRequestBody(responseRecipe)
+	suite.Nil(err)
+	request3 := apiGatewayRequestForTest(http.MethodPost, &existingBody, nil)
+	response3, err3 := suite.handler.handle(context.Background(), request3)
+	suite.Nil(err3)
+	suite.assertProblemResponse(response3, http.StatusConflict)
 }
 
 // Test get single recipe.
@@ -72,13 +94,8 @@ func (suite *HandlerTestSuite) TestGetSingleRecipe() {
 	notExistingId := utils.NewId()
 	request2 := apiGatewayRequestForTest(http.MethodGet, nil, &notExistingId)
 	response2, err := suite.handler.handle(context.Background(), request2)
-	suite.NotNil(err)
-	suite.assertResponseStatusCode(response2, http.StatusInternalServerError)
-
-	request3 := apiGatewayRequestForTest(http.MethodGet, nil, nil)
-	response3, err := suite.handler.handle(context.Background(), request3)
-	suite.NotNil(err)
-	suite.assertResponseStatusCode(response3, http.StatusBadRequest)
+	suite.Nil(err)
+	suite.assertProblemResponse(response2, http.StatusNotFound)
 }
 
 // Test get recipes by type.
@@ -97,8 +114,182 @@ func (suite *HandlerTestSuite) TestListRecipesByType() {
 	suite.Equal(recipe.Id, recipes[0].Id)
 
 	request2 := apiGatewayRequestWithQueryParamForTest(http.MethodGet, "recipetype", "xxx")
-	_, err2 := suite.handler.handle(context.Background(), request2)
-	suite.NotNil(err2)
+	response2, err2 := suite.handler.handle(context.Background(), request2)
+	suite.Nil(err2)
+	suite.assertProblemResponse(response2, http.StatusBadRequest)
+
+	// A single recipe type without recipes returns an empty list, like multiple types do. It used to be a 404.
+	request3 := apiGatewayRequestWithQueryParamForTest(http.MethodGet, "recipetype", "cooking")
+	response3, err3 := suite.handler.handle(context.Background(), request3)
+	suite.Nil(err3)
+	suite.assertSuccessfulResponse(response3)
+	recipes3, err := getRecipeListFromResponse(response3)
+	suite.Nil(err)
+	suite.Len(recipes3, 0)
+}
+
+// Test list recipes of all or of multiple types.
+func (suite *HandlerTestSuite) TestListAllRecipes() {
+
+	request := apiGatewayRequestForTest(http.MethodGet, nil, nil)
+	response, err := suite.handler.handle(context.Background(), request)
+	suite.Nil(err)
+	suite.assertSuccessfulResponse(response)
+	recipes, err := getRecipeListFromResponse(response)
+	suite.Nil(err)
+	suite.Len(recipes, 0)
+
+	recipe1 := recipeForTest()
+	suite.repo.Recipes[recipe1.Id] = recipe1
+	recipe2 := recipeForTest()
+	recipe2.Type = model.CookingRecipe
+	recipe2.CreatedAt = recipe1.CreatedAt.Add(-1 * time.Minute)
+	suite.repo.Recipes[recipe2.Id] = recipe2
+
+	response2, err := suite.handler.handle(context.Background(), request)
+	suite.Nil(err)
+	suite.assertSuccessfulResponse(response2)
+	recipes2, err := getRecipeListFromResponse(response2)
+	suite.Nil(err)
+	suite.Len(recipes2, 2)
+	suite.Equal(recipe2.Id, recipes2[0].Id)
+	suite.Equal(recipe1.Id, recipes2[1].Id)
+
+	request3 := apiGatewayRequestForTest(http.MethodGet, nil, nil)
+	request3.MultiValueQueryStringParameters = map[string][]string{"recipetype": {"baking", "cooking", "baking"}}
+	response3, err := suite.handler.handle(context.Background(), request3)
+	suite.Nil(err)
+	suite.assertSuccessfulResponse(response3)
+	recipes3, err := getRecipeListFromResponse(response3)
+	suite.Nil(err)
+	suite.Len(recipes3, 2)
+
+	request4 := apiGatewayRequestForTest(http.MethodGet, nil, nil)
+	request4.MultiValueQueryStringParameters = map[string][]string{"recipetype": {"baking", "frying"}}
+	response4, err := suite.handler.handle(context.Background(), request4)
+	suite.Nil(err)
+	suite.assertProblemResponse(response4, http.StatusBadRequest)
+}
+
+// Test get recipes page by page.
+func (suite *HandlerTestSuite) TestListRecipesPageByPage() {
+
+	for i := 0; i < 5; i++ {
+		recipe := recipeForTest()
+		suite.repo.Recipes[recipe.Id] = recipe
+	}
+
+	request := apiGatewayRequestWithQueryParamForTest(http.MethodGet, "recipetype", "baking")
+	request.QueryStringParameters["limit"] = "2"
+	ids := make(map[string]bool)
+	pages := 0
+	for {
+		response, err := suite.handler.handle(context.Background(), request)
+		suite.Nil(err)
+		suite.assertSuccessfulResponse(response)
+		items, next, err := getRecipePageFromResponse(response)
+		suite.Nil(err)
+		suite.True(len(items) <= 2)
+		for _, recipe := range items {
+			ids[recipe.Id] = true
+		}
+		pages++
+		if next == nil || pages > 3 {
+			break
+		}
+		nextUrl, err := url.Parse(*next)
+		suite.Nil(err)
+		suite.Equal("/recipes", nextUrl.Path)
+		suite.Equal("2", nextUrl.Query().Get("limit"))
+		request.QueryStringParameters["cursor"] = nextUrl.Query().Get("cursor")
+	}
+	suite.Equal(3, pages)
+	suite.Len(ids, 5)
+
+	request.QueryStringParameters["cursor"] = "xxx"
+	response, err := suite.handler.handle(context.Background(), request)
+	suite.Nil(err)
+	suite.assertProblemResponse(response, http.StatusBadRequest)
+}
+
+// Test next page links keep all recipe types of multi value query params.
+func (suite *HandlerTestSuite) TestListRecipesOfMultipleTypesPageByPage() {
+
+	for i := 0; i < 6; i++ {
+		recipe := recipeForTest()
+		if i%2 == 0 {
+			recipe.Type = model.CookingRecipe
+		}
+		suite.repo.Recipes[recipe.Id] = recipe
+	}
+
+	request := apiGatewayRequestForTest(http.MethodGet, nil, nil)
+	request.QueryStringParameters = map[string]string{"recipetype": "cooking", "limit": "2"}
+	request.MultiValueQueryStringParameters = map[string][]string{"recipetype": {"baking", "cooking"}, "limit": {"2"}}
+	ids := make(map[string]bool)
+	pages := 0
+	for {
+		response, err := suite.handler.handle(context.Background(), request)
+		suite.Nil(err)
+		suite.assertSuccessfulResponse(response)
+		items, next, err := getRecipePageFromResponse(response)
+		suite.Nil(err)
+		for _, recipe := range items {
+			ids[recipe.Id] = true
+		}
+		pages++
+		if next == nil || pages > 3 {
+			break
+		}
+		suite.Equal(fmt.Sprintf("<%s>; rel=\"next\"", *next), response.Headers["Link"])
+		nextUrl, err := url.Parse(*next)
+		suite.Nil(err)
+		suite.Equal([]string{"baking", "cooking"}, nextUrl.Query()["recipetype"])
+		request.QueryStringParameters = map[string]string{}
+		for name, values := range nextUrl.Query() {
+			request.QueryStringParameters[name] = values[len(values)-1]
+		}
+		request.MultiValueQueryStringParameters = nextUrl.Query()
+	}
+	suite.Equal(3, pages)
+	suite.Len(ids, 6)
+}
+
+// Test lists without limit are returned with default page size.
+func (suite *HandlerTestSuite) TestListRecipesWithoutPaging() {
+
+	for i := 0; i < defaultPageSize+5; i++ {
+		recipe := recipeForTest()
+		suite.repo.Recipes[recipe.Id] = recipe
+	}
+
+	request := apiGatewayRequestForTest(http.MethodGet, nil, nil)
+	response, err := suite.handler.handle(context.Background(), request)
+	suite.Nil(err)
+	suite.assertSuccessfulResponse(response)
+	recipes, err := getRecipeListFromResponse(response)
+	suite.Nil(err)
+	suite.Len(recipes, defaultPageSize+5)
+	suite.Empty(response.Headers["Link"])
+
+	request.QueryStringParameters = map[string]string{"limit": "100"}
+	response, err = suite.handler.handle(context.Background(), request)
+	suite.Nil(err)
+	items, next, err := getRecipePageFromResponse(response)
+	suite.Nil(err)
+	suite.Len(items, defaultPageSize+5)
+	suite.Nil(next)
+	suite.Empty(response.Headers["Link"])
+
+	cursor := encodeCursor(listCursor{Sort: defaultSortOrder, Key: "", Id: "0"})
+	request.QueryStringParameters = map[string]string{"cursor": cursor}
+	response, err = suite.handler.handle(context.Background(), request)
+	suite.Nil(err)
+	items, next, err = getRecipePageFromResponse(response)
+	suite.Nil(err)
+	suite.Len(items, defaultPageSize)
+	suite.NotNil(next)
+	suite.Contains(response.Headers["Link"], "cursor=")
 }
 
 // Test updating recipes.
@@ -131,13 +322,75 @@ func (suite *HandlerTestSuite) TestUpdateRecipe() {
 	notExistingId := utils.NewId()
 	request2 := apiGatewayRequestForTest(http.MethodPut, &requestBody, &notExistingId)
 	response2, err := suite.handler.handle(context.Background(), request2)
-	suite.NotNil(err)
-	suite.assertResponseStatusCode(response2, http.StatusInternalServerError)
+	suite.Nil(err)
+	suite.assertProblemResponse(response2, http.StatusUnprocessableEntity)
+
+	recipe.Id = notExistingId
+	notExistingBody, err := toRequestBody(recipe)
+	suite.Nil(err)
+	request2_1 := apiGatewayRequestForTest(http.MethodPut, &notExistingBody, &notExistingId)
+	response2_1, err := suite.handler.handle(context.Background(), request2_1)
+	suite.Nil(err)
+	suite.assertProblemResponse(response2_1, http.StatusNotFound)
 
 	request3 := apiGatewayRequestForTest(http.MethodPut, &requestBody, nil)
 	response3, err := suite.handler.handle(context.Background(), request3)
-	suite.NotNil(err)
-	suite.assertResponseStatusCode(response3, http.StatusBadRequest)
+	suite.Nil(err)
+	suite.assertProblemResponse(response3, http.StatusMethodNotAllowed)
+}
+
+// Test patching recipes with JSON merge patch and JSON patch.
+func (suite *HandlerTestSuite) TestPatchRecipe() {
+
+	recipe := recipeForTest()
+	suite.repo.Recipes[recipe.Id] = recipe
+
+	request := apiGatewayPatchRequestForTest(contentTypeMergePatch, `{"title": "Bake a Pie", "ingredients": null}`, recipe.Id)
+	response, err := suite.handler.handle(context.Background(), request)
+	suite.Nil(err)
+	suite.assertSuccessfulResponse(response)
+
+	responseRecipe, err := getRecipeFromResponse(response)
+	suite.Nil(err)
+	suite.Equal("Bake a Pie", responseRecipe.Title)
+	suite.Equal("", responseRecipe.Ingredients)
+	suite.Equal(recipe.Description, responseRecipe.Description)
+	suite.Equal(recipe.Type, responseRecipe.Type)
+	suite.Equal("Bake a Pie", suite.repo.Recipes[recipe.Id].Title)
+
+	jsonPatch := `[{"op": "test", "path": "/title", "value": "Bake a Pie"}, {"op": "replace", "path": "/type", "value": "cooking"}, {"op": "copy", "from": "/title", "path": "/description"}]`
+	request2 := apiGatewayPatchRequestForTest(contentTypeJsonPatch+"; charset=utf-8", jsonPatch, recipe.Id)
+	response2, err := suite.handler.handle(context.Background(), request2)
+	suite.Nil(err)
+	suite.assertSuccessfulResponse(response2)
+
+	responseRecipe2, err := getRecipeFromResponse(response2)
+	suite.Nil(err)
+	suite.Equal(model.CookingRecipe, responseRecipe2.Type)
+	suite.Equal("Bake a Pie", responseRecipe2.Description)
+
+	failedTest := `[{"op": "test", "path": "/title", "value": "Bake a Cake"}, {"op": "remove", "path": "/description"}]`
+	response3, err := suite.handler.handle(context.Background(), apiGatewayPatchRequestForTest(contentTypeJsonPatch, failedTest, recipe.Id))
+	suite.Nil(err)
+	suite.assertProblemResponse(response3, http.StatusConflict)
+	suite.Equal("Bake a Pie", suite.repo.Recipes[recipe.Id].Description)
+
+	response4, err := suite.handler.handle(context.Background(), apiGatewayPatchRequestForTest(contentTypeMergePatch, `{"title": null}`, recipe.Id))
+	suite.Nil(err)
+	suite.assertProblemResponse(response4, http.StatusUnprocessableEntity)
+
+	response5, err := suite.handler.handle(context.Background(), apiGatewayPatchRequestForTest("application/json", `{"title": "xxx"}`, recipe.Id))
+	suite.Nil(err)
+	suite.assertProblemResponse(response5, http.StatusUnsupportedMediaType)
+	suite.Equal(contentTypeMergePatch+", "+contentTypeJsonPatch, response5.Headers["Accept-Patch"])
+
+	response6, err := suite.handler.handle(context.Background(), apiGatewayPatchRequestForTest(contentTypeJsonPatch, `[{"op": "rename"}]`, recipe.Id))
+	suite.Nil(err)
+	suite.assertProblemResponse(response6, http.StatusBadRequest)
+
+	response7, err := suite.handler.handle(context.Background(), apiGatewayPatchRequestForTest(contentTypeMergePatch, `{"title": "xxx"}`, utils.NewId()))
+	suite.Nil(err)
+	suite.assertProblemResponse(response7, http.StatusNotFound)
 }
 
 // Test delete a recipes.
@@ -161,13 +414,349 @@ func (suite *HandlerTestSuite) TestDeleteRecipe() {
 	notExistingId := utils.NewId()
 	request2 := apiGatewayRequestForTest(http.MethodDelete, nil, &notExistingId)
 	response2, err := suite.handler.handle(context.Background(), request2)
-	suite.NotNil(err)
-	suite.assertResponseStatusCode(response2, http.StatusInternalServerError)
+	suite.Nil(err)
+	suite.assertProblemResponse(response2, http.StatusNotFound)
 
 	request3 := apiGatewayRequestForTest(http.MethodDelete, nil, nil)
 	response3, err := suite.handler.handle(context.Background(), request3)
-	suite.NotNil(err)
-	suite.assertResponseStatusCode(response3, http.StatusBadRequest)
+	suite.Nil(err)
+	suite.assertProblemResponse(response3, http.StatusMethodNotAllowed)
+}
+
+// Test listing recipes with sort order and filters.
+func (suite *HandlerTestSuite) TestListRecipesSortedAndFiltered() {
+
+	createdAt := time.Date(2021, 6, 1, 12, 0, 0, 0, time.UTC)
+	for idx, title := range []string{"Cheesecake", "Apple Cake", "Leek Soup"} {
+		recipe := recipeForTest()
+		recipe.Title = title
+		recipe.CreatedAt = createdAt.Add(time.Duration(idx) * time.Hour)
+		suite.repo.Recipes[recipe.Id] = recipe
+	}
+
+	request := apiGatewayRequestForTest(http.MethodGet, nil, nil)
+	request.QueryStringParameters["sort"] = "title"
+	response, err := suite.handler.handle(context.Background(), request)
+	suite.Nil(err)
+	suite.assertSuccessfulResponse(response)
+	recipes, err := getRecipeListFromResponse(response)
+	suite.Nil(err)
+	suite.Equal([]string{"Apple Cake", "Cheesecake", "Leek Soup"}, recipeTitles(recipes))
+
+	request.QueryStringParameters = map[string]string{"sort": "-createdat", "title": "cake", "limit": "1"}
+	response2, err := suite.handler.handle(context.Background(), request)
+	suite.Nil(err)
+	suite.assertSuccessfulResponse(response2)
+	items, next, err := getRecipePageFromResponse(response2)
+	suite.Nil(err)
+	suite.Equal([]string{"Apple Cake"}, recipeTitles(items))
+	suite.NotNil(next)
+
+	nextUrl, err := url.Parse(*next)
+	suite.Nil(err)
+	request.QueryStringParameters["cursor"] = nextUrl.Query().Get("cursor")
+	response3, err := suite.handler.handle(context.Background(), request)
+	suite.Nil(err)
+	items3, next3, err := getRecipePageFromResponse(response3)
+	suite.Nil(err)
+	suite.Equal([]string{"Cheesecake"}, recipeTitles(items3))
+	suite.Nil(next3)
+
+	request.QueryStringParameters = map[string]string{"createdafter": "2021-06-01T13:00:00Z", "createdbefore": "2021-06-01T14:00:00Z"}
+	response4, err := suite.handler.handle(context.Background(), request)
+	suite.Nil(err)
+	recipes4, err := getRecipeListFromResponse(response4)
+	suite.Nil(err)
+	suite.Equal([]string{"Apple Cake"}, recipeTitles(recipes4))
+
+	request.QueryStringParameters = map[string]string{"sort": "ingredients"}
+	response5, err := suite.handler.handle(context.Background(), request)
+	suite.Nil(err)
+	suite.assertProblemResponse(response5, http.StatusBadRequest)
+
+	request.QueryStringParameters = map[string]string{"createdafter": "last week"}
+	response6, err := suite.handler.handle(context.Background(), request)
+	suite.Nil(err)
+	suite.assertProblemResponse(response6, http.StatusBadRequest)
+}
+
+// Test reducing returned recipes to requested fields.
+func (suite *HandlerTestSuite) TestGetRecipesWithFields() {
+
+	recipe := recipeForTest()
+	suite.repo.Recipes[recipe.Id] = recipe
+
+	request := apiGatewayRequestForTest(http.MethodGet, nil, &recipe.Id)
+	request.QueryStringParameters["fields"] = "id,title"
+	response, err := suite.handler.handle(context.Background(), request)
+	suite.Nil(err)
+	suite.assertSuccessfulResponse(response)
+	values := make(map[string]interface{})
+	suite.Nil(json.Unmarshal([]byte(response.Body), &values))
+	suite.Equal(map[string]interface{}{"Id": recipe.Id, "Title": recipe.Title}, values)
+
+	request2 := apiGatewayRequestForTest(http.MethodGet, nil, nil)
+	request2.QueryStringParameters["fields"] = "id,type,title,createdat"
+	response2, err := suite.handler.handle(context.Background(), request2)
+	suite.Nil(err)
+	suite.assertSuccessfulResponse(response2)
+	list := []map[string]interface{}{}
+	suite.Nil(json.Unmarshal([]byte(response2.Body), &list))
+	suite.Len(list, 1)
+	suite.Len(list[0], 4)
+	suite.NotContains(list[0], "Description")
+
+	request2.QueryStringParameters["limit"] = "1"
+	response3, err := suite.handler.handle(context.Background(), request2)
+	suite.Nil(err)
+	suite.assertSuccessfulResponse(response3)
+	page := struct {
+		Items []map[string]interface{} `json:"items"`
+	}{}
+	suite.Nil(json.Unmarshal([]byte(response3.Body), &page))
+	suite.Len(page.Items, 1)
+	suite.Len(page.Items[0], 4)
+
+	request.QueryStringParameters["fields"] = "id,calories"
+	response4, err := suite.handler.handle(context.Background(), request)
+	suite.Nil(err)
+	suite.assertProblemResponse(response4, http.StatusBadRequest)
+
+	request2.QueryStringParameters["fields"] = "ID,Secret"
+	response5, err := suite.handler.handle(context.Background(), request2)
+	suite.Nil(err)
+	suite.assertProblemResponse(response5, http.StatusBadRequest)
+}
+
+// Test conditional requests with ETag, If-None-Match and If-Match headers.
+func (suite *HandlerTestSuite) TestConditionalRequests() {
+
+	recipe := recipeForTest()
+	requestBody, err := toRequestBody(recipe)
+	suite.Nil(err)
+	response, err := suite.handler.handle(context.Background(), apiGatewayRequestForTest(http.MethodPost, &requestBody, nil))
+	suite.Nil(err)
+	suite.assertSuccessfulResponse(response)
+	etag := response.Headers["ETag"]
+	suite.NotEmpty(etag)
+
+	getRequest := apiGatewayRequestForTest(http.MethodGet, nil, &recipe.Id)
+	response2, err := suite.handler.handle(context.Background(), getRequest)
+	suite.Nil(err)
+	suite.assertSuccessfulResponse(response2)
+	suite.Equal(etag, response2.Headers["ETag"])
+
+	getRequest.Headers = map[string]string{"if-none-match": etag}
+	response3, err := suite.handler.handle(context.Background(), getRequest)
+	suite.Nil(err)
+	suite.assertResponseStatusCode(response3, http.StatusNotModified)
+	suite.Equal(etag, response3.Headers["ETag"])
+	suite.Empty(response3.Body)
+
+	variantRequests := []events.APIGatewayProxyRequest{
+		apiGatewayRequestForTest(http.MethodGet, nil, &recipe.Id),
+		apiGatewayRequestForTest(http.MethodGet, nil, &recipe.Id),
+		apiGatewayRequestForTest(http.MethodGet, nil, &recipe.Id),
+		apiGatewayRequestForTest(http.MethodGet, nil, &recipe.Id),
+		apiGatewayRequestForTest(http.MethodGet, nil, &recipe.Id),
+	}
+	variantRequests[0].QueryStringParameters = map[string]string{"fields": "title"}
+	variantRequests[1].QueryStringParameters = map[string]string{"scale": "2"}
+	variantRequests[2].QueryStringParameters = map[string]string{"scale": "3"}
+	variantRequests[3].Headers = map[string]string{"Accept": "text/markdown"}
+	variantRequests[4].Headers = map[string]string{"Accept": "text/html"}
+	variantETags := []string{etag}
+	for _, variantRequest := range variantRequests {
+		variantResponse, err := suite.handler.handle(context.Background(), variantRequest)
+		suite.Nil(err)
+		suite.assertSuccessfulResponse(variantResponse)
+		variantTag := variantResponse.Headers["ETag"]
+		suite.True(strings.HasPrefix(variantTag, weakETagPrefix))
+		for _, otherETag := range variantETags {
+			suite.NotEqual(strings.TrimPrefix(otherETag, weakETagPrefix), strings.TrimPrefix(variantTag, weakETagPrefix))
+		}
+		variantETags = append(variantETags, variantTag)
+
+		variantRequest.Headers = map[string]string{"Accept": variantRequest.Headers["Accept"], "If-None-Match": variantTag}
+		variantResponse, err = suite.handler.handle(context.Background(), variantRequest)
+		suite.Nil(err)
+		suite.assertResponseStatusCode(variantResponse, http.StatusNotModified)
+	}
+
+	// Entity tag of a variant doesn't match other variants, e.g. other scale factors or media types.
+	variantRequests[2].Headers = map[string]string{"If-None-Match": variantETags[2]}
+	variantResponse, err := suite.handler.handle(context.Background(), variantRequests[2])
+	suite.Nil(err)
+	suite.assertSuccessfulResponse(variantResponse)
+	variantRequests[4].Headers = map[string]string{"Accept": "text/html", "If-None-Match": variantETags[4]}
+	variantResponse, err = suite.handler.handle(context.Background(), variantRequests[4])
+	suite.Nil(err)
+	suite.assertResponseStatusCode(variantResponse, http.StatusOK)
+
+	recipe.Title = "Changed Title"
+	requestBody, _ = toRequestBody(recipe)
+	weakPutRequest := apiGatewayRequestForTest(http.MethodPut, &requestBody, &recipe.Id)
+	weakPutRequest.Headers = map[string]string{"If-Match": variantETags[1]}
+	weakPutResponse, err := suite.handler.handle(context.Background(), weakPutRequest)
+	suite.Nil(err)
+	suite.assertProblemResponse(weakPutResponse, http.StatusPreconditionFailed)
+
+	putRequest := apiGatewayRequestForTest(http.MethodPut, &requestBody, &recipe.Id)
+	putRequest.Headers = map[string]string{"If-Match": etag}
+	response4, err := suite.handler.handle(context.Background(), putRequest)
+	suite.Nil(err)
+	suite.assertSuccessfulResponse(response4)
+	newETag := response4.Headers["ETag"]
+	suite.NotEqual(etag, newETag)
+
+	// second client still uses the old ETag.
+	response5, err := suite.handler.handle(context.Background(), putRequest)
+	suite.Nil(err)
+	suite.assertProblemResponse(response5, http.StatusPreconditionFailed)
+
+	patchRequest := apiGatewayPatchRequestForTest(contentTypeMergePatch, `{"title": "Patched Title"}`, recipe.Id)
+	patchRequest.Headers["If-Match"] = etag
+	response6, err := suite.handler.handle(context.Background(), patchRequest)
+	suite.Nil(err)
+	suite.assertProblemResponse(response6, http.StatusPreconditionFailed)
+
+	patchRequest.Headers["If-Match"] = newETag
+	response7, err := suite.handler.handle(context.Background(), patchRequest)
+	suite.Nil(err)
+	suite.assertSuccessfulResponse(response7)
+	suite.NotEqual(newETag, response7.Headers["ETag"])
+
+	deleteRequest := apiGatewayRequestForTest(http.MethodDelete, nil, &recipe.Id)
+	deleteRequest.Headers = map[string]string{"If-Match": newETag}
+	response8, err := suite.handler.handle(context.Background(), deleteRequest)
+	suite.Nil(err)
+	suite.assertProblemResponse(response8, http.StatusPreconditionFailed)
+
+	deleteRequest.Headers["If-Match"] = response7.Headers["ETag"]
+	response9, err := suite.handler.handle(context.Background(), deleteRequest)
+	suite.Nil(err)
+	suite.assertSuccessfulResponse(response9)
+}
+
+// Test If-Match header is mandatory to change recipes if it's enabled in config.
+func (suite *HandlerTestSuite) TestRequiredIfMatch() {
+
+	recipe := recipeForTest()
+	suite.repo.Recipes[recipe.Id] = recipe
+	factory := factoryForTest(suite.repo, suite.publisher, loggerForTest())
+	factory.config = configForTest("etag:\n  requireifmatch: true\n")
+	handler := routerWithFactoryForTest(factory, loggerForTest())
+
+	requestBody, _ := toRequestBody(recipe)
+	response, err := handler.handle(context.Background(), apiGatewayRequestForTest(http.MethodPut, &requestBody, &recipe.Id))
+	suite.Nil(err)
+	suite.assertProblemResponse(response, http.StatusPreconditionRequired)
+
+	response2, err := handler.handle(context.Background(), apiGatewayRequestForTest(http.MethodDelete, nil, &recipe.Id))
+	suite.Nil(err)
+	suite.assertProblemResponse(response2, http.StatusPreconditionRequired)
+
+	etag, _ := recipeETag(recipe)
+	deleteRequest := apiGatewayRequestForTest(http.MethodDelete, nil, &recipe.Id)
+	deleteRequest.Headers = map[string]string{"If-Match": etag}
+	response3, err := handler.handle(context.Background(), deleteRequest)
+	suite.Nil(err)
+	suite.assertSuccessfulResponse(response3)
+}
+
+// Test full-text search for recipes.
+func (suite *HandlerTestSuite) TestSearchRecipes() {
+
+	existingRecipe := recipeForTest()
+	existingRecipe.Title = "Leek Soup"
+	existingRecipe.Ingredients = "2 leeks"
+	suite.repo.Recipes[existingRecipe.Id] = existingRecipe
+
+	request := apiGatewayRequestWithPathForTest(http.MethodGet, "/recipes/search")
+	request.QueryStringParameters["q"] = "leeks"
+	response1, err := suite.handler.handle(context.Background(), request)
+	suite.Nil(err)
+	suite.assertSuccessfulResponse(response1)
+	result1, err := getSearchResponseFromResponse(response1)
+	suite.Nil(err)
+	suite.Len(result1.Items, 1)
+
+	newRecipe := newRecipeForTest()
+	newRecipe.Title = "Potato Salad"
+	newRecipe.Ingredients = "1kg potatoes"
+	newRecipe.Description = "Serve with fried <leek>."
+	requestBody, err := toRequestBody(newRecipe)
+	suite.Nil(err)
+	response, err := suite.handler.handle(context.Background(), apiGatewayRequestForTest(http.MethodPost, &requestBody, nil))
+	suite.Nil(err)
+	suite.assertSuccessfulResponse(response)
+
+	response2, err := suite.handler.handle(context.Background(), request)
+	suite.Nil(err)
+	suite.assertSuccessfulResponse(response2)
+	result, err := getSearchResponseFromResponse(response2)
+	suite.Nil(err)
+	suite.Equal("leeks", result.Query)
+	suite.Len(result.Items, 2)
+	suite.Equal(existingRecipe.Id, result.Items[0].Recipe.Id)
+	suite.Equal("<mark>Leek</mark> Soup", result.Items[0].Highlights["title"])
+	suite.Equal("2 <mark>leeks</mark>", result.Items[0].Highlights["ingredients"])
+	suite.Equal("Serve with fried &lt;<mark>leek</mark>&gt;.", result.Items[1].Highlights["description"])
+	suite.True(result.Items[0].Score > result.Items[1].Score)
+
+	request.QueryStringParameters["limit"] = "1"
+	response3, err := suite.handler.handle(context.Background(), request)
+	suite.Nil(err)
+	suite.assertSuccessfulResponse(response3)
+	result3, err := getSearchResponseFromResponse(response3)
+	suite.Nil(err)
+	suite.Len(result3.Items, 1)
+
+	response4, err := suite.handler.handle(context.Background(), apiGatewayRequestForTest(http.MethodDelete, nil, &existingRecipe.Id))
+	suite.Nil(err)
+	suite.assertSuccessfulResponse(response4)
+	delete(request.QueryStringParameters, "limit")
+	response5, err := suite.handler.handle(context.Background(), request)
+	suite.Nil(err)
+	result5, err := getSearchResponseFromResponse(response5)
+	suite.Nil(err)
+	suite.Len(result5.Items, 1)
+
+	request6 := apiGatewayRequestWithPathForTest(http.MethodGet, "/recipes/search")
+	response6, err := suite.handler.handle(context.Background(), request6)
+	suite.Nil(err)
+	suite.assertProblemResponse(response6, http.StatusBadRequest)
+
+	request6.QueryStringParameters = map[string]string{"q": "leek", "limit": "0"}
+	response7, err := suite.handler.handle(context.Background(), request6)
+	suite.Nil(err)
+	suite.assertProblemResponse(response7, http.StatusBadRequest)
+}
+
+// Test recipes created before search has been available are found, even if other recipes have been indexed before.
+func (suite *HandlerTestSuite) TestSearchRecipesCreatedBeforeIndex() {
+
+	existingRecipe := recipeForTest()
+	existingRecipe.Title = "Leek Soup"
+	suite.repo.Recipes[existingRecipe.Id] = existingRecipe
+
+	newRecipe := newRecipeForTest()
+	newRecipe.Title = "Potato Salad"
+	requestBody, err := toRequestBody(newRecipe)
+	suite.Nil(err)
+	response, err := suite.handler.handle(context.Background(), apiGatewayRequestForTest(http.MethodPost, &requestBody, nil))
+	suite.Nil(err)
+	suite.assertSuccessfulResponse(response)
+
+	request := apiGatewayRequestWithPathForTest(http.MethodGet, "/recipes/search")
+	request.QueryStringParameters["q"] = "leek"
+	response, err = suite.handler.handle(context.Background(), request)
+	suite.Nil(err)
+	suite.assertSuccessfulResponse(response)
+	result, err := getSearchResponseFromResponse(response)
+	suite.Nil(err)
+	suite.Len(result.Items, 1)
+	suite.Equal(existingRecipe.Id, result.Items[0].Recipe.Id)
 }
 
 // Assert a successful response status between 200 and 299.
@@ -180,7 +769,104 @@ func (suite *HandlerTestSuite) assertResponseStatusCode(response events.APIGatew
 	suite.Equal(expectedStatusCode, response.StatusCode)
 }
 
+// Assert that response has expected status code and a problem details body.
+func (suite *HandlerTestSuite) assertProblemResponse(response events.APIGatewayProxyResponse, expectedStatusCode int) {
+	suite.assertResponseStatusCode(response, expectedStatusCode)
+	suite.Equal("application/problem+json", response.Headers["Content-Type"])
+	problem, err := getProblemDetailsFromResponse(response)
+	suite.Nil(err)
+	suite.Equal(expectedStatusCode, problem.Status)
+}
+
 func toRequestBody(recipe model.Recipe) (string, error) {
 	jsonBytes, err := json.Marshal(recipe)
 	return string(jsonBytes), err
 }
+
+// Test content negotiation for single recipes.
+func (suite *HandlerTestSuite) TestGetRecipeRepresentations() {
+
+	recipe := recipeForTest()
+	requestBody, err := toRequestBody(recipe)
+	suite.Nil(err)
+	response, err := suite.handler.handle(context.Background(), apiGatewayRequestForTest(http.MethodPost, &requestBody, nil))
+	suite.Nil(err)
+	suite.assertSuccessfulResponse(response)
+	suite.Equal("application/json; charset=utf-8", response.Headers["Content-Type"])
+
+	getRequest := apiGatewayRequestForTest(http.MethodGet, nil, &recipe.Id)
+	response2, err := suite.handler.handle(context.Background(), getRequest)
+	suite.Nil(err)
+	suite.assertSuccessfulResponse(response2)
+	suite.Equal("application/json; charset=utf-8", response2.Headers["Content-Type"])
+	suite.Equal("Accept", response2.Headers["Vary"])
+	jsonETag := response2.Headers["ETag"]
+
+	getRequest.Headers = map[string]string{"Accept": "text/markdown"}
+	response3, err := suite.handler.handle(context.Background(), getRequest)
+	suite.Nil(err)
+	suite.assertSuccessfulResponse(response3)
+	suite.Equal("text/markdown; charset=utf-8", response3.Headers["Content-Type"])
+	suite.Contains(response3.Body, "# Bake a Cake")
+	suite.NotEqual(jsonETag, response3.Headers["ETag"])
+
+	getRequest.Headers = map[string]string{"Accept": "application/yaml"}
+	response4, err := suite.handler.handle(context.Background(), getRequest)
+	suite.Nil(err)
+	suite.assertSuccessfulResponse(response4)
+	suite.Equal("application/yaml; charset=utf-8", response4.Headers["Content-Type"])
+	suite.Contains(response4.Body, "Title: Bake a Cake")
+
+	getRequest.Headers = map[string]string{"Accept": "text/plain;q=0.5, application/json"}
+	response5, err := suite.handler.handle(context.Background(), getRequest)
+	suite.Nil(err)
+	suite.assertSuccessfulResponse(response5)
+	suite.Equal("application/json; charset=utf-8", response5.Headers["Content-Type"])
+
+	getRequest.Headers = map[string]string{"Accept": "image/png"}
+	response6, err := suite.handler.handle(context.Background(), getRequest)
+	suite.Nil(err)
+	suite.assertProblemResponse(response6, http.StatusNotAcceptable)
+
+	listRequest := apiGatewayRequestForTest(http.MethodGet, nil, nil)
+	listRequest.Headers = map[string]string{"Accept": "text/markdown"}
+	response7, err := suite.handler.handle(context.Background(), listRequest)
+	suite.Nil(err)
+	suite.assertProblemResponse(response7, http.StatusNotAcceptable)
+}
+
+// Test getting a recipe as printable HTML page.
+func (suite *HandlerTestSuite) TestPrintRecipe() {
+
+	recipe := recipeForTest()
+	requestBody, err := toRequestBody(recipe)
+	suite.Nil(err)
+	response, err := suite.handler.handle(context.Background(), apiGatewayRequestForTest(http.MethodPost, &requestBody, nil))
+	suite.Nil(err)
+	suite.assertSuccessfulResponse(response)
+
+	printRequest := apiGatewayRequestWithPathForTest(http.MethodGet, "/recipes/"+recipe.Id+"/print")
+	response2, err := suite.handler.handle(context.Background(), printRequest)
+	suite.Nil(err)
+	suite.assertSuccessfulResponse(response2)
+	suite.Equal("text/html; charset=utf-8", response2.Headers["Content-Type"])
+	suite.Contains(response2.Body, "<h1>Bake a Cake</h1>")
+	suite.NotEmpty(response2.Headers["ETag"])
+
+	printRequest.Headers = map[string]string{"Accept": "application/json"}
+	response3, err := suite.handler.handle(context.Background(), printRequest)
+	suite.Nil(err)
+	suite.assertProblemResponse(response3, http.StatusNotAcceptable)
+
+	getRequest := apiGatewayRequestForTest(http.MethodGet, nil, &recipe.Id)
+	getRequest.Headers = map[string]string{"Accept": "text/html,application/xhtml+xml,*/*;q=0.8"}
+	response4, err := suite.handler.handle(context.Background(), getRequest)
+	suite.Nil(err)
+	suite.assertSuccessfulResponse(response4)
+	suite.Equal("text/html; charset=utf-8", response4.Headers["Content-Type"])
+	suite.Equal(response2.Body, response4.Body)
+
+	response5, err := suite.handler.handle(context.Background(), apiGatewayRequestWithPathForTest(http.MethodGet, "/recipes/xxx/print"))
+	suite.Nil(err)
+	suite.assertProblemResponse(response5, http.StatusNotFound)
+}
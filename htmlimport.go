@@ -0,0 +1,375 @@
+package main
+
+import (
+	"regexp"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// ingredientHeadingPattern matches headings of ingredient lists in heuristic extraction.
+var ingredientHeadingPattern = regexp.MustCompile(`(?i)ingredient|zutaten`)
+
+// instructionHeadingPattern matches headings of instructions in heuristic extraction.
+var instructionHeadingPattern = regexp.MustCompile(`(?i)instruction|direction|method|preparation|steps|zubereitung|anleitung`)
+
+// ingredientClassPattern matches class names or ids of elements which contain ingredients.
+var ingredientClassPattern = regexp.MustCompile(`(?i)ingredient`)
+
+// instructionClassPattern matches class names or ids of elements which contain instructions.
+var instructionClassPattern = regexp.MustCompile(`(?i)instruction|direction|preparation|method|steps`)
+
+// whitespacePattern matches sequences of whitespaces.
+var whitespacePattern = regexp.MustCompile(`\s+`)
+
+// blockElements are elements which start a new line in extracted texts.
+var blockElements = map[atom.Atom]bool{
+	atom.Address: true, atom.Article: true, atom.Blockquote: true, atom.Br: true, atom.Dd: true, atom.Div: true,
+	atom.Dl: true, atom.Dt: true, atom.Figcaption: true, atom.Footer: true, atom.H1: true, atom.H2: true,
+	atom.H3: true, atom.H4: true, atom.H5: true, atom.H6: true, atom.Header: true, atom.Hr: true, atom.Li: true,
+	atom.Ol: true, atom.P: true, atom.Pre: true, atom.Section: true, atom.Table: true, atom.Tr: true, atom.Ul: true,
+}
+
+// headingElements are all HTML heading elements.
+var headingElements = map[atom.Atom]bool{
+	atom.H1: true, atom.H2: true, atom.H3: true, atom.H4: true, atom.H5: true, atom.H6: true,
+}
+
+// parseHtmlImportItems extracts recipes from passed HTML page. Embedded schema.org JSON-LD is preferred,
+// microdata is used if a page doesn't contain JSON-LD recipes. If there's neither JSON-LD nor microdata,
+// a draft recipe is extracted heuristically from ingredient lists and instruction blocks.
+func parseHtmlImportItems(document string) ([]importItem, error) {
+
+	root, err := html.Parse(strings.NewReader(document))
+	if err != nil {
+		return nil, newBadRequestError("Request body is not a valid HTML document", err)
+	}
+
+	nodes := htmlJsonLdRecipeNodes(root)
+	if len(nodes) == 0 {
+		nodes = microdataRecipeNodes(root)
+	}
+	if len(nodes) == 0 {
+		if node := heuristicRecipeNode(root); node != nil {
+			nodes = append(nodes, node)
+		}
+	}
+
+	items := []importItem{}
+	for _, node := range nodes {
+		items = append(items, importItem{values: recipeValuesFromJsonLd(node)})
+	}
+	return items, nil
+}
+
+// htmlJsonLdRecipeNodes returns all schema.org Recipes from JSON-LD script elements of passed page.
+// Script elements which doesn't contain valid JSON are skipped.
+func htmlJsonLdRecipeNodes(root *html.Node) []map[string]interface{} {
+
+	nodes := []map[string]interface{}{}
+	for _, element := range htmlElements(root) {
+		if element.DataAtom != atom.Script || mediaType(htmlAttr(element, "type")) != jsonLdMediaType {
+			continue
+		}
+		var value interface{}
+		if err := decodeJson(htmlRawText(element), &value); err == nil {
+			nodes = append(nodes, jsonLdRecipeNodes(value)...)
+		}
+	}
+	return nodes
+}
+
+// microdataRecipeNodes returns all top level microdata items of type schema.org Recipe. Properties of an item
+// are converted to the same structure as JSON-LD nodes, so they can be mapped to recipes the same way.
+func microdataRecipeNodes(root *html.Node) []map[string]interface{} {
+
+	nodes := []map[string]interface{}{}
+	var walk func(node *html.Node)
+	walk = func(node *html.Node) {
+		if node.Type == html.ElementNode && htmlHasAttr(node, "itemscope") && isMicrodataRecipe(node) {
+			nodes = append(nodes, microdataItem(node))
+			return
+		}
+		for child := node.FirstChild; child != nil; child = child.NextSibling {
+			walk(child)
+		}
+	}
+	walk(root)
+	return nodes
+}
+
+// isMicrodataRecipe returns true if itemtype of passed element is a schema.org Recipe.
+func isMicrodataRecipe(element *html.Node) bool {
+
+	for _, itemType := range strings.Fields(htmlAttr(element, "itemtype")) {
+		itemType = strings.TrimSuffix(itemType, "/")
+		if itemType[strings.LastIndex(itemType, "/")+1:] == schemaOrgRecipeType {
+			return true
+		}
+	}
+	return false
+}
+
+// microdataItem collects all properties of passed microdata item. Properties with multiple values
+// are returned as list, nested items as objects.
+func microdataItem(scope *html.Node) map[string]interface{} {
+
+	item := map[string]interface{}{}
+	if itemTypes := strings.Fields(htmlAttr(scope, "itemtype")); len(itemTypes) > 0 {
+		item["@type"] = toInterfaceSlice(itemTypes)
+	}
+
+	var walk func(node *html.Node)
+	walk = func(node *html.Node) {
+		for child := node.FirstChild; child != nil; child = child.NextSibling {
+			if child.Type != html.ElementNode {
+				continue
+			}
+			isNestedItem := htmlHasAttr(child, "itemscope")
+			if names := strings.Fields(htmlAttr(child, "itemprop")); len(names) > 0 {
+				var value interface{}
+				if isNestedItem {
+					value = microdataItem(child)
+				} else {
+					value = microdataValue(child)
+				}
+				for _, name := range names {
+					addMicrodataProperty(item, name, value)
+				}
+			}
+			if !isNestedItem {
+				walk(child)
+			}
+		}
+	}
+	walk(scope)
+	return item
+}
+
+// microdataValue returns the value of a microdata property, which depends on the element it's defined on.
+func microdataValue(element *html.Node) interface{} {
+
+	switch element.DataAtom {
+	case atom.Meta:
+		return htmlAttr(element, "content")
+	case atom.A, atom.Area, atom.Link:
+		return htmlAttr(element, "href")
+	case atom.Audio, atom.Embed, atom.Iframe, atom.Img, atom.Source, atom.Track, atom.Video:
+		return htmlAttr(element, "src")
+	case atom.Data, atom.Meter:
+		return htmlAttr(element, "value")
+	case atom.Time:
+		if htmlHasAttr(element, "datetime") {
+			return htmlAttr(element, "datetime")
+		}
+	}
+	return htmlText(element)
+}
+
+// addMicrodataProperty adds passed value to a property of an item. A property becomes a list
+// if it's defined multiple times.
+func addMicrodataProperty(item map[string]interface{}, name string, value interface{}) {
+
+	switch existingValue := item[name].(type) {
+	case nil:
+		item[name] = value
+	case []interface{}:
+		item[name] = append(existingValue, value)
+	default:
+		item[name] = []interface{}{existingValue, value}
+	}
+}
+
+// heuristicRecipeNode extracts a recipe from a page without structured data. Title is taken from the first
+// h1 element or the page title. Ingredients and instructions are taken from lists or paragraphs which follow
+// a matching heading, or from elements with matching class names. Title is used as category as well, so e.g.
+// an apple cake is imported as baking recipe. It returns nil if neither ingredients nor instructions can be found.
+func heuristicRecipeNode(root *html.Node) map[string]interface{} {
+
+	elements := htmlElements(root)
+	ingredients := heuristicSection(elements, ingredientHeadingPattern, ingredientClassPattern)
+	instructions := heuristicSection(elements, instructionHeadingPattern, instructionClassPattern)
+	if len(ingredients) == 0 && len(instructions) == 0 {
+		return nil
+	}
+
+	node := map[string]interface{}{
+		"@type":              schemaOrgRecipeType,
+		"recipeIngredient":   toInterfaceSlice(ingredients),
+		"recipeInstructions": toInterfaceSlice(instructions),
+	}
+	if title := heuristicTitle(elements); title != "" {
+		node["name"] = title
+		node["recipeCategory"] = title
+	}
+	return node
+}
+
+// heuristicTitle returns text of the first h1 element, the page title or an empty string.
+func heuristicTitle(elements []*html.Node) string {
+
+	for _, tag := range []atom.Atom{atom.H1, atom.Title} {
+		for _, element := range elements {
+			if element.DataAtom == tag {
+				if title := htmlText(element); title != "" {
+					return title
+				}
+			}
+		}
+	}
+	return ""
+}
+
+// heuristicSection returns lines of a section identified by a heading or class name matching given patterns.
+// If a heading matches, items of the first list following it are returned, or all paragraphs until
+// the next heading if there's no list. Otherwise lines of the first element with a matching class or id are used.
+func heuristicSection(elements []*html.Node, headingPattern, classPattern *regexp.Regexp) []string {
+
+	for idx, element := range elements {
+		if !headingElements[element.DataAtom] || !headingPattern.MatchString(htmlText(element)) {
+			continue
+		}
+		paragraphs := []string{}
+		for _, next := range elements[idx+1:] {
+			if headingElements[next.DataAtom] {
+				break
+			}
+			if next.DataAtom == atom.Ul || next.DataAtom == atom.Ol {
+				return htmlListItems(next)
+			}
+			if next.DataAtom == atom.P {
+				paragraphs = append(paragraphs, recipeLines(htmlText(next))...)
+			}
+		}
+		if len(paragraphs) > 0 {
+			return paragraphs
+		}
+	}
+
+	for _, element := range elements {
+		if headingElements[element.DataAtom] || element.DataAtom == atom.Body || element.DataAtom == atom.Html {
+			continue
+		}
+		if !classPattern.MatchString(htmlAttr(element, "class") + " " + htmlAttr(element, "id")) {
+			continue
+		}
+		for _, child := range htmlElements(element) {
+			if child.DataAtom == atom.Ul || child.DataAtom == atom.Ol {
+				return htmlListItems(child)
+			}
+		}
+		if lines := recipeLines(htmlText(element)); len(lines) > 0 {
+			return lines
+		}
+	}
+	return []string{}
+}
+
+// htmlListItems returns texts of all items of passed list.
+func htmlListItems(list *html.Node) []string {
+
+	items := []string{}
+	for child := list.FirstChild; child != nil; child = child.NextSibling {
+		if child.DataAtom == atom.Li {
+			if text := strings.Join(recipeLines(htmlText(child)), " "); text != "" {
+				items = append(items, text)
+			}
+		}
+	}
+	return items
+}
+
+// htmlElements returns all elements of passed tree in document order.
+func htmlElements(root *html.Node) []*html.Node {
+
+	elements := []*html.Node{}
+	var walk func(node *html.Node)
+	walk = func(node *html.Node) {
+		if node.Type == html.ElementNode {
+			elements = append(elements, node)
+		}
+		for child := node.FirstChild; child != nil; child = child.NextSibling {
+			walk(child)
+		}
+	}
+	walk(root)
+	return elements
+}
+
+// htmlText returns the visible text of passed element. Whitespaces are collapsed, but block elements
+// start a new line. Content of scripts, styles and templates is skipped.
+func htmlText(element *html.Node) string {
+
+	text := strings.Builder{}
+	var walk func(node *html.Node)
+	walk = func(node *html.Node) {
+		switch node.Type {
+		case html.TextNode:
+			text.WriteString(whitespacePattern.ReplaceAllString(node.Data, " "))
+		case html.ElementNode:
+			if node.DataAtom == atom.Script || node.DataAtom == atom.Style || node.DataAtom == atom.Template || node.DataAtom == atom.Noscript {
+				return
+			}
+			if blockElements[node.DataAtom] {
+				text.WriteString("\n")
+			}
+		}
+		for child := node.FirstChild; child != nil; child = child.NextSibling {
+			walk(child)
+		}
+		if node.Type == html.ElementNode && blockElements[node.DataAtom] {
+			text.WriteString("\n")
+		}
+	}
+	walk(element)
+
+	lines := []string{}
+	for _, line := range strings.Split(text.String(), "\n") {
+		if line = strings.TrimSpace(whitespacePattern.ReplaceAllString(line, " ")); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// htmlRawText returns the unprocessed content of passed element, e.g. of a script element.
+func htmlRawText(element *html.Node) string {
+
+	text := strings.Builder{}
+	for child := element.FirstChild; child != nil; child = child.NextSibling {
+		if child.Type == html.TextNode {
+			text.WriteString(child.Data)
+		}
+	}
+	return text.String()
+}
+
+// htmlAttr returns the value of an attribute of passed element or an empty string if it's not defined.
+func htmlAttr(element *html.Node, name string) string {
+	for _, attr := range element.Attr {
+		if strings.EqualFold(attr.Key, name) {
+			return attr.Val
+		}
+	}
+	return ""
+}
+
+// htmlHasAttr returns true if passed element has given attribute.
+func htmlHasAttr(element *html.Node, name string) bool {
+	for _, attr := range element.Attr {
+		if strings.EqualFold(attr.Key, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// toInterfaceSlice converts passed list of strings to a list of empty interfaces, as used in decoded JSON.
+func toInterfaceSlice(values []string) []interface{} {
+	list := []interface{}{}
+	for _, value := range values {
+		list = append(list, value)
+	}
+	return list
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+	"golang.org/x/net/html"
+)
+
+// Test suite for importing recipes from HTML pages.
+type HtmlImportTestSuite struct {
+	suite.Suite
+}
+
+func TestHtmlImportTestSuite(t *testing.T) {
+	suite.Run(t, new(HtmlImportTestSuite))
+}
+
+// Test extracting recipes from JSON-LD embedded in a page.
+func (suite *HtmlImportTestSuite) TestImportJsonLdPage() {
+
+	items := suite.importItemsFromFixture("jsonld.html")
+	suite.Len(items, 1)
+	suite.Equal("Classic Banana Bread", items[0].values["title"])
+	suite.Equal("baking", items[0].values["type"])
+	suite.Equal("3 ripe bananas\n250 g flour\n100 g butter & sugar", items[0].values["ingredients"])
+	suite.Equal("Preheat oven to 180 °C.\nMash bananas and mix with all other ingredients.\nBake for 60 minutes.", items[0].values["description"])
+}
+
+// Test extracting recipes from microdata.
+func (suite *HtmlImportTestSuite) TestImportMicrodataPage() {
+
+	items := suite.importItemsFromFixture("microdata.html")
+	suite.Len(items, 1)
+	suite.Equal("Tomato Soup", items[0].values["title"])
+	suite.Equal("cooking", items[0].values["type"])
+	suite.Equal("1 kg tomatoes\n1 onion, chopped\n500 ml vegetable stock", items[0].values["ingredients"])
+	suite.Equal("Sauté onion.\nAdd tomatoes and stock, simmer for 20 minutes.\nBlend & season.", items[0].values["description"])
+}
+
+// Test heuristic extraction of recipes by headings.
+func (suite *HtmlImportTestSuite) TestImportPageByHeadings() {
+
+	items := suite.importItemsFromFixture("heuristic.html")
+	suite.Len(items, 1)
+	suite.Equal("Grandma's Apple Cake", items[0].values["title"])
+	suite.Equal("baking", items[0].values["type"])
+	suite.Equal("4 apples\n200 g flour\n150 g sugar\n3 eggs", items[0].values["ingredients"])
+	suite.Equal("Peel and slice apples.\nMix flour, sugar and eggs.\nPour batter into a tin and top with apples.\nBake for 45 minutes.", items[0].values["description"])
+}
+
+// Test heuristic extraction of recipes by class names.
+func (suite *HtmlImportTestSuite) TestImportPageByClassNames() {
+
+	items := suite.importItemsFromFixture("classnames.html")
+	suite.Len(items, 1)
+	suite.Equal("Pasta Aglio e Olio", items[0].values["title"])
+	suite.Equal("cooking", items[0].values["type"])
+	suite.Equal("200 g spaghetti\n3 cloves garlic\nolive oil", items[0].values["ingredients"])
+	suite.Equal("Cook spaghetti.\nFry garlic in olive oil.\nMix and serve.", items[0].values["description"])
+}
+
+// Test pages without recipes.
+func (suite *HtmlImportTestSuite) TestImportPageWithoutRecipe() {
+	suite.Len(suite.importItemsFromFixture("norecipe.html"), 0)
+}
+
+// Test importing a page by an import request.
+func (suite *HtmlImportTestSuite) TestImportRequest() {
+
+	repo := repositoryForTest()
+	router := routerForTest(repo, publisherForTest(), loggerForTest())
+
+	response, err := router.handle(context.Background(), importRequestForTest("text/html; charset=utf-8", suite.fixture("microdata.html")))
+	suite.Nil(err)
+	suite.Equal(http.StatusOK, response.StatusCode)
+	suite.Contains(response.Body, `"created":1`)
+	suite.Len(repo.Recipes, 1)
+	for _, recipe := range repo.Recipes {
+		suite.Equal("Tomato Soup", recipe.Title)
+	}
+
+	response, err = router.handle(context.Background(), importRequestForTest("text/html", suite.fixture("norecipe.html")))
+	suite.Nil(err)
+	suite.Equal(http.StatusBadRequest, response.StatusCode)
+}
+
+// Test extracting visible text of elements.
+func (suite *HtmlImportTestSuite) TestHtmlText() {
+
+	root, err := html.Parse(strings.NewReader("<div> A  <b>bold</b>\n text<p>Paragraph</p>x<br>y<script>var a;</script></div>"))
+	suite.Nil(err)
+	suite.Equal("A bold text\nParagraph\nx\ny", htmlText(root))
+}
+
+// importItemsFromFixture returns import items extracted from passed fixture page.
+func (suite *HtmlImportTestSuite) importItemsFromFixture(name string) []importItem {
+	items, err := parseImportItems(htmlMediaType, suite.fixture(name))
+	suite.Nil(err)
+	return items
+}
+
+// fixture returns the content of passed fixture page.
+func (suite *HtmlImportTestSuite) fixture(name string) string {
+	content, err := ioutil.ReadFile(filepath.Join("testdata", "import", name))
+	suite.Nil(err)
+	return string(content)
+}
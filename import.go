@@ -0,0 +1,257 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/aws/aws-lambda-go/events"
+	core "github.com/tommzn/recipeboard-core"
+	model "github.com/tommzn/recipeboard-core/model"
+)
+
+const (
+
+	// ndjsonMediaType is the content type of newline delimited JSON documents.
+	ndjsonMediaType = "application/x-ndjson"
+
+	// csvMediaType is the content type of CSV documents.
+	csvMediaType = "text/csv"
+
+	// utf8ByteOrderMark is added to CSV files by some spreadsheet applications.
+	utf8ByteOrderMark = "\ufeff"
+
+	// defaultImportMaxSize is the max number of recipes in a single import request if it's not configured.
+	// Recipes are created one after another, so an import has to finish within the Lambda timeout.
+	defaultImportMaxSize = 50
+)
+
+// importContentTypes contains all content types of documents recipes can be imported from.
+var importContentTypes = []string{jsonMediaType, ndjsonMediaType, csvMediaType, jsonLdMediaType, htmlMediaType}
+
+// importMediaTypeAliases maps alternative content types of import documents to a supported content type.
+var importMediaTypeAliases = map[string]string{
+	"application/ndjson":          ndjsonMediaType,
+	"application/jsonl":           ndjsonMediaType,
+	"application/x-jsonl":         ndjsonMediaType,
+	"application/csv":             csvMediaType,
+	"text/comma-separated-values": csvMediaType,
+}
+
+// parseRequest will decode the request body depending on it's content type into recipes which should be imported.
+func (handler *apiGatewayImportRequestHandler) parseRequest(request events.APIGatewayProxyRequest) error {
+
+	items, err := parseImportItems(mediaType(headerValue(request, "Content-Type")), request.Body)
+	if err != nil {
+		return err
+	}
+	if len(items) == 0 {
+		return newBadRequestError("Request body doesn't contain any recipes.", nil)
+	}
+	if len(items) > handler.maxSize {
+		return newBadRequestError(fmt.Sprintf("Too many recipes: %d, max: %d", len(items), handler.maxSize), nil)
+	}
+	handler.items = items
+	return nil
+}
+
+// handle POST requests to import recipes. Each recipe is validated and created on it's own, so a single invalid
+// recipe doesn't abort the entire import. It returns a report with the result for each imported recipe.
+func (handler *apiGatewayImportRequestHandler) handle() (*string, error) {
+
+	report := importReport{Items: []importResult{}}
+	for idx, item := range handler.items {
+		result := importRecipe(handler.recipeService, item)
+		result.Index = idx
+		result.Row = item.row
+		if result.Id != "" {
+			report.Created++
+		} else {
+			report.Failed++
+			handler.logger.Infof("Unable to import recipe %d, reason: %s", idx, result.Error)
+		}
+		report.Items = append(report.Items, result)
+	}
+	b, err := json.Marshal(report)
+	jsonStr := string(b)
+	return &jsonStr, err
+}
+
+// parseImportItems decodes passed document of given content type into values of all recipes it contains.
+func parseImportItems(contentType, document string) ([]importItem, error) {
+
+	if alias, ok := importMediaTypeAliases[contentType]; ok {
+		contentType = alias
+	}
+	switch contentType {
+	case jsonMediaType:
+		return parseJsonImportItems(document)
+	case ndjsonMediaType:
+		return parseNdjsonImportItems(document)
+	case csvMediaType:
+		return parseCsvImportItems(document)
+	case jsonLdMediaType:
+		return parseJsonLdImportItems(document)
+	case htmlMediaType:
+		return parseHtmlImportItems(document)
+	default:
+		return nil, &unsupportedMediaTypeError{contentType: contentType, supportedTypes: importContentTypes}
+	}
+}
+
+// parseJsonImportItems returns all recipes of passed JSON array. Each element of an array has the same format
+// as the request body to create a single recipe. A single JSON object is imported as one recipe.
+func parseJsonImportItems(document string) ([]importItem, error) {
+
+	var value interface{}
+	if err := decodeJson(document, &value); err != nil {
+		return nil, newBadRequestError("Request body is not a valid JSON document", err)
+	}
+	elements, ok := value.([]interface{})
+	if !ok {
+		elements = []interface{}{value}
+	}
+
+	items := []importItem{}
+	for _, element := range elements {
+		item := importItem{}
+		if rawValues, ok := element.(map[string]interface{}); ok {
+			item.values, item.err = normalizeRequestValues(rawValues)
+		} else {
+			item.err = newBadRequestError("Recipe is not a JSON object.", nil)
+		}
+		items = append(items, item)
+	}
+	return items, nil
+}
+
+// parseNdjsonImportItems returns all recipes of passed newline delimited JSON document, one recipe per line.
+// Empty lines are skipped. Lines which doesn't contain a valid JSON object are reported as failed recipes.
+func parseNdjsonImportItems(document string) ([]importItem, error) {
+
+	items := []importItem{}
+	for idx, line := range strings.Split(document, "\n") {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		item := importItem{row: idx + 1}
+		item.values, item.err = decodeRequestBody(line)
+		items = append(items, item)
+	}
+	return items, nil
+}
+
+// parseCsvImportItems returns all recipes of passed CSV document. First row is a header with recipe field names,
+// e.g. type, title, ingredients and description. Field names are case-insensitive and empty cells are skipped.
+// Values can be separated by comma or, if the header doesn't contain a comma, by semicolon.
+func parseCsvImportItems(document string) ([]importItem, error) {
+
+	document = strings.TrimPrefix(document, utf8ByteOrderMark)
+	reader := csv.NewReader(strings.NewReader(document))
+	reader.FieldsPerRecord = -1
+	header := strings.SplitN(document, "\n", 2)[0]
+	if !strings.Contains(header, ",") && strings.Contains(header, ";") {
+		reader.Comma = ';'
+	}
+
+	columns, err := reader.Read()
+	if err != nil {
+		return nil, newBadRequestError("Request body doesn't contain a CSV header", err)
+	}
+	fieldErrors := []fieldError{}
+	for idx, column := range columns {
+		columns[idx] = strings.ToLower(strings.TrimSpace(column))
+		if _, ok := newRecipeSchema.properties[columns[idx]]; !ok {
+			fieldErrors = append(fieldErrors, fieldError{Field: column, Message: "is not a recipe field"})
+		}
+	}
+	if len(fieldErrors) > 0 {
+		return nil, newFieldValidationError(fieldErrors)
+	}
+
+	items := []importItem{}
+	for row := 2; ; row++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, newBadRequestError("Request body is not a valid CSV document", err)
+		}
+		item := importItem{row: row, values: make(map[string]interface{})}
+		if len(record) != len(columns) {
+			item.err = newBadRequestError(fmt.Sprintf("Row has %d columns, but header has %d.", len(record), len(columns)), nil)
+		}
+		for idx, value := range record {
+			if idx < len(columns) && strings.TrimSpace(value) != "" {
+				item.values[columns[idx]] = value
+			}
+		}
+		items = append(items, item)
+	}
+	return items, nil
+}
+
+// parseJsonLdImportItems returns all schema.org Recipes of passed JSON-LD document.
+func parseJsonLdImportItems(document string) ([]importItem, error) {
+
+	var value interface{}
+	if err := decodeJson(document, &value); err != nil {
+		return nil, newBadRequestError("Request body is not a valid JSON-LD document", err)
+	}
+	items := []importItem{}
+	for _, node := range jsonLdRecipeNodes(value) {
+		items = append(items, importItem{values: recipeValuesFromJsonLd(node)})
+	}
+	return items, nil
+}
+
+// importRecipe validates and creates a single recipe and returns the result of it's import.
+func importRecipe(recipeService core.RecipeService, item importItem) importResult {
+
+	if title, ok := item.values["title"].(string); ok {
+		item.title = title
+	}
+	if item.err != nil {
+		return failedImportResult(item, item.err)
+	}
+	recipe, err := recipeFromRequestBody(item.values, newRecipeSchema)
+	if err != nil {
+		return failedImportResult(item, err)
+	}
+	newRecipe, err := createRecipe(recipeService, *recipe)
+	if err != nil {
+		return failedImportResult(item, err)
+	}
+	return importResult{Status: http.StatusCreated, Id: newRecipe.Id, Title: newRecipe.Title}
+}
+
+// failedImportResult returns the result of a recipe which can't be imported because of passed error.
+// Messages of internal errors are replaced by a generic message, like in problem details.
+func failedImportResult(item importItem, err error) importResult {
+
+	statusCode := statusCodeForError(err, http.StatusInternalServerError)
+	result := importResult{
+		Status: statusCode,
+		Title:  item.title,
+		Error:  errorDetail(err, statusCode),
+	}
+	if validationErr, ok := err.(*validationError); ok {
+		result.Errors = validationErr.fieldErrors
+	}
+	return result
+}
+
+// createRecipe creates passed recipe. It fails with a conflict if the recipe has an id which is already in use.
+func createRecipe(recipeService core.RecipeService, recipe model.Recipe) (model.Recipe, error) {
+
+	if recipe.Id != "" {
+		if _, err := recipeService.Get(recipe.Id); err == nil {
+			return recipe, newConflictError(fmt.Sprintf("Recipe %s already exists.", recipe.Id), nil)
+		}
+	}
+	return recipeService.Create(recipe)
+}
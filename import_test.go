@@ -0,0 +1,242 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/stretchr/testify/suite"
+	"github.com/tommzn/recipeboard-core/mock"
+	model "github.com/tommzn/recipeboard-core/model"
+)
+
+// Test suite for recipe imports.
+type ImportTestSuite struct {
+	suite.Suite
+	repo   *mock.RepositoryMock
+	router LambdaRequestHandler
+}
+
+func TestImportTestSuite(t *testing.T) {
+	suite.Run(t, new(ImportTestSuite))
+}
+
+// Setup test.
+func (suite *ImportTestSuite) SetupTest() {
+	suite.repo = repositoryForTest()
+	suite.router = routerForTest(suite.repo, publisherForTest(), loggerForTest())
+}
+
+// Test importing recipes from a JSON-LD document.
+func (suite *ImportTestSuite) TestImportJsonLd() {
+
+	existingRecipe := recipeForTest()
+	suite.repo.Recipes[existingRecipe.Id] = existingRecipe
+
+	document := `{"@context": "https://schema.org", "@graph": [
+		{"@type": "WebSite", "name": "Recipes"},
+		{"@type": "Recipe", "name": "Banana Bread", "recipeCategory": "Bread",
+			"recipeIngredient": ["3 bananas", "250g flour"], "recipeInstructions": "Mash bananas.\nBake."},
+		{"@type": "Recipe", "recipeIngredient": ["1 egg"]},
+		{"@type": "Recipe", "name": "Risotto", "recipeCategory": "Main course"}
+	]}`
+	response, err := suite.router.handle(context.Background(), importRequestForTest(jsonLdMediaType, document))
+	suite.Nil(err)
+	suite.Equal(http.StatusOK, response.StatusCode)
+	suite.Equal("application/json; charset=utf-8", response.Headers["Content-Type"])
+
+	report := suite.importReportFromResponse(response)
+	suite.Equal(2, report.Created)
+	suite.Equal(1, report.Failed)
+	suite.Len(report.Items, 3)
+
+	suite.Equal(0, report.Items[0].Index)
+	suite.Equal(http.StatusCreated, report.Items[0].Status)
+	suite.Equal("Banana Bread", report.Items[0].Title)
+	recipe, ok := suite.repo.Recipes[report.Items[0].Id]
+	suite.True(ok)
+	suite.Equal(model.BakingRecipe, recipe.Type)
+	suite.Equal("3 bananas\n250g flour", recipe.Ingredients)
+	suite.Equal("Mash bananas.\nBake.", recipe.Description)
+
+	suite.Equal(1, report.Items[1].Index)
+	suite.Equal(http.StatusUnprocessableEntity, report.Items[1].Status)
+	suite.Equal("", report.Items[1].Id)
+	suite.Equal([]fieldError{{Field: "title", Message: "is required"}}, report.Items[1].Errors)
+
+	suite.Equal(http.StatusCreated, report.Items[2].Status)
+	suite.Equal(model.CookingRecipe, suite.repo.Recipes[report.Items[2].Id].Type)
+	suite.Len(suite.repo.Recipes, 3)
+}
+
+// Test importing recipes from a JSON array.
+func (suite *ImportTestSuite) TestImportJsonArray() {
+
+	document := `[
+		{"type": "baking", "title": "Bake a Cake", "ingredients": "100g Mehl", "description": "Backen."},
+		{"Type": 0, "Title": "Cook Pasta"},
+		{"type": "frying", "title": ""},
+		"not a recipe",
+		{"title": "A", "TITLE": "B"}
+	]`
+	response, err := suite.router.handle(context.Background(), importRequestForTest("application/json", document))
+	suite.Nil(err)
+	suite.Equal(http.StatusOK, response.StatusCode)
+
+	report := suite.importReportFromResponse(response)
+	suite.Equal(2, report.Created)
+	suite.Equal(3, report.Failed)
+	suite.Equal([]int{http.StatusCreated, http.StatusCreated, http.StatusUnprocessableEntity, http.StatusBadRequest, http.StatusUnprocessableEntity}, importStatusCodes(report))
+	suite.Equal(model.CookingRecipe, suite.repo.Recipes[report.Items[1].Id].Type)
+	suite.Len(report.Items[2].Errors, 2)
+	suite.Len(suite.repo.Recipes, 2)
+
+	response, err = suite.router.handle(context.Background(), importRequestForTest("application/json", `{"type": "baking", "title": "Single"}`))
+	suite.Nil(err)
+	suite.Equal(1, suite.importReportFromResponse(response).Created)
+}
+
+// Test importing recipes from newline delimited JSON.
+func (suite *ImportTestSuite) TestImportNdjson() {
+
+	document := "{\"type\": \"baking\", \"title\": \"Bread\"}\n\n{\"type\": \"cooking\"\n{\"type\": \"cooking\", \"title\": \"Soup\"}\n"
+	response, err := suite.router.handle(context.Background(), importRequestForTest("application/x-ndjson", document))
+	suite.Nil(err)
+	suite.Equal(http.StatusOK, response.StatusCode)
+
+	report := suite.importReportFromResponse(response)
+	suite.Equal(2, report.Created)
+	suite.Equal(1, report.Failed)
+	suite.Equal([]int{http.StatusCreated, http.StatusBadRequest, http.StatusCreated}, importStatusCodes(report))
+	suite.Equal([]int{1, 3, 4}, []int{report.Items[0].Row, report.Items[1].Row, report.Items[2].Row})
+	suite.Equal(2, report.Items[2].Index)
+}
+
+// Test importing recipes from CSV.
+func (suite *ImportTestSuite) TestImportCsv() {
+
+	document := utf8ByteOrderMark + "Type,Title,Ingredients,Description\n" +
+		"baking,Bake a Cake,\"100g Mehl\n100g Zucker\",Backen.\n" +
+		"cooking,,Water,\n" +
+		"Cooking,\"Pasta, al dente\",,\n" +
+		"cooking,Too many,a,b,c\n"
+	response, err := suite.router.handle(context.Background(), importRequestForTest("text/csv", document))
+	suite.Nil(err)
+	suite.Equal(http.StatusOK, response.StatusCode)
+
+	report := suite.importReportFromResponse(response)
+	suite.Equal(2, report.Created)
+	suite.Equal(2, report.Failed)
+	suite.Equal([]int{http.StatusCreated, http.StatusUnprocessableEntity, http.StatusCreated, http.StatusBadRequest}, importStatusCodes(report))
+	suite.Equal([]int{2, 3, 4, 5}, []int{report.Items[0].Row, report.Items[1].Row, report.Items[2].Row, report.Items[3].Row})
+	suite.Equal([]fieldError{{Field: "title", Message: "is required"}}, report.Items[1].Errors)
+
+	recipe := suite.repo.Recipes[report.Items[0].Id]
+	suite.Equal(model.BakingRecipe, recipe.Type)
+	suite.Equal("100g Mehl\n100g Zucker", recipe.Ingredients)
+	suite.Equal("Pasta, al dente", suite.repo.Recipes[report.Items[2].Id].Title)
+}
+
+// Test CSV documents with semicolons and invalid CSV documents.
+func (suite *ImportTestSuite) TestParseCsvImportItems() {
+
+	items, err := parseCsvImportItems("type;title\r\nbaking;Cake\r\n")
+	suite.Nil(err)
+	suite.Len(items, 1)
+	suite.Equal(map[string]interface{}{"type": "baking", "title": "Cake"}, items[0].values)
+
+	_, err = parseCsvImportItems("type,title,servings\nbaking,Cake,4\n")
+	suite.IsType(&validationError{}, err)
+
+	_, err = parseCsvImportItems("")
+	suite.IsType(&badRequestError{}, err)
+
+	_, err = parseCsvImportItems("type,title\nbaking,\"Cake\n")
+	suite.IsType(&badRequestError{}, err)
+}
+
+// Test invalid import requests.
+func (suite *ImportTestSuite) TestInvalidImportRequests() {
+
+	response, err := suite.router.handle(context.Background(), importRequestForTest("application/xml", "<recipe/>"))
+	suite.Nil(err)
+	suite.Equal(http.StatusUnsupportedMediaType, response.StatusCode)
+
+	response, err = suite.router.handle(context.Background(), importRequestForTest(jsonLdMediaType, "{"))
+	suite.Nil(err)
+	suite.Equal(http.StatusBadRequest, response.StatusCode)
+
+	response, err = suite.router.handle(context.Background(), importRequestForTest(jsonLdMediaType, `{"@type": "Person"}`))
+	suite.Nil(err)
+	suite.Equal(http.StatusBadRequest, response.StatusCode)
+
+	lines := strings.Repeat(`{"type": "baking", "title": "Cake"}`+"\n", defaultImportMaxSize+1)
+	response, err = suite.router.handle(context.Background(), importRequestForTest(ndjsonMediaType, lines))
+	suite.Nil(err)
+	suite.Equal(http.StatusBadRequest, response.StatusCode)
+	suite.Len(suite.repo.Recipes, 0)
+
+	request := importRequestForTest(jsonLdMediaType, `{"@type": "Recipe"}`)
+	request.HTTPMethod = http.MethodGet
+	response, err = suite.router.handle(context.Background(), request)
+	suite.Nil(err)
+	suite.Equal(http.StatusMethodNotAllowed, response.StatusCode)
+	suite.Equal("POST", response.Headers["Allow"])
+}
+
+// Test creating recipes with ids which are already in use.
+func (suite *ImportTestSuite) TestCreateRecipeWithExistingId() {
+
+	recipe := recipeForTest()
+	service := recipeManagerForTest(suite.repo, publisherForTest(), loggerForTest())
+	_, err := createRecipe(service, recipe)
+	suite.Nil(err)
+
+	_, err = createRecipe(service, recipe)
+	suite.IsType(&conflictError{}, err)
+
+	result := importRecipe(service, importItem{values: map[string]interface{}{"id": recipe.Id, "type": "baking", "title": "Cake"}})
+	suite.Equal(http.StatusConflict, result.Status)
+	suite.Equal("Cake", result.Title)
+	suite.NotEmpty(result.Error)
+}
+
+// Test hiding messages of internal errors in import results.
+func (suite *ImportTestSuite) TestFailedImportResult() {
+
+	result := failedImportResult(importItem{title: "Cake"}, errors.New("DynamoDb: ProvisionedThroughputExceededException"))
+	suite.Equal(http.StatusInternalServerError, result.Status)
+	suite.Equal("Unable to process request.", result.Error)
+
+	result = failedImportResult(importItem{title: "Cake"}, newConflictError("Recipe 123 already exists.", nil))
+	suite.Equal(http.StatusConflict, result.Status)
+	suite.Equal("Recipe 123 already exists.", result.Error)
+}
+
+// importRequestForTest returns a request to import passed document of given content type.
+func importRequestForTest(contentType, document string) events.APIGatewayProxyRequest {
+	request := apiGatewayRequestWithPathForTest(http.MethodPost, "/recipes:import")
+	request.Headers = map[string]string{"Content-Type": contentType}
+	request.Body = document
+	return request
+}
+
+// importStatusCodes returns status codes of all items of passed import report.
+func importStatusCodes(report importReport) []int {
+	statusCodes := []int{}
+	for _, item := range report.Items {
+		statusCodes = append(statusCodes, item.Status)
+	}
+	return statusCodes
+}
+
+// importReportFromResponse decodes an import report from passed response.
+func (suite *ImportTestSuite) importReportFromResponse(response events.APIGatewayProxyResponse) importReport {
+	report := importReport{}
+	suite.Nil(json.Unmarshal([]byte(response.Body), &report))
+	return report
+}
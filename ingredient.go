@@ -0,0 +1,368 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ingredientsParsedField is the name of the response field which contains parsed ingredients.
+const ingredientsParsedField = "ingredientsParsed"
+
+// quantityPattern matches a quantity or a quantity range at the beginning of an ingredient line,
+// e.g. "2", "1.5", "1,5", "1/2", "2 1/2" or "2-3".
+var quantityPattern = regexp.MustCompile(`^(\d+\s+\d+/\d+|\d+/\d+|\d+(?:[.,]\d+)?)(?:\s*(?:-|to|bis)\s*(\d+\s+\d+/\d+|\d+/\d+|\d+(?:[.,]\d+)?))?`)
+
+// parenthesesPattern matches text in parentheses, which is used as note of an ingredient.
+var parenthesesPattern = regexp.MustCompile(`\s*\(([^)]*)\)`)
+
+// unicodeFractions replaces unicode vulgar fractions by a space and a plain fraction, e.g. "2½" by "2 1/2".
+var unicodeFractions = strings.NewReplacer(
+	"½", " 1/2", "⅓", " 1/3", "⅔", " 2/3", "¼", " 1/4", "¾", " 3/4",
+	"⅕", " 1/5", "⅖", " 2/5", "⅗", " 3/5", "⅘", " 4/5", "⅙", " 1/6", "⅚", " 5/6",
+	"⅛", " 1/8", "⅜", " 3/8", "⅝", " 5/8", "⅞", " 7/8", "⁄", "/", "–", "-", "—", "-",
+)
+
+// unitAliases maps all supported spellings of units to their canonical name. Aliases are lower case.
+var unitAliases = map[string]string{
+	"g": "g", "gr": "g", "gram": "g", "grams": "g", "gramm": "g",
+	"kg": "kg", "kilogram": "kg", "kilograms": "kg", "kilogramm": "kg",
+	"mg": "mg", "milligram": "mg", "milligrams": "mg",
+	"ml": "ml", "milliliter": "ml", "milliliters": "ml", "millilitre": "ml", "millilitres": "ml",
+	"cl": "cl", "dl": "dl",
+	"l": "l", "liter": "l", "liters": "l", "litre": "l", "litres": "l",
+	"tsp": "tsp", "teaspoon": "tsp", "teaspoons": "tsp", "tl": "tsp",
+	"tbsp": "tbsp", "tbs": "tbsp", "tablespoon": "tbsp", "tablespoons": "tbsp", "el": "tbsp",
+	"cup": "cup", "cups": "cup",
+	"fl oz": "fl oz", "fl. oz": "fl oz", "fluid ounce": "fl oz", "fluid ounces": "fl oz",
+	"oz": "oz", "ounce": "oz", "ounces": "oz",
+	"lb": "lb", "lbs": "lb", "pound": "lb", "pounds": "lb",
+	"pt": "pint", "pint": "pint", "pints": "pint",
+	"qt": "quart", "quart": "quart", "quarts": "quart",
+	"gal": "gallon", "gallon": "gallon", "gallons": "gallon",
+	"pinch": "pinch", "pinches": "pinch", "prise": "pinch",
+	"dash": "dash", "dashes": "dash",
+	"clove": "clove", "cloves": "clove",
+	"can": "can", "cans": "can",
+	"slice": "slice", "slices": "slice",
+	"piece": "piece", "pieces": "piece", "stück": "piece", "stk": "piece",
+	"bunch": "bunch", "bunches": "bunch", "bund": "bunch",
+	"stick": "stick", "sticks": "stick",
+	"package": "package", "packages": "package", "pkg": "package", "packet": "package", "packets": "package",
+}
+
+// unitPlurals contains plural names of canonical units, used for quantities greater than one.
+// Abbreviated units have no plural.
+var unitPlurals = map[string]string{
+	"cup": "cups", "pint": "pints", "quart": "quarts", "gallon": "gallons", "pinch": "pinches", "dash": "dashes",
+	"clove": "cloves", "can": "cans", "slice": "slices", "piece": "pieces", "bunch": "bunches",
+	"stick": "sticks", "package": "packages",
+}
+
+// metricUnits contains all canonical metric units. Their quantities are rendered as decimals instead of fractions.
+var metricUnits = map[string]bool{"g": true, "kg": true, "mg": true, "ml": true, "cl": true, "dl": true, "l": true}
+
+// quantityFractions are used to render quantities as fractions, if they're close enough.
+var quantityFractions = []struct {
+	value float64
+	text  string
+}{
+	{1.0 / 8, "1/8"}, {1.0 / 4, "1/4"}, {1.0 / 3, "1/3"}, {3.0 / 8, "3/8"}, {1.0 / 2, "1/2"},
+	{5.0 / 8, "5/8"}, {2.0 / 3, "2/3"}, {3.0 / 4, "3/4"}, {7.0 / 8, "7/8"},
+}
+
+// parseIngredients parses all lines of passed ingredients text. Empty lines are skipped.
+func parseIngredients(text string) []ingredient {
+
+	ingredients := []ingredient{}
+	for _, line := range recipeLines(text) {
+		ingredients = append(ingredients, parseIngredient(line))
+	}
+	return ingredients
+}
+
+// parseIngredient splits a single ingredient line into quantity, unit, name and note,
+// e.g. "2 1/2 cups flour, sifted" into 2.5, cup, flour and sifted. Quantities can be decimals, fractions,
+// mixed numbers or ranges like "2-3". Text after the first comma and text in parentheses is used as note.
+// Lines without a leading quantity, e.g. "Salt to taste", only have a name and maybe a note.
+func parseIngredient(line string) ingredient {
+
+	parsed := ingredient{Text: strings.TrimSpace(line)}
+	rest := strings.TrimSpace(unicodeFractions.Replace(parsed.Text))
+
+	if match := quantityPattern.FindStringSubmatch(rest); match != nil {
+		quantity, _ := parseQuantity(match[1])
+		parsed.Quantity = &quantity
+		if match[2] != "" {
+			quantityMax, _ := parseQuantity(match[2])
+			parsed.QuantityMax = &quantityMax
+		}
+		rest = strings.TrimSpace(rest[len(match[0]):])
+		parsed.Unit, rest = parseUnit(rest)
+		if strings.HasPrefix(strings.ToLower(rest), "of ") {
+			rest = strings.TrimSpace(rest[3:])
+		}
+	}
+
+	notes := []string{}
+	for _, match := range parenthesesPattern.FindAllStringSubmatch(rest, -1) {
+		if note := strings.TrimSpace(match[1]); note != "" {
+			notes = append(notes, note)
+		}
+	}
+	rest = strings.TrimSpace(parenthesesPattern.ReplaceAllString(rest, ""))
+	if idx := strings.Index(rest, ","); idx >= 0 {
+		if note := strings.TrimSpace(rest[idx+1:]); note != "" {
+			notes = append([]string{note}, notes...)
+		}
+		rest = strings.TrimSpace(rest[:idx])
+	}
+	parsed.Name = rest
+	parsed.Note = strings.Join(notes, "; ")
+	return parsed
+}
+
+// parseQuantity converts a decimal, fraction or mixed number to a float. Comma can be used as decimal separator.
+func parseQuantity(text string) (float64, error) {
+
+	quantity := 0.0
+	for _, part := range strings.Fields(text) {
+		if fraction := strings.Split(part, "/"); len(fraction) == 2 {
+			numerator, err := strconv.ParseFloat(fraction[0], 64)
+			if err != nil {
+				return 0, err
+			}
+			denominator, err := strconv.ParseFloat(fraction[1], 64)
+			if err != nil || denominator == 0 {
+				return 0, fmt.Errorf("Invalid fraction: %s", part)
+			}
+			quantity += numerator / denominator
+			continue
+		}
+		value, err := strconv.ParseFloat(strings.Replace(part, ",", ".", 1), 64)
+		if err != nil {
+			return 0, err
+		}
+		quantity += value
+	}
+	return quantity, nil
+}
+
+// parseUnit returns the canonical unit at the beginning of passed text and the remaining text.
+// Two word units, e.g. "fl oz", are preferred. Unit is empty if text doesn't start with a known unit.
+func parseUnit(text string) (string, string) {
+
+	words := strings.Fields(text)
+	for count := 2; count >= 1; count-- {
+		if len(words) < count {
+			continue
+		}
+		candidate := strings.TrimSuffix(strings.ToLower(strings.Join(words[:count], " ")), ".")
+		if unit, ok := unitAliases[candidate]; ok {
+			return unit, strings.Join(words[count:], " ")
+		}
+	}
+	return "", text
+}
+
+// formatIngredient returns passed ingredient as a single line, e.g. "2 1/2 cups flour, sifted".
+// The original text is used for ingredients without a name.
+func formatIngredient(ingredient ingredient) string {
+
+	if ingredient.Name == "" {
+		return ingredient.Text
+	}
+	parts := []string{}
+	if ingredient.Quantity != nil {
+		quantity := formatQuantityForUnit(*ingredient.Quantity, ingredient.Unit)
+		if ingredient.QuantityMax != nil {
+			quantity += "-" + formatQuantityForUnit(*ingredient.QuantityMax, ingredient.Unit)
+		}
+		parts = append(parts, quantity)
+	}
+	if ingredient.Unit != "" {
+		parts = append(parts, formatUnit(ingredient))
+	}
+	parts = append(parts, ingredient.Name)
+	line := strings.Join(parts, " ")
+	if ingredient.Note != "" {
+		line += ", " + ingredient.Note
+	}
+	return line
+}
+
+// formatUnit returns the unit of passed ingredient, in plural if it's quantity is greater than one.
+func formatUnit(ingredient ingredient) string {
+
+	quantity := 1.0
+	if ingredient.Quantity != nil {
+		quantity = *ingredient.Quantity
+	}
+	if ingredient.QuantityMax != nil {
+		quantity = *ingredient.QuantityMax
+	}
+	if plural, ok := unitPlurals[ingredient.Unit]; ok && quantity > 1 {
+		return plural
+	}
+	return ingredient.Unit
+}
+
+// formatQuantityForUnit renders passed quantity as decimal for metric units and with fractions for all other units.
+func formatQuantityForUnit(quantity float64, unit string) string {
+	if metricUnits[unit] {
+		return strconv.FormatFloat(math.Round(quantity*100)/100, 'f', -1, 64)
+	}
+	return formatQuantity(quantity)
+}
+
+// formatQuantity renders passed quantity as integer, mixed number with a common fraction, e.g. "2 1/2",
+// or as decimal with at most two decimal places.
+func formatQuantity(quantity float64) string {
+
+	whole := math.Floor(quantity)
+	remainder := quantity - whole
+	if remainder < 0.01 {
+		return strconv.FormatFloat(whole, 'f', -1, 64)
+	}
+	if remainder > 0.99 {
+		return strconv.FormatFloat(whole+1, 'f', -1, 64)
+	}
+	for _, fraction := range quantityFractions {
+		if math.Abs(remainder-fraction.value) < 0.01 {
+			if whole == 0 {
+				return fraction.text
+			}
+			return strconv.FormatFloat(whole, 'f', -1, 64) + " " + fraction.text
+		}
+	}
+	return strconv.FormatFloat(math.Round(quantity*100)/100, 'f', -1, 64)
+}
+
+// normalizeIngredients converts structured ingredients in passed request body values to the ingredients text.
+// Ingredients can be passed as list of strings or objects with quantity, quantityMax, unit, name and note.
+// Parsed ingredients returned in responses are ignored, so a response can be used as request body.
+func normalizeIngredients(values map[string]interface{}) error {
+
+	for name := range values {
+		if strings.EqualFold(name, ingredientsParsedField) {
+			delete(values, name)
+		}
+	}
+
+	items, ok := values["ingredients"].([]interface{})
+	if !ok {
+		return nil
+	}
+	lines := []string{}
+	fieldErrors := []fieldError{}
+	for idx, item := range items {
+		field := fmt.Sprintf("ingredients[%d]", idx)
+		switch value := item.(type) {
+		case string:
+			if line := strings.TrimSpace(value); line != "" {
+				lines = append(lines, line)
+			}
+		case map[string]interface{}:
+			ingredient, errs := ingredientFromValues(value, field)
+			if len(errs) > 0 {
+				fieldErrors = append(fieldErrors, errs...)
+				continue
+			}
+			lines = append(lines, formatIngredient(ingredient))
+		default:
+			fieldErrors = append(fieldErrors, fieldError{Field: field, Message: "must be a string or an object"})
+		}
+	}
+	if len(fieldErrors) > 0 {
+		return newFieldValidationError(fieldErrors)
+	}
+	values["ingredients"] = strings.Join(lines, "\n")
+	return nil
+}
+
+// ingredientFromValues converts JSON values of a structured ingredient. Name is required, quantities can be
+// passed as numbers or as text, e.g. "2 1/2". Read-only values of parsed ingredients are ignored, all other
+// unknown values are rejected. Passed field is used as prefix for validation errors.
+func ingredientFromValues(values map[string]interface{}, field string) (ingredient, []fieldError) {
+
+	keys := []string{}
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	parsed := ingredient{}
+	fieldErrors := []fieldError{}
+	for _, key := range keys {
+		value := values[key]
+		switch strings.ToLower(key) {
+		case "quantity":
+			var err error
+			if parsed.Quantity, err = quantityFromValue(value); err != nil {
+				fieldErrors = append(fieldErrors, fieldError{Field: field + "." + key, Message: "must be a positive number"})
+			}
+		case "quantitymax":
+			var err error
+			if parsed.QuantityMax, err = quantityFromValue(value); err != nil {
+				fieldErrors = append(fieldErrors, fieldError{Field: field + "." + key, Message: "must be a positive number"})
+			}
+		case "unit", "name", "note":
+			text, ok := value.(string)
+			if !ok && value != nil {
+				fieldErrors = append(fieldErrors, fieldError{Field: field + "." + key, Message: "must be a string"})
+				continue
+			}
+			setIngredientText(&parsed, strings.ToLower(key), strings.TrimSpace(text))
+		case "text", "unscaled":
+		default:
+			fieldErrors = append(fieldErrors, fieldError{Field: field + "." + key, Message: "is not supported"})
+		}
+	}
+	if parsed.Name == "" {
+		fieldErrors = append(fieldErrors, fieldError{Field: field + ".name", Message: "is required"})
+	}
+	return parsed, fieldErrors
+}
+
+// setIngredientText assigns passed text to the unit, name or note of given ingredient. Units are converted to their canonical name.
+func setIngredientText(parsed *ingredient, key, text string) {
+
+	switch key {
+	case "unit":
+		parsed.Unit = text
+		if canonicalUnit, ok := unitAliases[strings.ToLower(text)]; ok {
+			parsed.Unit = canonicalUnit
+		}
+	case "name":
+		parsed.Name = text
+	case "note":
+		parsed.Note = text
+	}
+}
+
+// quantityFromValue converts a JSON number or a quantity text to a positive quantity.
+func quantityFromValue(value interface{}) (*float64, error) {
+
+	var quantity float64
+	var err error
+	switch number := value.(type) {
+	case nil:
+		return nil, nil
+	case json.Number:
+		quantity, err = number.Float64()
+	case float64:
+		quantity = number
+	case string:
+		quantity, err = parseQuantity(unicodeFractions.Replace(number))
+	default:
+		err = fmt.Errorf("Invalid quantity: %v", value)
+	}
+	if err != nil || quantity <= 0 {
+		return nil, fmt.Errorf("Invalid quantity: %v", value)
+	}
+	return &quantity, nil
+}
@@ -0,0 +1,130 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+)
+
+// Test suite for ingredient parsing.
+type IngredientTestSuite struct {
+	suite.Suite
+}
+
+func TestIngredientTestSuite(t *testing.T) {
+	suite.Run(t, new(IngredientTestSuite))
+}
+
+// Test parsing single ingredient lines.
+func (suite *IngredientTestSuite) TestParseIngredient() {
+
+	suite.assertIngredient("2 1/2 cups flour, sifted", 2.5, nil, "cup", "flour", "sifted")
+	suite.assertIngredient("100g Mehl", 100, nil, "g", "Mehl", "")
+	suite.assertIngredient("1,5 l Milch", 1.5, nil, "l", "Milch", "")
+	suite.assertIngredient("½ tsp. salt", 0.5, nil, "tsp", "salt", "")
+	suite.assertIngredient("2½ Tablespoons of butter (soft)", 2.5, nil, "tbsp", "butter", "soft")
+	suite.assertIngredient("8 fl oz cream", 8, nil, "fl oz", "cream", "")
+	suite.assertIngredient("2 large eggs", 2, nil, "", "large eggs", "")
+	suite.assertIngredient("3 EL Öl", 3, nil, "tbsp", "Öl", "")
+
+	max := 3.0
+	suite.assertIngredient("2-3 cloves garlic, minced", 2, &max, "clove", "garlic", "minced")
+	suite.assertIngredient("2 to 3 cloves garlic", 2, &max, "clove", "garlic", "")
+	suite.assertIngredient("2 – 3 cloves garlic", 2, &max, "clove", "garlic", "")
+
+	salt := parseIngredient("Salt, to taste")
+	suite.Nil(salt.Quantity)
+	suite.Equal("", salt.Unit)
+	suite.Equal("Salt", salt.Name)
+	suite.Equal("to taste", salt.Note)
+	suite.Equal("Salt, to taste", salt.Text)
+
+	suite.Len(parseIngredients("100g Mehl\n\n  \n50ml Wasser"), 2)
+}
+
+// Test rendering quantities and ingredients.
+func (suite *IngredientTestSuite) TestFormatIngredient() {
+
+	suite.Equal("2", formatQuantity(2))
+	suite.Equal("1/2", formatQuantity(0.5))
+	suite.Equal("2 1/3", formatQuantity(2.3333))
+	suite.Equal("1", formatQuantity(0.999))
+	suite.Equal("1.15", formatQuantity(1.15))
+
+	for _, line := range []string{"2 1/2 cups flour, sifted", "100 g Mehl", "2-3 cloves garlic, minced", "1 cup milk", "Salt"} {
+		suite.Equal(line, formatIngredient(parseIngredient(line)))
+	}
+}
+
+// Test converting structured ingredients in request bodies.
+func (suite *IngredientTestSuite) TestNormalizeIngredients() {
+
+	values, err := decodeRequestBody(`{"ingredients": [{"quantity": "2 1/2", "unit": "cups", "name": "flour", "note": "sifted"}, "1 egg", {"quantity": 100, "unit": "g", "name": "sugar"}], "ingredientsParsed": []}`)
+	suite.Nil(err)
+	suite.Nil(normalizeIngredients(values))
+	suite.Equal("2 1/2 cups flour, sifted\n1 egg\n100 g sugar", values["ingredients"])
+	_, ok := values["ingredientsparsed"]
+	suite.False(ok)
+
+	values, err = decodeRequestBody(`{"ingredients": [{"quantity": -1, "unit": "g"}, 5]}`)
+	suite.Nil(err)
+	err = normalizeIngredients(values)
+	suite.IsType(&validationError{}, err)
+	suite.Len(err.(*validationError).fieldErrors, 3)
+
+	values, err = decodeRequestBody(`{"ingredients": [{"name": "flour", "text": "flour", "unscaled": true, "amount": 2, "unit": 5}]}`)
+	suite.Nil(err)
+	err = normalizeIngredients(values)
+	suite.IsType(&validationError{}, err)
+	suite.Equal([]fieldError{
+		{Field: "ingredients[0].amount", Message: "is not supported"},
+		{Field: "ingredients[0].unit", Message: "must be a string"},
+	}, err.(*validationError).fieldErrors)
+
+	values, err = decodeRequestBody(`{"ingredients": "100g Mehl"}`)
+	suite.Nil(err)
+	suite.Nil(normalizeIngredients(values))
+	suite.Equal("100g Mehl", values["ingredients"])
+}
+
+// Test creating a recipe with structured ingredients, which are returned as text and parsed.
+func (suite *IngredientTestSuite) TestCreateRecipeWithStructuredIngredients() {
+
+	router := mockedRouterForTest(loggerForTest())
+	body := `{"type": "baking", "title": "Bread", "ingredients": [{"quantity": 500, "unit": "g", "name": "flour"}, "1 tsp salt"]}`
+	response, err := router.handle(context.Background(), apiGatewayRequestForTest(http.MethodPost, &body, nil))
+	suite.Nil(err)
+	suite.Equal(http.StatusOK, response.StatusCode)
+
+	values := struct {
+		Ingredients       string
+		IngredientsParsed []ingredient `json:"ingredientsParsed"`
+	}{}
+	suite.Nil(json.Unmarshal([]byte(response.Body), &values))
+	suite.Equal("500 g flour\n1 tsp salt", values.Ingredients)
+	suite.Len(values.IngredientsParsed, 2)
+	suite.Equal("salt", values.IngredientsParsed[1].Name)
+	suite.Equal("tsp", values.IngredientsParsed[1].Unit)
+}
+
+// assertIngredient parses passed line and compares it with expected values.
+func (suite *IngredientTestSuite) assertIngredient(line string, quantity float64, quantityMax *float64, unit, name, note string) {
+
+	parsed := parseIngredient(line)
+	suite.NotNil(parsed.Quantity, line)
+	if parsed.Quantity != nil {
+		suite.InDelta(quantity, *parsed.Quantity, 0.001, line)
+	}
+	if quantityMax == nil {
+		suite.Nil(parsed.QuantityMax, line)
+	} else if suite.NotNil(parsed.QuantityMax, line) {
+		suite.InDelta(*quantityMax, *parsed.QuantityMax, 0.001, line)
+	}
+	suite.Equal(unit, parsed.Unit, line)
+	suite.Equal(name, parsed.Name, line)
+	suite.Equal(note, parsed.Note, line)
+	suite.Equal(line, parsed.Text)
+}
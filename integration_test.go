@@ -91,7 +91,7 @@ func (suite *IntegrationTestSuite) TestCrudActions() {
 
 	request4_1 := apiGatewayRequestWithQueryParamForTest(http.MethodGet, "recipetype", "cooking")
 	response4_1, err4_1 := suite.handler.handle(context.Background(), request4_1)
-	suite.NotNil(err4_1)
+	suite.Nil(err4_1)
 	suite.True(response4_1.StatusCode >= 400)
 
 	responseRecipes4_1, err4_1 := getRecipeListFromResponse(response4_1)
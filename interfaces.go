@@ -2,8 +2,11 @@ package main
 
 import (
 	"context"
+	"time"
 
 	"github.com/aws/aws-lambda-go/events"
+	awsdynamodb "github.com/aws/aws-sdk-go/service/dynamodb"
+	model "github.com/tommzn/recipeboard-core/model"
 )
 
 // LambdaRequestHandler process requests send from API Gateway.
@@ -23,9 +26,137 @@ type apiGatewayRequestHandler interface {
 	handle() (*string, error)
 }
 
+// responseHeaderProvider is implemented by request handlers which add headers to successful responses.
+type responseHeaderProvider interface {
+
+	// responseHeaders returns all headers which should be added to a response.
+	responseHeaders() map[string]string
+}
+
+// contentNegotiator is implemented by request handlers which can render a response in different media types.
+type contentNegotiator interface {
+
+	// mediaTypes returns all media types a response can be rendered in. The first one is used by default.
+	mediaTypes() []string
+
+	// useMediaType defines the media type a response should be rendered in.
+	useMediaType(mediaType string)
+}
+
+// binaryResponseProvider is implemented by request handlers which can return binary response bodies.
+type binaryResponseProvider interface {
+
+	// isBase64Encoded returns true if a response body contains base64 encoded binary data.
+	isBase64Encoded() bool
+}
+
 // handlerFactory is an interface for factories which creates handlers for APT Gateway requests.
 type handlerFactory interface {
 
 	// handlerForRequest will create a handler for passed request.
 	handlerForRequest(request events.APIGatewayProxyRequest) (apiGatewayRequestHandler, error)
 }
+
+// httpStatusError is an error which defines the HTTP status code a request should be responded with.
+type httpStatusError interface {
+	error
+
+	// statusCode returns the HTTP status code for an error.
+	statusCode() int
+}
+
+// documentStore persists JSON documents, grouped by collections.
+type documentStore interface {
+
+	// get will load the document identified by passed collection and id into given value.
+	// It returns a not found error if there's no such document.
+	get(collection, id string, value interface{}) error
+
+	// set will create or replace a document.
+	set(collection, id string, value interface{}) error
+
+	// create will add a new document. It returns a conflict error if there's already a document with passed id,
+	// also if it has been written concurrently by another process.
+	create(collection, id string, value interface{}) error
+
+	// delete will remove a document. Deleting a not existing document will not fail.
+	delete(collection, id string) error
+
+	// list returns all documents of a collection, mapped by their id.
+	list(collection string) (map[string][]byte, error)
+}
+
+// dynamoDbItemWriter writes items to DynamoDb, optionally with a condition.
+type dynamoDbItemWriter interface {
+
+	// PutItem creates or replaces an item if the condition of passed input is met.
+	PutItem(*awsdynamodb.PutItemInput) (*awsdynamodb.PutItemOutput, error)
+}
+
+// searchIndex is used to search for recipes by words in their title, ingredients or description.
+type searchIndex interface {
+
+	// index adds passed recipe to the index or replaces it if it's already indexed.
+	index(model.Recipe) error
+
+	// remove deletes the recipe with passed id from the index.
+	remove(string) error
+
+	// search returns ids of recipes which matches passed query, ordered by their relevance.
+	// Number of results is limited by passed max value.
+	search(string, int) ([]searchHit, error)
+
+	// version returns the version the index has been built with, or zero if it has never been built.
+	version() (int, error)
+
+	// setVersion persists the version the index has been built with.
+	setVersion(int) error
+}
+
+// recipeTrash keeps deleted recipes until they're restored or purged.
+type recipeTrash interface {
+
+	// add moves passed recipe to the trash, deleted at given time.
+	add(model.Recipe, time.Time) error
+
+	// get returns the trashed recipe with passed id. It returns a not found error if there's no such recipe in the trash.
+	get(string) (*trashedRecipe, error)
+
+	// remove deletes the recipe with passed id from the trash.
+	remove(string) error
+
+	// list returns all trashed recipes, most recently deleted first.
+	list() ([]trashedRecipe, error)
+}
+
+// revisionLog is an append-only log of recipe revisions.
+type revisionLog interface {
+
+	// append adds passed revision to the revisions of it's recipe and assigns the next revision number.
+	append(recipeRevision) error
+
+	// list returns all revisions of the recipe with passed id, oldest first. It's empty if there're no revisions.
+	list(string) ([]recipeRevision, error)
+
+	// get returns a single revision of the recipe with passed id. It returns a not found error if there's no such revision.
+	get(string, int) (*recipeRevision, error)
+}
+
+// mealPlanStore persists weekly meal plans.
+type mealPlanStore interface {
+
+	// get returns the meal plan for passed week. It returns a not found error if there's no meal plan for this week.
+	get(string) (*mealPlan, error)
+
+	// set creates or replaces the meal plan for it's week.
+	set(mealPlan) error
+
+	// create adds a meal plan. It returns a conflict error if there's already a meal plan for it's week.
+	create(mealPlan) error
+
+	// delete removes the meal plan for passed week.
+	delete(string) error
+
+	// list returns all meal plans, most recent week first.
+	list() ([]mealPlan, error)
+}
@@ -0,0 +1,199 @@
+package main
+
+import (
+	"encoding/json"
+	"html"
+	"strings"
+	"time"
+
+	model "github.com/tommzn/recipeboard-core/model"
+)
+
+const (
+
+	// schemaOrgContext is the JSON-LD context of schema.org documents.
+	schemaOrgContext = "https://schema.org"
+
+	// schemaOrgRecipeType is the schema.org type of recipes.
+	schemaOrgRecipeType = "Recipe"
+
+	// schemaOrgHowToStepType is the schema.org type of a single instruction step.
+	schemaOrgHowToStepType = "HowToStep"
+)
+
+// bakingCategoryKeywords are used to identify baking recipes by their categories on import.
+// All other recipes are imported as cooking recipes.
+var bakingCategoryKeywords = []string{
+	"bak", "bread", "brot", "brownie", "cake", "cookie", "cupcake", "gebäck", "keks", "kuchen",
+	"muffin", "pastry", "pie", "plätzchen", "scone", "tart", "torte",
+}
+
+// toSchemaOrgRecipe converts passed recipe to a schema.org Recipe. Ingredients are split into single lines
+// and each line of the description becomes a step of recipe instructions.
+func toSchemaOrgRecipe(recipe model.Recipe) schemaOrgRecipe {
+
+	instructions := []schemaOrgHowToStep{}
+	for _, step := range recipeSteps(recipe.Description) {
+		instructions = append(instructions, schemaOrgHowToStep{Type: schemaOrgHowToStepType, Text: step})
+	}
+	schemaRecipe := schemaOrgRecipe{
+		Context:            schemaOrgContext,
+		Type:               schemaOrgRecipeType,
+		Identifier:         recipe.Id,
+		Name:               recipe.Title,
+		RecipeCategory:     recipeTypeLabel(recipe.Type),
+		RecipeIngredient:   recipeLines(recipe.Ingredients),
+		RecipeInstructions: instructions,
+	}
+	if !recipe.CreatedAt.IsZero() {
+		schemaRecipe.DateCreated = recipe.CreatedAt.Format(time.RFC3339)
+	}
+	return schemaRecipe
+}
+
+// renderRecipeJsonLd returns passed recipe as schema.org Recipe JSON-LD document.
+func renderRecipeJsonLd(recipe model.Recipe) (*string, error) {
+
+	document, err := json.Marshal(toSchemaOrgRecipe(recipe))
+	if err != nil {
+		return nil, err
+	}
+	body := string(document)
+	return &body, nil
+}
+
+// jsonLdRecipeNodes returns all nodes of type Recipe from passed decoded JSON-LD document.
+// Recipes are searched in arrays, @graph and all nested objects, e.g. mainEntity of a web page.
+func jsonLdRecipeNodes(document interface{}) []map[string]interface{} {
+
+	nodes := []map[string]interface{}{}
+	switch value := document.(type) {
+	case []interface{}:
+		for _, element := range value {
+			nodes = append(nodes, jsonLdRecipeNodes(element)...)
+		}
+	case map[string]interface{}:
+		if isJsonLdRecipe(value) {
+			return append(nodes, value)
+		}
+		for _, element := range value {
+			nodes = append(nodes, jsonLdRecipeNodes(element)...)
+		}
+	}
+	return nodes
+}
+
+// isJsonLdRecipe returns true if @type of passed node is a schema.org Recipe.
+func isJsonLdRecipe(node map[string]interface{}) bool {
+
+	for _, nodeType := range jsonLdStrings(node["@type"]) {
+		nodeType = nodeType[strings.LastIndexAny(nodeType, "/:")+1:]
+		if nodeType == schemaOrgRecipeType {
+			return true
+		}
+	}
+	return false
+}
+
+// recipeValuesFromJsonLd maps passed schema.org Recipe to request body values of a new recipe,
+// so it can be validated the same way as recipes created by a POST request.
+// Instructions are used as recipe description, or the schema.org description if there're no instructions.
+func recipeValuesFromJsonLd(node map[string]interface{}) map[string]interface{} {
+
+	values := map[string]interface{}{
+		"type": fromRecipeType(recipeTypeFromCategories(jsonLdStrings(node["recipeCategory"]))),
+	}
+	if names := jsonLdStrings(node["name"]); len(names) > 0 {
+		values["title"] = names[0]
+	}
+
+	ingredientsValue, ok := node["recipeIngredient"]
+	if !ok {
+		ingredientsValue = node["ingredients"]
+	}
+	if ingredients := jsonLdStrings(ingredientsValue); len(ingredients) > 0 {
+		values["ingredients"] = strings.Join(ingredients, "\n")
+	}
+
+	steps := jsonLdInstructions(node["recipeInstructions"])
+	if len(steps) == 0 {
+		steps = jsonLdStrings(node["description"])
+	}
+	if len(steps) > 0 {
+		values["description"] = strings.Join(steps, "\n")
+	}
+	return values
+}
+
+// jsonLdInstructions returns all steps of passed recipe instructions. Instructions can be a text,
+// a list of texts, HowToStep objects or HowToSection objects which contain steps in itemListElement.
+func jsonLdInstructions(instructions interface{}) []string {
+
+	steps := []string{}
+	switch value := instructions.(type) {
+	case string:
+		steps = append(steps, recipeLines(cleanJsonLdText(value))...)
+	case []interface{}:
+		for _, element := range value {
+			steps = append(steps, jsonLdInstructions(element)...)
+		}
+	case map[string]interface{}:
+		if elements, ok := value["itemListElement"]; ok {
+			return jsonLdInstructions(elements)
+		}
+		texts := jsonLdStrings(value["text"])
+		if len(texts) == 0 {
+			texts = jsonLdStrings(value["name"])
+		}
+		steps = append(steps, texts...)
+	}
+	return steps
+}
+
+// jsonLdStrings returns all texts of passed JSON-LD value, which can be a text, a list of texts
+// or value objects with a @value. HTML entities are decoded and all texts are trimmed.
+func jsonLdStrings(value interface{}) []string {
+
+	texts := []string{}
+	switch element := value.(type) {
+	case string:
+		if text := cleanJsonLdText(element); text != "" {
+			texts = append(texts, text)
+		}
+	case json.Number:
+		texts = append(texts, element.String())
+	case []interface{}:
+		for _, item := range element {
+			texts = append(texts, jsonLdStrings(item)...)
+		}
+	case map[string]interface{}:
+		texts = append(texts, jsonLdStrings(element["@value"])...)
+	}
+	return texts
+}
+
+// cleanJsonLdText decodes HTML entities in passed text and removes leading and trailing whitespaces.
+func cleanJsonLdText(text string) string {
+	return strings.TrimSpace(html.UnescapeString(text))
+}
+
+// recipeTypeFromCategories maps schema.org recipe categories to a recipe type. Categories which are
+// equal to a recipe type are used directly, otherwise a recipe is a baking recipe if one of it's
+// categories contains a baking keyword. Cooking is used by default.
+func recipeTypeFromCategories(categories []string) model.RecipeType {
+
+	for _, category := range categories {
+		if recipeType, err := toRecipeType(category); err == nil {
+			return *recipeType
+		}
+	}
+	for _, category := range categories {
+		category = strings.ToLower(category)
+		for _, keyword := range bakingCategoryKeywords {
+			if strings.Contains(category, keyword) {
+				return model.BakingRecipe
+			}
+		}
+	}
+	return model.CookingRecipe
+}
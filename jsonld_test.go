@@ -0,0 +1,123 @@
+package main
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+	model "github.com/tommzn/recipeboard-core/model"
+)
+
+// Test suite for schema.org JSON-LD export and import.
+type JsonLdTestSuite struct {
+	suite.Suite
+}
+
+func TestJsonLdTestSuite(t *testing.T) {
+	suite.Run(t, new(JsonLdTestSuite))
+}
+
+// Test exporting a recipe as schema.org Recipe.
+func (suite *JsonLdTestSuite) TestRenderRecipeJsonLd() {
+
+	recipe := recipeForTest()
+	body, err := renderRecipe(recipe, []string{"title"}, jsonLdMediaType)
+	suite.Nil(err)
+
+	document := make(map[string]interface{})
+	suite.Nil(json.Unmarshal([]byte(*body), &document))
+	suite.Equal("https://schema.org", document["@context"])
+	suite.Equal("Recipe", document["@type"])
+	suite.Equal(recipe.Id, document["identifier"])
+	suite.Equal("Bake a Cake", document["name"])
+	suite.Equal("Baking", document["recipeCategory"])
+	suite.Equal([]interface{}{"100g Mehl", "100g Zucker", "50ml Wasser"}, document["recipeIngredient"])
+	suite.Len(document["recipeInstructions"], 3)
+	suite.Equal(map[string]interface{}{"@type": "HowToStep", "text": "Einrühren."}, document["recipeInstructions"].([]interface{})[0])
+	suite.NotEmpty(document["dateCreated"])
+
+	recipe = model.Recipe{Title: "Empty"}
+	body, err = renderRecipeJsonLd(recipe)
+	suite.Nil(err)
+	suite.Equal(`{"@context":"https://schema.org","@type":"Recipe","name":"Empty","recipeCategory":"Cooking","recipeIngredient":[],"recipeInstructions":[]}`, *body)
+}
+
+// Test exported recipes can be imported again.
+func (suite *JsonLdTestSuite) TestExportAndImport() {
+
+	recipe := recipeForTest()
+	body, err := renderRecipeJsonLd(recipe)
+	suite.Nil(err)
+
+	items, err := parseJsonLdImportItems(*body)
+	suite.Nil(err)
+	suite.Len(items, 1)
+	imported, err := recipeFromRequestBody(items[0].values, newRecipeSchema)
+	suite.Nil(err)
+	suite.Equal(recipe.Type, imported.Type)
+	suite.Equal(recipe.Title, imported.Title)
+	suite.Equal(recipe.Ingredients, imported.Ingredients)
+	suite.Equal(recipe.Description, imported.Description)
+	suite.Equal("", imported.Id)
+}
+
+// Test finding recipes in JSON-LD documents.
+func (suite *JsonLdTestSuite) TestJsonLdRecipeNodes() {
+
+	documents := map[string]int{
+		`{"@type": "Recipe", "name": "A"}`: 1,
+		`[{"@type": "Recipe", "name": "A"}, {"@type": "Person"}, {"@type": ["Thing", "Recipe"]}]`:          2,
+		`{"@context": "https://schema.org", "@graph": [{"@type": "WebPage"}, {"@type": "schema:Recipe"}]}`: 1,
+		`{"@type": "WebPage", "mainEntity": {"@type": "http://schema.org/Recipe", "name": "A"}}`:           1,
+		`{"@type": "Person", "name": "Recipe"}`:                                                            0,
+		`"Recipe"`:                                                                                         0,
+	}
+	for document, expectedCount := range documents {
+		var value interface{}
+		suite.Nil(decodeJson(document, &value))
+		suite.Len(jsonLdRecipeNodes(value), expectedCount, document)
+	}
+}
+
+// Test mapping schema.org recipes to recipe values.
+func (suite *JsonLdTestSuite) TestRecipeValuesFromJsonLd() {
+
+	var node map[string]interface{}
+	suite.Nil(decodeJson(`{
+		"@type": "Recipe",
+		"name": " Apple &amp; Cinnamon Pie ",
+		"recipeCategory": ["Dessert", "Pie"],
+		"recipeIngredient": ["3 apples", "1 tsp cinnamon"],
+		"recipeInstructions": [
+			{"@type": "HowToSection", "name": "Filling", "itemListElement": [
+				{"@type": "HowToStep", "text": "Slice apples."},
+				{"@type": "HowToStep", "name": "Add cinnamon."}
+			]},
+			"Bake for 45 minutes."
+		]
+	}`, &node))
+	values := recipeValuesFromJsonLd(node)
+	suite.Equal("Apple & Cinnamon Pie", values["title"])
+	suite.Equal("baking", values["type"])
+	suite.Equal("3 apples\n1 tsp cinnamon", values["ingredients"])
+	suite.Equal("Slice apples.\nAdd cinnamon.\nBake for 45 minutes.", values["description"])
+
+	var node2 map[string]interface{}
+	suite.Nil(decodeJson(`{"@type": "Recipe", "ingredients": "Pasta", "description": "Boil pasta."}`, &node2))
+	values = recipeValuesFromJsonLd(node2)
+	suite.Equal("cooking", values["type"])
+	suite.Equal("Pasta", values["ingredients"])
+	suite.Equal("Boil pasta.", values["description"])
+	_, ok := values["title"]
+	suite.False(ok)
+}
+
+// Test mapping categories to recipe types.
+func (suite *JsonLdTestSuite) TestRecipeTypeFromCategories() {
+	suite.Equal(model.CookingRecipe, recipeTypeFromCategories([]string{}))
+	suite.Equal(model.CookingRecipe, recipeTypeFromCategories([]string{"Main Course", "Italian"}))
+	suite.Equal(model.BakingRecipe, recipeTypeFromCategories([]string{"Dessert", "Cakes"}))
+	suite.Equal(model.BakingRecipe, recipeTypeFromCategories([]string{"Baking"}))
+	suite.Equal(model.CookingRecipe, recipeTypeFromCategories([]string{"Bread", "cooking"}))
+	suite.Equal(model.BakingRecipe, recipeTypeFromCategories([]string{"Brot & Gebäck"}))
+}
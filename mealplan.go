@@ -0,0 +1,407 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	core "github.com/tommzn/recipeboard-core"
+)
+
+const (
+
+	// mealPlanCollection is the document store collection for meal plans.
+	mealPlanCollection = "mealplans"
+
+	// mealPlanDateFormat is the format of dates of planned meals.
+	mealPlanDateFormat = "2006-01-02"
+)
+
+// weekPattern matches ISO 8601 weeks, e.g. 2021-W07.
+var weekPattern = regexp.MustCompile(`^(\d{4})-[Ww](\d{2})$`)
+
+// mealSlots contains all slots of a day meals can be planned for, in the order they're listed.
+var mealSlots = []string{"breakfast", "lunch", "dinner", "snack"}
+
+// newDocumentMealPlanStore returns a meal plan store which persists meal plans in passed document store.
+func newDocumentMealPlanStore(store documentStore) mealPlanStore {
+	return &documentMealPlanStore{store: store}
+}
+
+// get returns the meal plan for passed week.
+func (plans *documentMealPlanStore) get(week string) (*mealPlan, error) {
+
+	plan := mealPlan{}
+	if err := plans.store.get(mealPlanCollection, week, &plan); err != nil {
+		if _, ok := err.(*notFoundError); ok {
+			return nil, newNotFoundError(fmt.Sprintf("There's no meal plan for week %s.", week), nil)
+		}
+		return nil, err
+	}
+	return &plan, nil
+}
+
+// set creates or replaces the meal plan for it's week.
+func (plans *documentMealPlanStore) set(plan mealPlan) error {
+	return plans.store.set(mealPlanCollection, plan.Week, plan)
+}
+
+// create adds passed meal plan, if there's no meal plan for it's week. Meal plans are created by a conditional write,
+// so only one of concurrent requests for the same week succeeds.
+func (plans *documentMealPlanStore) create(plan mealPlan) error {
+
+	err := plans.store.create(mealPlanCollection, plan.Week, plan)
+	if _, ok := err.(*conflictError); ok {
+		return newConflictError(fmt.Sprintf("Meal plan for week %s already exists.", plan.Week), nil)
+	}
+	return err
+}
+
+// delete removes the meal plan for passed week.
+func (plans *documentMealPlanStore) delete(week string) error {
+	return plans.store.delete(mealPlanCollection, week)
+}
+
+// list returns all meal plans, most recent week first.
+func (plans *documentMealPlanStore) list() ([]mealPlan, error) {
+
+	documents, err := plans.store.list(mealPlanCollection)
+	if err != nil {
+		return nil, err
+	}
+	mealPlans := []mealPlan{}
+	for _, document := range documents {
+		plan := mealPlan{}
+		if err := json.Unmarshal(document, &plan); err != nil {
+			return nil, err
+		}
+		mealPlans = append(mealPlans, plan)
+	}
+	sort.Slice(mealPlans, func(i, j int) bool {
+		return mealPlans[i].Week > mealPlans[j].Week
+	})
+	return mealPlans, nil
+}
+
+// parseWeek converts passed ISO 8601 week, e.g. 2021-W07, to it's canonical form and returns the monday of this week.
+func parseWeek(week string) (string, time.Time, error) {
+
+	match := weekPattern.FindStringSubmatch(strings.TrimSpace(week))
+	if match == nil {
+		return "", time.Time{}, fmt.Errorf("Invalid week: %s", week)
+	}
+	year, _ := strconv.Atoi(match[1])
+	weekNumber, _ := strconv.Atoi(match[2])
+
+	// January 4th is always part of the first week of a year.
+	january4 := time.Date(year, time.January, 4, 0, 0, 0, 0, time.UTC)
+	monday := january4.AddDate(0, 0, -((int(january4.Weekday())+6)%7)+(weekNumber-1)*7)
+	if isoYear, isoWeek := monday.ISOWeek(); weekNumber < 1 || isoYear != year || isoWeek != weekNumber {
+		return "", time.Time{}, fmt.Errorf("Invalid week: %s", week)
+	}
+	return fmt.Sprintf("%04d-W%02d", year, weekNumber), monday, nil
+}
+
+// weekFromPath returns the canonical week from path params. It fails with a bad request for invalid weeks.
+func weekFromPath(request events.APIGatewayProxyRequest) (string, error) {
+
+	week, ok := request.PathParameters["week"]
+	if !ok {
+		return "", errors.New("Missing week.")
+	}
+	canonicalWeek, _, err := parseWeek(week)
+	if err != nil {
+		return "", newBadRequestError(err.Error(), nil)
+	}
+	return canonicalWeek, nil
+}
+
+// mealPlanFromRequestBody decodes passed request body to a meal plan. Week of the meal plan is normalized.
+func mealPlanFromRequestBody(requestBody string) (*mealPlan, error) {
+
+	plan := mealPlan{}
+	if err := decodeJson(requestBody, &plan); err != nil {
+		return nil, newBadRequestError("Request body is not a valid meal plan", err)
+	}
+	if plan.Meals == nil {
+		plan.Meals = []plannedMeal{}
+	}
+	for idx := range plan.Meals {
+		plan.Meals[idx].Slot = strings.ToLower(strings.TrimSpace(plan.Meals[idx].Slot))
+	}
+	if week, _, err := parseWeek(plan.Week); err == nil {
+		plan.Week = week
+	}
+	return &plan, nil
+}
+
+// checkMealCount returns a bad request error if passed meal plan contains more meals than allowed.
+// Recipes of all meals are loaded one after another, so the number of meals is limited like the number of recipes
+// of a shopping list.
+func checkMealCount(plan mealPlan, maxMeals int) error {
+	if len(plan.Meals) > maxMeals {
+		return newBadRequestError(fmt.Sprintf("Too many meals: %d, max: %d", len(plan.Meals), maxMeals), nil)
+	}
+	return nil
+}
+
+// validateMealPlan checks week, dates, slots, scale factors and recipes of all meals of passed plan.
+// Dates have to be part of the planned week and all recipes have to exist.
+// It returns a validation error with all invalid fields.
+func validateMealPlan(plan mealPlan, recipeService core.RecipeService) error {
+
+	fieldErrors := []fieldError{}
+	_, monday, err := parseWeek(plan.Week)
+	if err != nil {
+		fieldErrors = append(fieldErrors, fieldError{Field: "week", Message: "must be an ISO 8601 week, e.g. 2021-W07"})
+	}
+	for idx, meal := range plan.Meals {
+		field := fmt.Sprintf("meals[%d]", idx)
+		if date, err := time.Parse(mealPlanDateFormat, meal.Date); err != nil {
+			fieldErrors = append(fieldErrors, fieldError{Field: field + ".date", Message: "must be a date, e.g. 2021-02-15"})
+		} else if !monday.IsZero() && (date.Before(monday) || !date.Before(monday.AddDate(0, 0, 7))) {
+			fieldErrors = append(fieldErrors, fieldError{Field: field + ".date", Message: "must be part of week " + plan.Week})
+		}
+		if !containsString(mealSlots, meal.Slot) {
+			fieldErrors = append(fieldErrors, fieldError{Field: field + ".slot", Message: "must be one of: " + strings.Join(mealSlots, ", ")})
+		}
+		if meal.Scale != nil && *meal.Scale <= 0 {
+			fieldErrors = append(fieldErrors, fieldError{Field: field + ".scale", Message: "must be a positive number"})
+		}
+		if meal.RecipeId == "" {
+			fieldErrors = append(fieldErrors, fieldError{Field: field + ".recipeid", Message: "is required"})
+			continue
+		}
+		if _, err := recipeService.Get(meal.RecipeId); err != nil {
+			if _, ok := fromServiceError(err).(*notFoundError); !ok {
+				return err
+			}
+			fieldErrors = append(fieldErrors, fieldError{Field: field + ".recipeid", Message: "must be an existing recipe"})
+		}
+	}
+	if len(fieldErrors) > 0 {
+		return newFieldValidationError(fieldErrors)
+	}
+	return nil
+}
+
+// sortMeals orders meals of passed plan by date and slot. Meals of the same slot keep their order.
+func sortMeals(plan *mealPlan) {
+	sort.SliceStable(plan.Meals, func(i, j int) bool {
+		if plan.Meals[i].Date != plan.Meals[j].Date {
+			return plan.Meals[i].Date < plan.Meals[j].Date
+		}
+		return slotIndex(plan.Meals[i].Slot) < slotIndex(plan.Meals[j].Slot)
+	})
+}
+
+// slotIndex returns the position of passed slot in a day.
+func slotIndex(slot string) int {
+	for idx, mealSlot := range mealSlots {
+		if mealSlot == slot {
+			return idx
+		}
+	}
+	return len(mealSlots)
+}
+
+// saveMealPlan sorts meals of passed meal plan and persists it by given write function, e.g. create or set
+// of a meal plan store. It returns the meal plan as JSON.
+func saveMealPlan(write func(mealPlan) error, plan mealPlan) (*string, error) {
+
+	sortMeals(&plan)
+	plan.UpdatedAt = time.Now().Round(1 * time.Second)
+	if err := write(plan); err != nil {
+		return nil, err
+	}
+	return marshalMealPlan(plan)
+}
+
+// marshalMealPlan returns passed meal plan as JSON string.
+func marshalMealPlan(plan interface{}) (*string, error) {
+	b, err := json.Marshal(plan)
+	jsonStr := string(b)
+	return &jsonStr, err
+}
+
+// parseRequest for meal plan lists doesn't need any values from a request.
+func (handler *apiGatewayMealPlanListRequestHandler) parseRequest(request events.APIGatewayProxyRequest) error {
+	return nil
+}
+
+// handle requests to list all meal plans, most recent week first.
+func (handler *apiGatewayMealPlanListRequestHandler) handle() (*string, error) {
+
+	plans, err := handler.mealPlans.list()
+	if err != nil {
+		return nil, err
+	}
+	return marshalMealPlan(mealPlanListResponse{Items: plans})
+}
+
+// parseRequest will try to convert request body to a meal plan.
+func (handler *apiGatewayMealPlanPostRequestHandler) parseRequest(request events.APIGatewayProxyRequest) error {
+
+	plan, err := mealPlanFromRequestBody(request.Body)
+	if err != nil {
+		return err
+	}
+	handler.plan = plan
+	return checkMealCount(*plan, handler.maxMeals)
+}
+
+// handle POST requests to create a new meal plan. Creating a meal plan for a week which
+// has already been planned will fail with a conflict, also for concurrent requests.
+func (handler *apiGatewayMealPlanPostRequestHandler) handle() (*string, error) {
+
+	if handler.plan == nil {
+		return nil, errors.New("Bad request")
+	}
+	if err := validateMealPlan(*handler.plan, handler.recipeService); err != nil {
+		return nil, err
+	}
+	body, err := saveMealPlan(handler.mealPlans.create, *handler.plan)
+	if err == nil {
+		handler.logger.Infof("Meal plan for week %s created.", handler.plan.Week)
+	}
+	return body, err
+}
+
+// parseRequest will extract the week from path.
+func (handler *apiGatewayMealPlanGetRequestHandler) parseRequest(request events.APIGatewayProxyRequest) error {
+
+	week, err := weekFromPath(request)
+	handler.week = week
+	return err
+}
+
+// handle GET requests to return the meal plan of a single week.
+func (handler *apiGatewayMealPlanGetRequestHandler) handle() (*string, error) {
+
+	plan, err := handler.mealPlans.get(handler.week)
+	if err != nil {
+		return nil, err
+	}
+	return marshalMealPlan(plan)
+}
+
+// parseRequest will try to convert request body to a meal plan and extract the week from path.
+// Week from path is used if request body doesn't contain a week, but if both are available they have to be equal.
+func (handler *apiGatewayMealPlanPutRequestHandler) parseRequest(request events.APIGatewayProxyRequest) error {
+
+	week, err := weekFromPath(request)
+	if err != nil {
+		return err
+	}
+	plan, err := mealPlanFromRequestBody(request.Body)
+	if err != nil {
+		return err
+	}
+	if plan.Week == "" {
+		plan.Week = week
+	}
+	if plan.Week != week {
+		return newFieldValidationError([]fieldError{{Field: "week", Message: "must match week in path"}})
+	}
+	handler.plan = plan
+	return checkMealCount(*plan, handler.maxMeals)
+}
+
+// handle PUT requests to replace all meals of an existing meal plan.
+func (handler *apiGatewayMealPlanPutRequestHandler) handle() (*string, error) {
+
+	if handler.plan == nil {
+		return nil, errors.New("Bad request")
+	}
+	if _, err := handler.mealPlans.get(handler.plan.Week); err != nil {
+		return nil, err
+	}
+	if err := validateMealPlan(*handler.plan, handler.recipeService); err != nil {
+		return nil, err
+	}
+	body, err := saveMealPlan(handler.mealPlans.set, *handler.plan)
+	if err == nil {
+		handler.logger.Infof("Meal plan for week %s updated.", handler.plan.Week)
+	}
+	return body, err
+}
+
+// parseRequest will extract the week from path.
+func (handler *apiGatewayMealPlanDeleteRequestHandler) parseRequest(request events.APIGatewayProxyRequest) error {
+
+	week, err := weekFromPath(request)
+	handler.week = week
+	return err
+}
+
+// handle DELETE requests to remove the meal plan of a single week.
+func (handler *apiGatewayMealPlanDeleteRequestHandler) handle() (*string, error) {
+
+	if _, err := handler.mealPlans.get(handler.week); err != nil {
+		return nil, err
+	}
+	return nil, handler.mealPlans.delete(handler.week)
+}
+
+// parseRequest will extract the week from path and the unit system from units query param.
+func (handler *apiGatewayMealPlanShoppingListRequestHandler) parseRequest(request events.APIGatewayProxyRequest) error {
+
+	units, err := parseUnitSystem(request.QueryStringParameters)
+	if err != nil {
+		return err
+	}
+	handler.units = units
+
+	week, err := weekFromPath(request)
+	handler.week = week
+	return err
+}
+
+// handle requests to get a shopping list for all meals of a week, rendered as JSON or Markdown checklist.
+// Scale factors of meals with the same recipe are added. If a planned recipe has been deleted meanwhile,
+// a conflict is returned.
+func (handler *apiGatewayMealPlanShoppingListRequestHandler) handle() (*string, error) {
+
+	plan, err := handler.mealPlans.get(handler.week)
+	if err != nil {
+		return nil, err
+	}
+	recipes := []shoppingListRecipe{}
+	for _, meal := range plan.Meals {
+		scale := 1.0
+		if meal.Scale != nil {
+			scale = *meal.Scale
+		}
+		recipes = addShoppingListRecipe(recipes, shoppingListRecipe{Id: meal.RecipeId, Scale: scale})
+	}
+	if len(recipes) > handler.maxRecipes {
+		return nil, newConflictError(fmt.Sprintf("Meal plan for week %s contains too many recipes: %d, max: %d", handler.week, len(recipes), handler.maxRecipes), nil)
+	}
+	list, err := buildShoppingList(handler.recipeService, recipes, handler.units)
+	if err != nil {
+		if _, ok := err.(*validationError); ok {
+			return nil, newConflictError(fmt.Sprintf("Meal plan for week %s contains recipes which don't exist anymore.", handler.week), err)
+		}
+		return nil, err
+	}
+	if handler.mediaType == markdownMediaType {
+		return renderShoppingListMarkdown(list), nil
+	}
+	return marshalShoppingList(list)
+}
+
+// mediaTypes returns all media types a shopping list can be rendered in.
+func (handler *apiGatewayMealPlanShoppingListRequestHandler) mediaTypes() []string {
+	return shoppingListMediaTypes
+}
+
+// useMediaType defines the media type the shopping list is rendered in.
+func (handler *apiGatewayMealPlanShoppingListRequestHandler) useMediaType(mediaType string) {
+	handler.mediaType = mediaType
+}
@@ -0,0 +1,273 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/stretchr/testify/suite"
+	"github.com/tommzn/recipeboard-core/mock"
+	model "github.com/tommzn/recipeboard-core/model"
+)
+
+// Test suite for meal plans.
+type MealPlanTestSuite struct {
+	suite.Suite
+	repo    *mock.RepositoryMock
+	factory *requestHandlerFactory
+	router  LambdaRequestHandler
+}
+
+func TestMealPlanTestSuite(t *testing.T) {
+	suite.Run(t, new(MealPlanTestSuite))
+}
+
+// Setup test.
+func (suite *MealPlanTestSuite) SetupTest() {
+	suite.repo = repositoryForTest()
+	suite.factory = factoryForTest(suite.repo, publisherForTest(), loggerForTest())
+	suite.router = routerWithFactoryForTest(suite.factory, loggerForTest())
+}
+
+// Test parsing ISO 8601 weeks.
+func (suite *MealPlanTestSuite) TestParseWeek() {
+
+	week, monday, err := parseWeek("2021-w07")
+	suite.Nil(err)
+	suite.Equal("2021-W07", week)
+	suite.Equal(time.Date(2021, time.February, 15, 0, 0, 0, 0, time.UTC), monday)
+
+	_, monday, err = parseWeek("2020-W53")
+	suite.Nil(err)
+	suite.Equal(time.Date(2020, time.December, 28, 0, 0, 0, 0, time.UTC), monday)
+
+	_, monday, err = parseWeek("2019-W01")
+	suite.Nil(err)
+	suite.Equal(time.Date(2018, time.December, 31, 0, 0, 0, 0, time.UTC), monday)
+
+	for _, week := range []string{"2021-W53", "2021-W00", "2021-07", "xxx", ""} {
+		_, _, err = parseWeek(week)
+		suite.NotNil(err, week)
+	}
+}
+
+// Test validating meal plans.
+func (suite *MealPlanTestSuite) TestValidateMealPlan() {
+
+	recipe := suite.recipeForTest("Cake", "250 g Mehl")
+	scale := 0.0
+	plan := mealPlan{Week: "2021-W07", Meals: []plannedMeal{
+		{Date: "2021-02-15", Slot: "dinner", RecipeId: recipe.Id},
+		{Date: "2021-02-22", Slot: "brunch", RecipeId: "xxx", Scale: &scale},
+		{Date: "15.02.2021", Slot: "lunch"},
+	}}
+	err := validateMealPlan(plan, suite.factory.getRecipeService())
+	suite.NotNil(err)
+	validationErr, ok := err.(*validationError)
+	suite.True(ok)
+	fields := []string{}
+	for _, fieldErr := range validationErr.fieldErrors {
+		fields = append(fields, fieldErr.Field)
+	}
+	suite.Equal([]string{"meals[1].date", "meals[1].slot", "meals[1].scale", "meals[1].recipeid", "meals[2].date", "meals[2].recipeid"}, fields)
+
+	plan.Meals = plan.Meals[:1]
+	suite.Nil(validateMealPlan(plan, suite.factory.getRecipeService()))
+
+	plan.Week = "xxx"
+	suite.NotNil(validateMealPlan(plan, suite.factory.getRecipeService()))
+}
+
+// Test creating, updating, listing and deleting meal plans.
+func (suite *MealPlanTestSuite) TestMealPlanRequests() {
+
+	cake := suite.recipeForTest("Cake", "250 g Mehl")
+	soup := suite.recipeForTest("Soup", "2 Zwiebeln")
+
+	request := apiGatewayRequestWithPathForTest(http.MethodPost, "/mealplans")
+	request.Body = `{"week": "2021-w07", "meals": [
+		{"date": "2021-02-16", "slot": "Dinner", "recipeid": "` + soup.Id + `"},
+		{"date": "2021-02-15", "slot": "dinner", "recipeid": "` + cake.Id + `"},
+		{"date": "2021-02-16", "slot": "lunch", "recipeid": "` + cake.Id + `"}
+	]}`
+	response := suite.handle(request)
+	suite.Equal(http.StatusOK, response.StatusCode)
+	plan := suite.mealPlanFromResponse(response)
+	suite.Equal("2021-W07", plan.Week)
+	suite.Equal([]string{cake.Id, cake.Id, soup.Id}, suite.recipeIds(plan))
+	suite.Equal("lunch", plan.Meals[1].Slot)
+
+	suite.Equal(http.StatusConflict, suite.handle(request).StatusCode)
+
+	request.Body = `{"week": "2021-W08", "meals": [{"date": "2021-02-22", "slot": "dinner", "recipeid": "xxx"}]}`
+	suite.Equal(http.StatusUnprocessableEntity, suite.handle(request).StatusCode)
+
+	request = apiGatewayRequestWithPathForTest(http.MethodGet, "/mealplans/2021-W07")
+	response = suite.handle(request)
+	suite.Equal(http.StatusOK, response.StatusCode)
+	suite.Len(suite.mealPlanFromResponse(response).Meals, 3)
+
+	request = apiGatewayRequestWithPathForTest(http.MethodPut, "/mealplans/2021-W07")
+	request.Body = `{"meals": [{"date": "2021-02-21", "slot": "breakfast", "recipeid": "` + soup.Id + `"}]}`
+	response = suite.handle(request)
+	suite.Equal(http.StatusOK, response.StatusCode)
+	suite.Equal([]string{soup.Id}, suite.recipeIds(suite.mealPlanFromResponse(response)))
+
+	request.Body = `{"week": "2021-W08", "meals": []}`
+	suite.Equal(http.StatusUnprocessableEntity, suite.handle(request).StatusCode)
+
+	request = apiGatewayRequestWithPathForTest(http.MethodPut, "/mealplans/2021-W08")
+	request.Body = `{"meals": []}`
+	suite.Equal(http.StatusNotFound, suite.handle(request).StatusCode)
+
+	request = apiGatewayRequestWithPathForTest(http.MethodPost, "/mealplans")
+	request.Body = `{"week": "2021-W08", "meals": []}`
+	suite.Equal(http.StatusOK, suite.handle(request).StatusCode)
+
+	response = suite.handle(apiGatewayRequestWithPathForTest(http.MethodGet, "/mealplans"))
+	suite.Equal(http.StatusOK, response.StatusCode)
+	list := mealPlanListResponse{}
+	suite.Nil(json.Unmarshal([]byte(response.Body), &list))
+	suite.Len(list.Items, 2)
+	suite.Equal("2021-W08", list.Items[0].Week)
+
+	request = apiGatewayRequestWithPathForTest(http.MethodDelete, "/mealplans/2021-W07")
+	suite.Equal(http.StatusOK, suite.handle(request).StatusCode)
+	suite.Equal(http.StatusNotFound, suite.handle(request).StatusCode)
+	suite.Equal(http.StatusNotFound, suite.handle(apiGatewayRequestWithPathForTest(http.MethodGet, "/mealplans/2021-W07")).StatusCode)
+	suite.Equal(http.StatusBadRequest, suite.handle(apiGatewayRequestWithPathForTest(http.MethodGet, "/mealplans/2021-W53")).StatusCode)
+}
+
+// Test shopping lists for all meals of a week.
+func (suite *MealPlanTestSuite) TestMealPlanShoppingList() {
+
+	cake := suite.recipeForTest("Cake", "250 g Mehl\n2 Eier")
+	soup := suite.recipeForTest("Soup", "2 Zwiebeln\n2 Eier")
+
+	request := apiGatewayRequestWithPathForTest(http.MethodPost, "/mealplans")
+	request.Body = `{"week": "2021-W07", "meals": [
+		{"date": "2021-02-15", "slot": "dinner", "recipeid": "` + cake.Id + `"},
+		{"date": "2021-02-16", "slot": "dinner", "recipeid": "` + soup.Id + `", "scale": 2},
+		{"date": "2021-02-17", "slot": "snack", "recipeid": "` + cake.Id + `", "scale": 0.5}
+	]}`
+	suite.Equal(http.StatusOK, suite.handle(request).StatusCode)
+
+	request = apiGatewayRequestWithPathForTest(http.MethodGet, "/mealplans/2021-W07/shopping-list")
+	request.Headers = map[string]string{"Accept": "text/markdown"}
+	response := suite.handle(request)
+	suite.Equal(http.StatusOK, response.StatusCode)
+	suite.Equal("# Shopping List\n\n- Cake (x1 1/2)\n- Soup (x2)\n\n## Produce\n\n- [ ] 4 Zwiebeln\n\n## Dairy & Eggs\n\n- [ ] 7 Eier\n\n## Baking\n\n- [ ] 375 g Mehl\n", response.Body)
+
+	request.Headers = nil
+	response = suite.handle(request)
+	suite.Equal(http.StatusOK, response.StatusCode)
+	list := shoppingList{}
+	suite.Nil(json.Unmarshal([]byte(response.Body), &list))
+	suite.Len(list.Recipes, 2)
+
+	request = apiGatewayRequestWithPathForTest(http.MethodPost, "/shopping-lists")
+	request.Body = `{"recipes": [{"id": "` + cake.Id + `", "scale": 1.5}, {"id": "` + soup.Id + `", "scale": 2}]}`
+	expected := suite.handle(request)
+	suite.Equal(http.StatusOK, expected.StatusCode)
+	suite.JSONEq(expected.Body, response.Body)
+
+	request = apiGatewayRequestWithPathForTest(http.MethodGet, "/mealplans/2021-W07/shopping-list")
+	delete(suite.repo.Recipes, soup.Id)
+	suite.Equal(http.StatusConflict, suite.handle(request).StatusCode)
+
+	suite.Equal(http.StatusNotFound, suite.handle(apiGatewayRequestWithPathForTest(http.MethodGet, "/mealplans/2021-W08/shopping-list")).StatusCode)
+}
+
+// Test limiting the number of meals and recipes of meal plans.
+func (suite *MealPlanTestSuite) TestMealPlanLimits() {
+
+	suite.factory.config = configForTest("batch:\n  maxsize: 2\n")
+	cake := suite.recipeForTest("Cake", "250 g Mehl")
+	soup := suite.recipeForTest("Soup", "2 Zwiebeln")
+	bread := suite.recipeForTest("Bread", "500 g Mehl")
+
+	request := apiGatewayRequestWithPathForTest(http.MethodPost, "/mealplans")
+	request.Body = `{"week": "2021-W07", "meals": [
+		{"date": "2021-02-15", "slot": "dinner", "recipeid": "` + cake.Id + `"},
+		{"date": "2021-02-16", "slot": "dinner", "recipeid": "` + soup.Id + `"},
+		{"date": "2021-02-17", "slot": "dinner", "recipeid": "` + bread.Id + `"}
+	]}`
+	suite.Equal(http.StatusBadRequest, suite.handle(request).StatusCode)
+
+	request.Body = `{"week": "2021-W07", "meals": []}`
+	suite.Equal(http.StatusOK, suite.handle(request).StatusCode)
+
+	request = apiGatewayRequestWithPathForTest(http.MethodPut, "/mealplans/2021-W07")
+	request.Body = `{"meals": [
+		{"date": "2021-02-15", "slot": "dinner", "recipeid": "` + cake.Id + `"},
+		{"date": "2021-02-16", "slot": "dinner", "recipeid": "` + soup.Id + `"},
+		{"date": "2021-02-17", "slot": "dinner", "recipeid": "` + bread.Id + `"}
+	]}`
+	suite.Equal(http.StatusBadRequest, suite.handle(request).StatusCode)
+
+	// Meal plans persisted before the limit has been lowered can contain more recipes.
+	plan := mealPlan{Week: "2021-W08", Meals: []plannedMeal{
+		{Date: "2021-02-22", Slot: "dinner", RecipeId: cake.Id},
+		{Date: "2021-02-23", Slot: "dinner", RecipeId: soup.Id},
+		{Date: "2021-02-24", Slot: "dinner", RecipeId: bread.Id},
+	}}
+	suite.Nil(suite.factory.getMealPlanStore().set(plan))
+	response := suite.handle(apiGatewayRequestWithPathForTest(http.MethodGet, "/mealplans/2021-W08/shopping-list"))
+	suite.Equal(http.StatusConflict, response.StatusCode)
+}
+
+// Test only one of concurrent requests creates a meal plan for a week.
+func (suite *MealPlanTestSuite) TestCreateMealPlanConcurrently() {
+
+	store := newMemoryDocumentStore()
+	results := make(chan error, 10)
+	for i := 0; i < 10; i++ {
+		go func() {
+			results <- newDocumentMealPlanStore(store).create(mealPlan{Week: "2021-W07", Meals: []plannedMeal{}})
+		}()
+	}
+	created := 0
+	for i := 0; i < 10; i++ {
+		if err := <-results; err == nil {
+			created++
+		} else {
+			suite.IsType(&conflictError{}, err)
+		}
+	}
+	suite.Equal(1, created)
+}
+
+// recipeForTest creates a recipe with passed title and ingredients.
+func (suite *MealPlanTestSuite) recipeForTest(title, ingredients string) model.Recipe {
+	recipe := recipeForTest()
+	recipe.Title = title
+	recipe.Ingredients = ingredients
+	suite.repo.Recipes[recipe.Id] = recipe
+	return recipe
+}
+
+// mealPlanFromResponse decodes a meal plan from passed response.
+func (suite *MealPlanTestSuite) mealPlanFromResponse(response events.APIGatewayProxyResponse) mealPlan {
+	plan := mealPlan{}
+	suite.Nil(json.Unmarshal([]byte(response.Body), &plan))
+	return plan
+}
+
+// recipeIds returns the recipe ids of all meals of passed plan.
+func (suite *MealPlanTestSuite) recipeIds(plan mealPlan) []string {
+	ids := []string{}
+	for _, meal := range plan.Meals {
+		ids = append(ids, meal.RecipeId)
+	}
+	return ids
+}
+
+// handle passes a request to the router.
+func (suite *MealPlanTestSuite) handle(request events.APIGatewayProxyRequest) events.APIGatewayProxyResponse {
+	response, err := suite.router.handle(context.Background(), request)
+	suite.Nil(err)
+	return response
+}
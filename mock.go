@@ -1,6 +1,17 @@
 package main
 
-import "github.com/aws/aws-lambda-go/events"
+import (
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	awsdynamodb "github.com/aws/aws-sdk-go/service/dynamodb"
+	core "github.com/tommzn/recipeboard-core"
+	model "github.com/tommzn/recipeboard-core/model"
+)
 
 // apiGatewayRequestHandlerMock is used to test request router with pre definded return values
 // for parseRequest and handle method.
@@ -30,3 +41,110 @@ type requestHandlerFactoryMock struct {
 func (mock *requestHandlerFactoryMock) handlerForRequest(request events.APIGatewayProxyRequest) (apiGatewayRequestHandler, error) {
 	return mock.requestHandler, mock.responseError
 }
+
+// recipeServiceMock wraps a recipe service to serialize access to not thread-safe repository and publisher mocks
+// and records the max number of concurrent calls. If unserialized is set calls aren't serialized, like in production,
+// to verify a caller doesn't call the wrapped service concurrently.
+type recipeServiceMock struct {
+	core.RecipeService
+	unserialized bool
+	mutex        sync.Mutex
+	counter      sync.Mutex
+	inFlight     int
+	maxInFlight  int
+	delay        time.Duration
+}
+
+// Get a recipe from wrapped recipe service.
+func (mock *recipeServiceMock) Get(id string) (*model.Recipe, error) {
+	defer mock.track()()
+	return mock.RecipeService.Get(id)
+}
+
+// Delete a recipe by wrapped recipe service.
+func (mock *recipeServiceMock) Delete(recipe model.Recipe) error {
+	defer mock.track()()
+	return mock.RecipeService.Delete(recipe)
+}
+
+// track counts a concurrent call and waits for the defined delay before access to the wrapped service is locked.
+// It returns a function to release the lock, which has to be called at the end of a call.
+func (mock *recipeServiceMock) track() func() {
+
+	mock.counter.Lock()
+	mock.inFlight++
+	if mock.inFlight > mock.maxInFlight {
+		mock.maxInFlight = mock.inFlight
+	}
+	mock.counter.Unlock()
+
+	time.Sleep(mock.delay)
+	if !mock.unserialized {
+		mock.mutex.Lock()
+	}
+	return func() {
+		if !mock.unserialized {
+			mock.mutex.Unlock()
+		}
+		mock.counter.Lock()
+		mock.inFlight--
+		mock.counter.Unlock()
+	}
+}
+
+// dynamoDbItemWriterMock keeps keys of written items to evaluate attribute_not_exists conditions.
+type dynamoDbItemWriterMock struct {
+	items     map[string]bool
+	lastInput *awsdynamodb.PutItemInput
+	err       error
+}
+
+// PutItem writes passed item, if there's no item with the same key for conditional puts.
+func (mock *dynamoDbItemWriterMock) PutItem(input *awsdynamodb.PutItemInput) (*awsdynamodb.PutItemOutput, error) {
+
+	mock.lastInput = input
+	if mock.err != nil {
+		return nil, mock.err
+	}
+	key := *input.Item["ObjectType"].S + "/" + *input.Item["Id"].S
+	if input.ConditionExpression != nil && mock.items[key] {
+		return nil, awserr.New(awsdynamodb.ErrCodeConditionalCheckFailedException, "The conditional request failed", nil)
+	}
+	mock.items[key] = true
+	return &awsdynamodb.PutItemOutput{}, nil
+}
+
+// sizeLimitedDocumentStore rejects documents which exceed a max size.
+type sizeLimitedDocumentStore struct {
+	documentStore
+	maxSize int
+}
+
+// create will add a new document if it doesn't exceed the max size.
+func (store *sizeLimitedDocumentStore) create(collection, id string, value interface{}) error {
+	if err := store.checkSize(collection, id, value); err != nil {
+		return err
+	}
+	return store.documentStore.create(collection, id, value)
+}
+
+// set will create or replace a document if it doesn't exceed the max size.
+func (store *sizeLimitedDocumentStore) set(collection, id string, value interface{}) error {
+	if err := store.checkSize(collection, id, value); err != nil {
+		return err
+	}
+	return store.documentStore.set(collection, id, value)
+}
+
+// checkSize returns an error if passed document exceeds the max size.
+func (store *sizeLimitedDocumentStore) checkSize(collection, id string, value interface{}) error {
+
+	document, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	if len(document) > store.maxSize {
+		return fmt.Errorf("Document %s/%s exceeds max size: %d", collection, id, len(document))
+	}
+	return nil
+}
@@ -0,0 +1,139 @@
+package main
+
+import (
+	"strconv"
+	"strings"
+)
+
+const (
+
+	// jsonMediaType is the default media type of all responses.
+	jsonMediaType = "application/json"
+
+	// jsonLdMediaType is used for schema.org JSON-LD renderings.
+	jsonLdMediaType = "application/ld+json"
+
+	// yamlMediaType is used for YAML renderings.
+	yamlMediaType = "application/yaml"
+
+	// markdownMediaType is used for Markdown renderings.
+	markdownMediaType = "text/markdown"
+
+	// htmlMediaType is used for printable HTML renderings.
+	htmlMediaType = "text/html"
+
+	// plainTextMediaType is used for plain text renderings.
+	plainTextMediaType = "text/plain"
+
+	// defaultCharset is added to content type of all text based responses.
+	defaultCharset = "utf-8"
+)
+
+// recipeMediaTypes contains all media types a single recipe can be rendered in.
+var recipeMediaTypes = []string{jsonMediaType, jsonLdMediaType, yamlMediaType, markdownMediaType, htmlMediaType, plainTextMediaType}
+
+// printMediaTypes contains all media types of a printable recipe.
+var printMediaTypes = []string{htmlMediaType}
+
+// shoppingListMediaTypes contains all media types a shopping list can be rendered in.
+var shoppingListMediaTypes = []string{jsonMediaType, markdownMediaType}
+
+// mediaTypeAliases maps unofficial, but common media types to the media type used in responses.
+var mediaTypeAliases = map[string]string{
+	"application/x-yaml": yamlMediaType,
+	"text/yaml":          yamlMediaType,
+	"text/x-yaml":        yamlMediaType,
+	"text/x-markdown":    markdownMediaType,
+}
+
+// negotiateMediaType returns the media type from passed list which fits best to given Accept header.
+// Preference of the client, defined by quality values, wins. If the client accepts multiple media types with
+// the same quality, the first one in passed list is used. If there's no Accept header, the first media type
+// is returned. A not acceptable error is returned if no media type is acceptable for the client.
+func negotiateMediaType(accept string, mediaTypes []string) (string, error) {
+
+	if strings.TrimSpace(accept) == "" {
+		return mediaTypes[0], nil
+	}
+
+	ranges := parseAccept(accept)
+	bestMediaType := ""
+	bestQuality := 0.0
+	for _, mediaType := range mediaTypes {
+		if quality := acceptQuality(ranges, mediaType); quality > bestQuality {
+			bestMediaType = mediaType
+			bestQuality = quality
+		}
+	}
+	if bestMediaType == "" {
+		return "", &notAcceptableError{accept: accept, supportedTypes: mediaTypes}
+	}
+	return bestMediaType, nil
+}
+
+// parseAccept returns all media ranges of passed Accept header with their quality.
+// Media ranges without a quality value have a quality of 1. Invalid quality values are treated as 0.
+func parseAccept(accept string) []mediaRange {
+
+	ranges := []mediaRange{}
+	for _, element := range strings.Split(accept, ",") {
+		params := strings.Split(element, ";")
+		mediaType := mediaType(params[0])
+		if mediaType == "" {
+			continue
+		}
+		if alias, ok := mediaTypeAliases[mediaType]; ok {
+			mediaType = alias
+		}
+		quality := 1.0
+		for _, param := range params[1:] {
+			param = strings.TrimSpace(param)
+			if strings.HasPrefix(strings.ToLower(param), "q=") {
+				value, err := strconv.ParseFloat(param[2:], 64)
+				if err != nil || value < 0 || value > 1 {
+					value = 0
+				}
+				quality = value
+			}
+		}
+		ranges = append(ranges, mediaRange{mediaType: mediaType, quality: quality})
+	}
+	return ranges
+}
+
+// acceptQuality returns the quality of passed media type defined by the most specific matching media range.
+// Exact matches are more specific than a subtype wildcard, which is more specific than */*.
+func acceptQuality(ranges []mediaRange, mediaType string) float64 {
+
+	mainType := strings.Split(mediaType, "/")[0]
+	quality := 0.0
+	specificity := -1
+	for _, mediaRange := range ranges {
+		rangeSpecificity := -1
+		switch mediaRange.mediaType {
+		case mediaType:
+			rangeSpecificity = 2
+		case mainType + "/*":
+			rangeSpecificity = 1
+		case "*/*", "*":
+			rangeSpecificity = 0
+		}
+		if rangeSpecificity > specificity {
+			quality = mediaRange.quality
+			specificity = rangeSpecificity
+		}
+	}
+	return quality
+}
+
+// binaryMediaTypes contains all media types of binary responses, which have no charset.
+var binaryMediaTypes = []string{zipMediaType}
+
+// contentTypeHeader returns the value for a Content-Type header of passed media type, including the charset
+// for text based media types.
+func contentTypeHeader(mediaType string) string {
+	if containsString(binaryMediaTypes, mediaType) {
+		return mediaType
+	}
+	return mediaType + "; charset=" + defaultCharset
+}
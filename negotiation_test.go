@@ -0,0 +1,53 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+)
+
+// Test suite for content negotiation.
+type NegotiationTestSuite struct {
+	suite.Suite
+}
+
+func TestNegotiationTestSuite(t *testing.T) {
+	suite.Run(t, new(NegotiationTestSuite))
+}
+
+// Test selecting a media type by Accept header.
+func (suite *NegotiationTestSuite) TestNegotiateMediaType() {
+
+	testCases := map[string]string{
+		"":                                   jsonMediaType,
+		"*/*":                                jsonMediaType,
+		"application/json":                   jsonMediaType,
+		"application/yaml":                   yamlMediaType,
+		"application/x-yaml":                 yamlMediaType,
+		"text/markdown; charset=utf-8":       markdownMediaType,
+		"TEXT/PLAIN":                         plainTextMediaType,
+		"text/*":                             markdownMediaType,
+		"text/*, text/markdown;q=0.5":        htmlMediaType,
+		"application/json;q=0.5, text/plain": plainTextMediaType,
+		"text/html, */*;q=0.8":               htmlMediaType,
+		"image/png, */*;q=0.8":               jsonMediaType,
+		"*/*;q=0.1, application/json;q=0":    jsonLdMediaType,
+	}
+	for accept, expectedMediaType := range testCases {
+		mediaType, err := negotiateMediaType(accept, recipeMediaTypes)
+		suite.Nil(err, accept)
+		suite.Equal(expectedMediaType, mediaType, accept)
+	}
+
+	for _, accept := range []string{"text/html", "application/json;q=0", "image/*", "application/json;q=x"} {
+		_, err := negotiateMediaType(accept, []string{jsonMediaType})
+		suite.NotNil(err, accept)
+		suite.IsType(&notAcceptableError{}, err)
+	}
+}
+
+// Test content type header values.
+func (suite *NegotiationTestSuite) TestContentTypeHeader() {
+	suite.Equal("application/json; charset=utf-8", contentTypeHeader(jsonMediaType))
+	suite.Equal("text/markdown; charset=utf-8", contentTypeHeader(markdownMediaType))
+}
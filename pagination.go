@@ -0,0 +1,184 @@
+package main
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	model "github.com/tommzn/recipeboard-core/model"
+)
+
+const (
+
+	// defaultPageSize is used if a cursor is passed without a limit.
+	defaultPageSize = 20
+
+	// maxPageSize is the max number of recipes on a single page.
+	maxPageSize = 100
+
+	// defaultSortOrder is used to sort recipe lists if no sort order is requested.
+	defaultSortOrder = "createdat"
+
+	// descendingSortPrefix is used to revert a sort order, e.g. -createdat lists newest recipes first.
+	descendingSortPrefix = "-"
+
+	// sortKeyTimeFormat is a fixed length time format, so time sort keys can be compared as strings.
+	sortKeyTimeFormat = "2006-01-02T15:04:05.000000000Z"
+)
+
+// parsePageRequest extracts limit and cursor from passed query params.
+// If neither a limit nor a cursor has been passed the returned page request isn't paged and all recipes are listed.
+func parsePageRequest(queryParams map[string]string, sortOrder string) (pageRequest, error) {
+
+	limitStr, hasLimit := queryParams["limit"]
+	token, hasCursor := queryParams["cursor"]
+	page := pageRequest{limit: defaultPageSize, paged: hasLimit || hasCursor}
+	if hasLimit {
+		limit, err := strconv.Atoi(limitStr)
+		if err != nil || limit < 1 || limit > maxPageSize {
+			return page, newBadRequestError(fmt.Sprintf("Limit has to be a number between 1 and %d.", maxPageSize), nil)
+		}
+		page.limit = limit
+	}
+	if hasCursor {
+		cursor, err := decodeCursor(token)
+		if err != nil {
+			return page, err
+		}
+		if cursor.Sort != sortOrder {
+			return page, newBadRequestError("Cursor doesn't match requested sort order.", nil)
+		}
+		page.cursor = cursor
+	}
+	return page, nil
+}
+
+// encodeCursor converts passed cursor into an opaque token.
+func encodeCursor(cursor listCursor) string {
+	cursorJson, _ := json.Marshal(cursor)
+	return base64.RawURLEncoding.EncodeToString(cursorJson)
+}
+
+// decodeCursor converts passed token back into a cursor.
+func decodeCursor(token string) (*listCursor, error) {
+
+	cursorJson, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return nil, newBadRequestError("Invalid cursor.", err)
+	}
+	cursor := &listCursor{}
+	if err := json.Unmarshal(cursorJson, cursor); err != nil || cursor.Id == "" {
+		return nil, newBadRequestError("Invalid cursor.", err)
+	}
+	return cursor, nil
+}
+
+// sortOrders contains all supported sort orders for recipe lists.
+var sortOrders = []string{"createdat", "-createdat", "title", "-title"}
+
+// parseSortOrder returns the sort order from passed query params or the default sort order if it's omitted.
+func parseSortOrder(queryParams map[string]string) (string, error) {
+
+	sortOrder, ok := queryParams["sort"]
+	if !ok {
+		return defaultSortOrder, nil
+	}
+	sortOrder = strings.ToLower(sortOrder)
+	if !containsString(sortOrders, sortOrder) {
+		return "", newBadRequestError(fmt.Sprintf("Unsupported sort order: %s, supported: %s", queryParams["sort"], strings.Join(sortOrders, ", ")), nil)
+	}
+	return sortOrder, nil
+}
+
+// sortRecipes sorts passed recipes by given sort order. Recipe id is used as tie breaker,
+// so recipes with equal sort keys keep a stable order across requests.
+func sortRecipes(recipes []model.Recipe, sortOrder string) {
+	sort.Slice(recipes, func(i, j int) bool {
+		return compareSortPosition(sortOrder, sortKey(recipes[i], sortOrder), recipes[i].Id, sortKey(recipes[j], sortOrder), recipes[j].Id) < 0
+	})
+}
+
+// sortKey returns the value passed recipe is sorted by for given sort order.
+// Recipes are sorted by their creation time or case insensitive by their title.
+func sortKey(recipe model.Recipe, sortOrder string) string {
+
+	switch strings.TrimPrefix(sortOrder, descendingSortPrefix) {
+	case "title":
+		return strings.ToLower(recipe.Title)
+	default:
+		return recipe.CreatedAt.UTC().Format(sortKeyTimeFormat)
+	}
+}
+
+// compareSortPosition compares two positions in a sorted recipe list, defined by sort key and recipe id.
+// Result is reverted for descending sort orders.
+func compareSortPosition(sortOrder, key1, id1, key2, id2 string) int {
+
+	result := 0
+	switch {
+	case key1 < key2:
+		result = -1
+	case key1 > key2:
+		result = 1
+	case id1 < id2:
+		result = -1
+	case id1 > id2:
+		result = 1
+	}
+	if strings.HasPrefix(sortOrder, descendingSortPrefix) {
+		return -result
+	}
+	return result
+}
+
+// paginate returns all recipes of passed page from given sorted recipes. Page starts right after the cursor position,
+// so inserted or deleted recipes doesn't shift following pages. A cursor for the next page is returned
+// if there're more recipes available.
+func paginate(recipes []model.Recipe, page pageRequest, sortOrder string) ([]model.Recipe, *listCursor) {
+
+	start := 0
+	if page.cursor != nil {
+		start = sort.Search(len(recipes), func(idx int) bool {
+			return compareSortPosition(sortOrder, sortKey(recipes[idx], sortOrder), recipes[idx].Id, page.cursor.Key, page.cursor.Id) > 0
+		})
+	}
+
+	end := start + page.limit
+	if end >= len(recipes) {
+		return recipes[start:], nil
+	}
+	lastRecipe := recipes[end-1]
+	return recipes[start:end], &listCursor{Sort: sortOrder, Key: sortKey(lastRecipe, sortOrder), Id: lastRecipe.Id}
+}
+
+// nextPageLink creates a link to the page starting after passed cursor. All other query params are kept,
+// including all values of multi value query params, so filters like multiple recipe types apply to all pages.
+func nextPageLink(path string, queryParams url.Values, cursor listCursor) string {
+
+	values := url.Values{}
+	for key, paramValues := range queryParams {
+		values[key] = append([]string{}, paramValues...)
+	}
+	values.Set("cursor", encodeCursor(cursor))
+	return path + "?" + values.Encode()
+}
+
+// marshalRecipePage returns JSON string of a page with passed recipes and a link to the next page.
+// If fields are passed, only these fields are included for each recipe.
+func marshalRecipePage(recipes []model.Recipe, next *string, fields []string) (*string, error) {
+	for idx, recipe := range recipes {
+		recipes[idx].CreatedAt = recipe.CreatedAt.Round(1 * time.Second)
+	}
+	projections, err := projectRecipes(recipes, fields)
+	if err != nil {
+		return nil, err
+	}
+	b, err := json.Marshal(recipePage{Items: projections, Next: next})
+	jsonStr := string(b)
+	return &jsonStr, err
+}
@@ -0,0 +1,150 @@
+package main
+
+import (
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+	model "github.com/tommzn/recipeboard-core/model"
+)
+
+// Test suite for recipe list pagination.
+type PaginationTestSuite struct {
+	suite.Suite
+}
+
+func TestPaginationTestSuite(t *testing.T) {
+	suite.Run(t, new(PaginationTestSuite))
+}
+
+// Test parsing limit and cursor from query params.
+func (suite *PaginationTestSuite) TestParsePageRequest() {
+
+	page, err := parsePageRequest(map[string]string{"recipetype": "baking"}, defaultSortOrder)
+	suite.Nil(err)
+	suite.Equal(pageRequest{limit: defaultPageSize}, page)
+	suite.False(page.paged)
+
+	page, err = parsePageRequest(map[string]string{"limit": "5"}, defaultSortOrder)
+	suite.Nil(err)
+	suite.Equal(5, page.limit)
+	suite.Nil(page.cursor)
+	suite.True(page.paged)
+
+	cursor := listCursor{Sort: defaultSortOrder, Key: "2021", Id: "123"}
+	page, err = parsePageRequest(map[string]string{"cursor": encodeCursor(cursor)}, defaultSortOrder)
+	suite.Nil(err)
+	suite.Equal(defaultPageSize, page.limit)
+	suite.Equal(cursor, *page.cursor)
+
+	invalidParams := []map[string]string{
+		{"limit": "0"},
+		{"limit": "101"},
+		{"limit": "xxx"},
+		{"cursor": "xxx"},
+		{"cursor": encodeCursor(listCursor{Sort: "title", Key: "Cake", Id: "123"})},
+	}
+	for _, queryParams := range invalidParams {
+		_, err := parsePageRequest(queryParams, defaultSortOrder)
+		suite.IsType(&badRequestError{}, err, queryParams)
+	}
+}
+
+// Test iterate over all pages of a recipe list.
+func (suite *PaginationTestSuite) TestPaginate() {
+
+	createdAt := time.Now()
+	recipes := []model.Recipe{}
+	for _, id := range []string{"e", "d", "c", "b", "a"} {
+		recipe := recipeForTest()
+		recipe.Id = id
+		recipe.CreatedAt = createdAt
+		recipes = append(recipes, recipe)
+	}
+	recipes[0].CreatedAt = createdAt.Add(-1 * time.Hour)
+	sortRecipes(recipes, defaultSortOrder)
+	suite.Equal("e", recipes[0].Id)
+	suite.Equal("a", recipes[1].Id)
+
+	page1, cursor1 := paginate(recipes, pageRequest{limit: 2}, defaultSortOrder)
+	suite.Equal([]string{"e", "a"}, recipeIds(page1))
+	suite.NotNil(cursor1)
+
+	// a new recipe inserted before current position doesn't shift next page.
+	inserted := recipeForTest()
+	inserted.Id = "0"
+	inserted.CreatedAt = createdAt.Add(-2 * time.Hour)
+	recipes = append(recipes, inserted)
+	sortRecipes(recipes, defaultSortOrder)
+
+	page2, cursor2 := paginate(recipes, pageRequest{limit: 2, cursor: cursor1}, defaultSortOrder)
+	suite.Equal([]string{"b", "c"}, recipeIds(page2))
+	suite.NotNil(cursor2)
+
+	page3, cursor3 := paginate(recipes, pageRequest{limit: 2, cursor: cursor2}, defaultSortOrder)
+	suite.Equal([]string{"d"}, recipeIds(page3))
+	suite.Nil(cursor3)
+}
+
+// Test parsing sort order from query params.
+func (suite *PaginationTestSuite) TestParseSortOrder() {
+
+	sortOrder, err := parseSortOrder(map[string]string{})
+	suite.Nil(err)
+	suite.Equal(defaultSortOrder, sortOrder)
+
+	sortOrder, err = parseSortOrder(map[string]string{"sort": "-CreatedAt"})
+	suite.Nil(err)
+	suite.Equal("-createdat", sortOrder)
+
+	_, err = parseSortOrder(map[string]string{"sort": "type"})
+	suite.IsType(&badRequestError{}, err)
+}
+
+// Test sorting recipes by title and in descending order.
+func (suite *PaginationTestSuite) TestSortRecipes() {
+
+	createdAt := time.Now()
+	recipes := []model.Recipe{
+		{Id: "a", Title: "cheesecake", CreatedAt: createdAt},
+		{Id: "b", Title: "Apple Pie", CreatedAt: createdAt.Add(time.Hour)},
+		{Id: "c", Title: "Brownies", CreatedAt: createdAt.Add(-1 * time.Hour)},
+		{Id: "d", Title: "Brownies", CreatedAt: createdAt},
+	}
+
+	sortRecipes(recipes, "title")
+	suite.Equal([]string{"b", "c", "d", "a"}, recipeIds(recipes))
+
+	sortRecipes(recipes, "-title")
+	suite.Equal([]string{"a", "d", "c", "b"}, recipeIds(recipes))
+
+	sortRecipes(recipes, "-createdat")
+	suite.Equal([]string{"b", "d", "a", "c"}, recipeIds(recipes))
+
+	page1, cursor1 := paginate(recipes, pageRequest{limit: 2}, "-createdat")
+	suite.Equal([]string{"b", "d"}, recipeIds(page1))
+	page2, cursor2 := paginate(recipes, pageRequest{limit: 2, cursor: cursor1}, "-createdat")
+	suite.Equal([]string{"a", "c"}, recipeIds(page2))
+	suite.Nil(cursor2)
+}
+
+// Test creating links to next page.
+func (suite *PaginationTestSuite) TestNextPageLink() {
+
+	cursor := listCursor{Sort: defaultSortOrder, Key: "2021", Id: "123"}
+	link := nextPageLink("/recipes", url.Values{"limit": {"2"}, "cursor": {"xxx"}}, cursor)
+	suite.Equal("/recipes?cursor="+encodeCursor(cursor)+"&limit=2", link)
+
+	link = nextPageLink("/recipes", url.Values{"limit": {"2"}, "recipetype": {"baking", "cooking"}}, cursor)
+	suite.Equal("/recipes?cursor="+encodeCursor(cursor)+"&limit=2&recipetype=baking&recipetype=cooking", link)
+}
+
+// recipeIds returns ids of all passed recipes.
+func recipeIds(recipes []model.Recipe) []string {
+	ids := []string{}
+	for _, recipe := range recipes {
+		ids = append(ids, recipe.Id)
+	}
+	return ids
+}
@@ -0,0 +1,338 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+const (
+
+	// contentTypeMergePatch is the content type of JSON merge patch documents, RFC 7396.
+	contentTypeMergePatch = "application/merge-patch+json"
+
+	// contentTypeJsonPatch is the content type of JSON patch documents, RFC 6902.
+	contentTypeJsonPatch = "application/json-patch+json"
+)
+
+// parseMergePatch decodes passed request body as JSON merge patch for a recipe.
+// Property names are converted to lower case, same as for request bodies to create or update recipes.
+func parseMergePatch(requestBody string) (map[string]interface{}, error) {
+
+	rawPatch := make(map[string]interface{})
+	if err := decodeJson(requestBody, &rawPatch); err != nil {
+		return nil, newBadRequestError("Request body is not a valid JSON merge patch", err)
+	}
+	patch := make(map[string]interface{})
+	for key, value := range rawPatch {
+		patch[strings.ToLower(key)] = value
+	}
+	return patch, nil
+}
+
+// parseJsonPatch decodes passed request body as JSON patch and validates all operations.
+// The first token of each JSON pointer is converted to lower case, because it refers to a recipe property.
+func parseJsonPatch(requestBody string) ([]jsonPatchOperation, error) {
+
+	operations := []jsonPatchOperation{}
+	if err := decodeJson(requestBody, &operations); err != nil {
+		return nil, newBadRequestError("Request body is not a valid JSON patch", err)
+	}
+
+	for idx, operation := range operations {
+		switch operation.Op {
+		case "add", "replace", "test":
+			if len(operation.Value) == 0 {
+				return nil, newBadRequestError(fmt.Sprintf("Missing value for operation %d: %s", idx, operation.Op), nil)
+			}
+		case "move", "copy":
+			if _, err := jsonPointerTokens(operation.From); err != nil {
+				return nil, newBadRequestError(fmt.Sprintf("Invalid from for operation %d: %s", idx, operation.Op), err)
+			}
+			operations[idx].From = lowerFirstPointerToken(operation.From)
+		case "remove":
+		default:
+			return nil, newBadRequestError(fmt.Sprintf("Unsupported operation %d: %s", idx, operation.Op), nil)
+		}
+		if _, err := jsonPointerTokens(operation.Path); err != nil {
+			return nil, newBadRequestError(fmt.Sprintf("Invalid path for operation %d: %s", idx, operation.Op), err)
+		}
+		operations[idx].Path = lowerFirstPointerToken(operation.Path)
+	}
+	return operations, nil
+}
+
+// applyMergePatch applies passed patch to given target as described in RFC 7396.
+func applyMergePatch(target, patch interface{}) interface{} {
+
+	patchObject, ok := patch.(map[string]interface{})
+	if !ok {
+		return patch
+	}
+	targetObject, ok := target.(map[string]interface{})
+	if !ok {
+		targetObject = make(map[string]interface{})
+	}
+	for name, value := range patchObject {
+		if value == nil {
+			delete(targetObject, name)
+		} else {
+			targetObject[name] = applyMergePatch(targetObject[name], value)
+		}
+	}
+	return targetObject
+}
+
+// applyJsonPatch applies all passed operations to given document as described in RFC 6902.
+// Operations which can't be applied to current document, e.g. a failed test, end up in a conflict error.
+func applyJsonPatch(document interface{}, operations []jsonPatchOperation) (interface{}, error) {
+
+	var err error
+	for idx, operation := range operations {
+		document, err = applyJsonPatchOperation(document, operation)
+		if err != nil {
+			return nil, newConflictError(fmt.Sprintf("Unable to apply operation %d: %s %s", idx, operation.Op, operation.Path), err)
+		}
+	}
+	return document, nil
+}
+
+// applyJsonPatchOperation applies a single JSON patch operation to passed document.
+func applyJsonPatchOperation(document interface{}, operation jsonPatchOperation) (interface{}, error) {
+
+	path, _ := jsonPointerTokens(operation.Path)
+	from, _ := jsonPointerTokens(operation.From)
+
+	var value interface{}
+	if len(operation.Value) > 0 {
+		if err := decodeJson(string(operation.Value), &value); err != nil {
+			return nil, err
+		}
+	}
+
+	switch operation.Op {
+	case "add":
+		return addJsonValue(document, path, value)
+	case "remove":
+		document, _, err := removeJsonValue(document, path)
+		return document, err
+	case "replace":
+		document, _, err := removeJsonValue(document, path)
+		if err != nil {
+			return nil, err
+		}
+		return addJsonValue(document, path, value)
+	case "move":
+		document, movedValue, err := removeJsonValue(document, from)
+		if err != nil {
+			return nil, err
+		}
+		return addJsonValue(document, path, movedValue)
+	case "copy":
+		copiedValue, err := getJsonValue(document, from)
+		if err != nil {
+			return nil, err
+		}
+		return addJsonValue(document, path, copyJsonValue(copiedValue))
+	case "test":
+		currentValue, err := getJsonValue(document, path)
+		if err != nil {
+			return nil, err
+		}
+		if !reflect.DeepEqual(currentValue, value) {
+			return nil, errors.New("Test failed.")
+		}
+		return document, nil
+	default:
+		return nil, fmt.Errorf("Unsupported operation: %s", operation.Op)
+	}
+}
+
+// getJsonValue returns the value at location defined by passed JSON pointer tokens.
+func getJsonValue(document interface{}, tokens []string) (interface{}, error) {
+
+	if len(tokens) == 0 {
+		return document, nil
+	}
+	switch container := document.(type) {
+	case map[string]interface{}:
+		value, ok := container[tokens[0]]
+		if !ok {
+			return nil, fmt.Errorf("Missing member: %s", tokens[0])
+		}
+		return getJsonValue(value, tokens[1:])
+	case []interface{}:
+		idx, err := jsonArrayIndex(tokens[0], len(container)-1)
+		if err != nil {
+			return nil, err
+		}
+		return getJsonValue(container[idx], tokens[1:])
+	default:
+		return nil, fmt.Errorf("Unable to resolve: %s", tokens[0])
+	}
+}
+
+// addJsonValue adds passed value at location defined by given JSON pointer tokens
+// and returns the modified document.
+func addJsonValue(document interface{}, tokens []string, value interface{}) (interface{}, error) {
+
+	if len(tokens) == 0 {
+		return value, nil
+	}
+	switch container := document.(type) {
+	case map[string]interface{}:
+		if len(tokens) == 1 {
+			container[tokens[0]] = value
+			return container, nil
+		}
+		child, ok := container[tokens[0]]
+		if !ok {
+			return nil, fmt.Errorf("Missing member: %s", tokens[0])
+		}
+		newChild, err := addJsonValue(child, tokens[1:], value)
+		if err != nil {
+			return nil, err
+		}
+		container[tokens[0]] = newChild
+		return container, nil
+	case []interface{}:
+		if len(tokens) == 1 {
+			idx := len(container)
+			if tokens[0] != "-" {
+				var err error
+				if idx, err = jsonArrayIndex(tokens[0], len(container)); err != nil {
+					return nil, err
+				}
+			}
+			container = append(container, nil)
+			copy(container[idx+1:], container[idx:])
+			container[idx] = value
+			return container, nil
+		}
+		idx, err := jsonArrayIndex(tokens[0], len(container)-1)
+		if err != nil {
+			return nil, err
+		}
+		newChild, err := addJsonValue(container[idx], tokens[1:], value)
+		if err != nil {
+			return nil, err
+		}
+		container[idx] = newChild
+		return container, nil
+	default:
+		return nil, fmt.Errorf("Unable to resolve: %s", tokens[0])
+	}
+}
+
+// removeJsonValue removes the value at location defined by passed JSON pointer tokens
+// and returns the modified document and the removed value.
+func removeJsonValue(document interface{}, tokens []string) (interface{}, interface{}, error) {
+
+	if len(tokens) == 0 {
+		return nil, nil, errors.New("Unable to remove the whole document.")
+	}
+	switch container := document.(type) {
+	case map[string]interface{}:
+		child, ok := container[tokens[0]]
+		if !ok {
+			return nil, nil, fmt.Errorf("Missing member: %s", tokens[0])
+		}
+		if len(tokens) == 1 {
+			delete(container, tokens[0])
+			return container, child, nil
+		}
+		newChild, removedValue, err := removeJsonValue(child, tokens[1:])
+		if err != nil {
+			return nil, nil, err
+		}
+		container[tokens[0]] = newChild
+		return container, removedValue, nil
+	case []interface{}:
+		idx, err := jsonArrayIndex(tokens[0], len(container)-1)
+		if err != nil {
+			return nil, nil, err
+		}
+		if len(tokens) == 1 {
+			removedValue := container[idx]
+			return append(container[:idx], container[idx+1:]...), removedValue, nil
+		}
+		newChild, removedValue, err := removeJsonValue(container[idx], tokens[1:])
+		if err != nil {
+			return nil, nil, err
+		}
+		container[idx] = newChild
+		return container, removedValue, nil
+	default:
+		return nil, nil, fmt.Errorf("Unable to resolve: %s", tokens[0])
+	}
+}
+
+// copyJsonValue returns a deep copy of passed value.
+func copyJsonValue(value interface{}) interface{} {
+
+	switch container := value.(type) {
+	case map[string]interface{}:
+		copiedObject := make(map[string]interface{})
+		for key, element := range container {
+			copiedObject[key] = copyJsonValue(element)
+		}
+		return copiedObject
+	case []interface{}:
+		copiedArray := make([]interface{}, len(container))
+		for idx, element := range container {
+			copiedArray[idx] = copyJsonValue(element)
+		}
+		return copiedArray
+	default:
+		return value
+	}
+}
+
+// jsonPointerTokens splits passed JSON pointer into unescaped reference tokens, RFC 6901.
+func jsonPointerTokens(pointer string) ([]string, error) {
+
+	if pointer == "" {
+		return []string{}, nil
+	}
+	if !strings.HasPrefix(pointer, "/") {
+		return nil, fmt.Errorf("Invalid JSON pointer: %s", pointer)
+	}
+	tokens := strings.Split(pointer[1:], "/")
+	for idx, token := range tokens {
+		tokens[idx] = strings.Replace(strings.Replace(token, "~1", "/", -1), "~0", "~", -1)
+	}
+	return tokens, nil
+}
+
+// lowerFirstPointerToken converts the first token of passed JSON pointer to lower case.
+func lowerFirstPointerToken(pointer string) string {
+
+	if !strings.HasPrefix(pointer, "/") {
+		return pointer
+	}
+	tokens := strings.SplitN(pointer[1:], "/", 2)
+	tokens[0] = strings.ToLower(tokens[0])
+	return "/" + strings.Join(tokens, "/")
+}
+
+// jsonArrayIndex converts passed token to an array index and checks that it doesn't exceed given max index.
+func jsonArrayIndex(token string, maxIndex int) (int, error) {
+
+	idx, err := strconv.Atoi(token)
+	if err != nil || idx < 0 || idx > maxIndex || (len(token) > 1 && token[0] == '0') {
+		return 0, fmt.Errorf("Invalid array index: %s", token)
+	}
+	return idx, nil
+}
+
+// decodeJson decodes passed JSON string into given value. Numbers are decoded as json.Number.
+func decodeJson(jsonStr string, value interface{}) error {
+
+	decoder := json.NewDecoder(bytes.NewBufferString(jsonStr))
+	decoder.UseNumber()
+	return decoder.Decode(value)
+}
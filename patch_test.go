@@ -0,0 +1,91 @@
+package main
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+)
+
+// Test suite for JSON merge patch and JSON patch.
+type PatchTestSuite struct {
+	suite.Suite
+}
+
+func TestPatchTestSuite(t *testing.T) {
+	suite.Run(t, new(PatchTestSuite))
+}
+
+// Test applying JSON merge patches.
+func (suite *PatchTestSuite) TestApplyMergePatch() {
+
+	patch, err := parseMergePatch(`{"Title": "Pie", "ingredients": null, "tags": {"season": "winter"}}`)
+	suite.Nil(err)
+
+	document := map[string]interface{}{"title": "Cake", "ingredients": "Flour", "description": "Bake."}
+	patched := applyMergePatch(document, patch)
+	suite.Equal(map[string]interface{}{
+		"title":       "Pie",
+		"description": "Bake.",
+		"tags":        map[string]interface{}{"season": "winter"},
+	}, patched)
+
+	_, err = parseMergePatch(`["title"]`)
+	suite.IsType(&badRequestError{}, err)
+}
+
+// Test applying JSON patch operations.
+func (suite *PatchTestSuite) TestApplyJsonPatch() {
+
+	operations, err := parseJsonPatch(`[
+		{"op": "add", "path": "/Tags", "value": ["winter"]},
+		{"op": "add", "path": "/tags/0", "value": "soup"},
+		{"op": "add", "path": "/tags/-", "value": 1},
+		{"op": "replace", "path": "/title", "value": "Leek Soup"},
+		{"op": "move", "from": "/ingredients", "path": "/notes"},
+		{"op": "copy", "from": "/title", "path": "/a~1b"},
+		{"op": "remove", "path": "/tags/1"},
+		{"op": "test", "path": "/tags", "value": ["soup", 1]}
+	]`)
+	suite.Nil(err)
+
+	document := map[string]interface{}{"title": "Soup", "ingredients": "Leek"}
+	patched, err := applyJsonPatch(document, operations)
+	suite.Nil(err)
+	suite.Equal(map[string]interface{}{
+		"title": "Leek Soup",
+		"notes": "Leek",
+		"a/b":   "Leek Soup",
+		"tags":  []interface{}{"soup", json.Number("1")},
+	}, patched)
+}
+
+// Test JSON patches which can't be parsed or applied.
+func (suite *PatchTestSuite) TestInvalidJsonPatch() {
+
+	invalidPatches := []string{
+		`{"op": "add"}`,
+		`[{"op": "add", "path": "/title"}]`,
+		`[{"op": "move", "from": "title", "path": "/xxx"}]`,
+		`[{"op": "remove", "path": "title"}]`,
+		`[{"op": "rename", "path": "/title"}]`,
+	}
+	for _, invalidPatch := range invalidPatches {
+		_, err := parseJsonPatch(invalidPatch)
+		suite.IsType(&badRequestError{}, err, invalidPatch)
+	}
+
+	conflictingPatches := []string{
+		`[{"op": "remove", "path": "/xxx"}]`,
+		`[{"op": "replace", "path": "/xxx", "value": "yyy"}]`,
+		`[{"op": "add", "path": "/xxx/yyy", "value": "zzz"}]`,
+		`[{"op": "test", "path": "/title", "value": "Cake"}]`,
+		`[{"op": "add", "path": "/title/0", "value": "Cake"}]`,
+	}
+	for _, conflictingPatch := range conflictingPatches {
+		operations, err := parseJsonPatch(conflictingPatch)
+		suite.Nil(err)
+		_, err = applyJsonPatch(map[string]interface{}{"title": "Soup"}, operations)
+		suite.IsType(&conflictError{}, err, conflictingPatch)
+	}
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	model "github.com/tommzn/recipeboard-core/model"
+)
+
+// recipeFields contains names of all recipe fields which can be selected by fields query param.
+var recipeFields = []string{"id", "type", "title", "ingredients", "description", "createdat"}
+
+// derivedRecipeFields contains names of fields which are derived from other recipe fields.
+// They're only returned in responses and can be selected by fields query param as well.
+var derivedRecipeFields = []string{strings.ToLower(ingredientsParsedField)}
+
+// parseFields extracts the list of requested recipe fields from passed query params.
+// It returns nil if all fields should be returned.
+func parseFields(queryParams map[string]string) ([]string, error) {
+
+	fieldsParam, ok := queryParams["fields"]
+	if !ok {
+		return nil, nil
+	}
+
+	supportedFields := append(append([]string{}, recipeFields...), derivedRecipeFields...)
+	fields := []string{}
+	for _, field := range strings.Split(fieldsParam, ",") {
+		field = strings.ToLower(strings.TrimSpace(field))
+		if !containsString(supportedFields, field) {
+			return nil, newBadRequestError(fmt.Sprintf("Unsupported field: %s, supported: %s", field, strings.Join(supportedFields, ", ")), nil)
+		}
+		if !containsString(fields, field) {
+			fields = append(fields, field)
+		}
+	}
+	return fields, nil
+}
+
+// projectRecipe returns JSON values of passed recipe, including parsed ingredients, reduced to given fields.
+// All values are returned if no fields are passed.
+func projectRecipe(recipe model.Recipe, fields []string) (map[string]json.RawMessage, error) {
+	return projectRecipeWithIngredients(recipe, parseIngredients(recipe.Ingredients), fields)
+}
+
+// projectRecipeWithIngredients returns JSON values of passed recipe with given parsed ingredients, reduced to given fields.
+func projectRecipeWithIngredients(recipe model.Recipe, ingredients []ingredient, fields []string) (map[string]json.RawMessage, error) {
+
+	b, err := json.Marshal(recipe)
+	if err != nil {
+		return nil, err
+	}
+	values := make(map[string]json.RawMessage)
+	if err := json.Unmarshal(b, &values); err != nil {
+		return nil, err
+	}
+	if values[ingredientsParsedField], err = json.Marshal(ingredients); err != nil {
+		return nil, err
+	}
+	if fields == nil {
+		return values, nil
+	}
+	for name := range values {
+		if !containsString(fields, strings.ToLower(name)) {
+			delete(values, name)
+		}
+	}
+	return values, nil
+}
+
+// projectRecipes returns JSON values of all passed recipes, reduced to given fields.
+func projectRecipes(recipes []model.Recipe, fields []string) ([]map[string]json.RawMessage, error) {
+
+	projections := []map[string]json.RawMessage{}
+	for _, recipe := range recipes {
+		projection, err := projectRecipe(recipe, fields)
+		if err != nil {
+			return nil, err
+		}
+		projections = append(projections, projection)
+	}
+	return projections, nil
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+	model "github.com/tommzn/recipeboard-core/model"
+)
+
+// Test suite for recipe field projection.
+type ProjectionTestSuite struct {
+	suite.Suite
+}
+
+func TestProjectionTestSuite(t *testing.T) {
+	suite.Run(t, new(ProjectionTestSuite))
+}
+
+// Test parsing requested fields from query params.
+func (suite *ProjectionTestSuite) TestParseFields() {
+
+	fields, err := parseFields(map[string]string{})
+	suite.Nil(err)
+	suite.Nil(fields)
+
+	fields, err = parseFields(map[string]string{"fields": "id, Title,title,createdat"})
+	suite.Nil(err)
+	suite.Equal([]string{"id", "title", "createdat"}, fields)
+
+	for _, fieldsParam := range []string{"", "id,", "id,calories"} {
+		_, err := parseFields(map[string]string{"fields": fieldsParam})
+		suite.IsType(&badRequestError{}, err, fieldsParam)
+	}
+}
+
+// Test reducing recipes to requested fields.
+func (suite *ProjectionTestSuite) TestProjectRecipe() {
+
+	recipe := recipeForTest()
+	values, err := projectRecipe(recipe, nil)
+	suite.Nil(err)
+	suite.Len(values, 7)
+	suite.Contains(string(values[ingredientsParsedField]), `"name":"Mehl"`)
+
+	values, err = projectRecipe(recipe, []string{"id", "type"})
+	suite.Nil(err)
+	suite.Len(values, 2)
+	suite.Equal(`"`+recipe.Id+`"`, string(values["Id"]))
+	suite.Equal(json.RawMessage("1"), values["Type"])
+
+	values, err = projectRecipe(recipe, []string{"ingredientsparsed"})
+	suite.Nil(err)
+	suite.Len(values, 1)
+
+	projections, err := projectRecipes([]model.Recipe{recipe, recipe}, []string{"title"})
+	suite.Nil(err)
+	suite.Len(projections, 2)
+	suite.Len(projections[1], 1)
+}
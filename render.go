@@ -0,0 +1,248 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"regexp"
+	"strings"
+	"time"
+
+	model "github.com/tommzn/recipeboard-core/model"
+	yaml "gopkg.in/yaml.v2"
+)
+
+// stepNumberPattern matches numbering at the beginning of a step, e.g. "1." or "2)".
+var stepNumberPattern = regexp.MustCompile(`^\d+[.)]\s*`)
+
+// markdownSpecialChars are escaped in user content of Markdown renderings.
+var markdownSpecialChars = strings.NewReplacer(
+	"\\", "\\\\", "`", "\\`", "*", "\\*", "_", "\\_", "[", "\\[", "]", "\\]", "#", "\\#",
+	"&", "&amp;", "<", "&lt;", ">", "&gt;",
+)
+
+// printTemplate is used to render a recipe as a self-contained HTML page optimized for printing.
+// All values are escaped by html/template.
+var printTemplate = template.Must(template.New("print").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>{{.Title}}</title>
+<style>
+body { font-family: Georgia, serif; max-width: 42em; margin: 2em auto; padding: 0 1em; color: #222; line-height: 1.5; }
+h1 { margin-bottom: 0.2em; }
+h2 { border-bottom: 1px solid #ccc; padding-bottom: 0.2em; }
+.badge { display: inline-block; padding: 0.1em 0.6em; border: 1px solid #888; border-radius: 1em; font-family: sans-serif; font-size: 0.8em; text-transform: uppercase; letter-spacing: 0.05em; }
+.badge-cooking { background: #fde8d7; }
+.badge-baking { background: #f7f0d2; }
+ul.ingredients li { margin: 0.2em 0; }
+ol.steps li { margin: 0.5em 0; }
+@media print { body { margin: 0; max-width: none; font-size: 11pt; } h2 { page-break-after: avoid; } li { page-break-inside: avoid; } }
+</style>
+</head>
+<body>
+<article class="recipe">
+<h1>{{.Title}}</h1>
+<span class="badge badge-{{.Type}}">{{.TypeLabel}}</span>
+{{- if .Ingredients}}
+<h2>Ingredients</h2>
+<ul class="ingredients">
+{{- range .Ingredients}}
+<li>{{.}}</li>
+{{- end}}
+</ul>
+{{- end}}
+{{- if .Steps}}
+<h2>Steps</h2>
+<ol class="steps">
+{{- range .Steps}}
+<li>{{.}}</li>
+{{- end}}
+</ol>
+{{- end}}
+</article>
+</body>
+</html>
+`))
+
+// renderRecipe returns passed recipe in given media type. JSON and YAML renderings can be projected
+// to passed fields, JSON-LD, Markdown, HTML and plain text renderings always contain the entire recipe.
+func renderRecipe(recipe model.Recipe, fields []string, mediaType string) (*string, error) {
+
+	switch mediaType {
+	case yamlMediaType:
+		body, err := marshalRecipe(recipe, fields)
+		if err != nil {
+			return nil, err
+		}
+		return jsonToYaml(*body)
+	case jsonLdMediaType:
+		return renderRecipeJsonLd(recipe)
+	case markdownMediaType:
+		return renderRecipeMarkdown(recipe), nil
+	case htmlMediaType:
+		return renderRecipeHtml(recipe)
+	case plainTextMediaType:
+		return renderRecipeText(recipe), nil
+	default:
+		return marshalRecipe(recipe, fields)
+	}
+}
+
+// renderAdjustedRecipe scales passed recipe by given scaling and converts it to passed unit system, if they're defined.
+// Adjusted recipe is rendered in given media type. JSON and YAML responses contain all adjusted ingredients
+// in ingredientsParsed, ingredients without quantity are flagged as unscaled if a recipe is scaled.
+func renderAdjustedRecipe(recipe model.Recipe, scaling *recipeScaling, units string, fields []string, mediaType string) (*string, error) {
+
+	ingredients := parseIngredients(recipe.Ingredients)
+	if scaling != nil {
+		factor, err := scaling.scaleFactor(recipe)
+		if err != nil {
+			return nil, err
+		}
+		recipe, ingredients = scaleRecipe(recipe, ingredients, factor)
+	}
+	if units != "" {
+		recipe, ingredients = convertRecipe(recipe, ingredients, units)
+	}
+
+	switch mediaType {
+	case jsonLdMediaType, markdownMediaType, htmlMediaType, plainTextMediaType:
+		return renderRecipe(recipe, fields, mediaType)
+	case yamlMediaType:
+		body, err := marshalAdjustedRecipe(recipe, ingredients, fields)
+		if err != nil {
+			return nil, err
+		}
+		return jsonToYaml(*body)
+	default:
+		return marshalAdjustedRecipe(recipe, ingredients, fields)
+	}
+}
+
+// marshalAdjustedRecipe returns JSON string of passed recipe with given ingredients, reduced to passed fields.
+func marshalAdjustedRecipe(recipe model.Recipe, ingredients []ingredient, fields []string) (*string, error) {
+
+	recipe.CreatedAt = recipe.CreatedAt.Round(1 * time.Second)
+	projection, err := projectRecipeWithIngredients(recipe, ingredients, fields)
+	if err != nil {
+		return nil, err
+	}
+	b, err := json.Marshal(projection)
+	jsonStr := string(b)
+	return &jsonStr, err
+}
+
+// jsonToYaml converts passed JSON document to YAML. Order of all keys is kept.
+func jsonToYaml(document string) (*string, error) {
+
+	var value yaml.MapSlice
+	if err := yaml.Unmarshal([]byte(document), &value); err != nil {
+		return nil, err
+	}
+	content, err := yaml.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	body := string(content)
+	return &body, nil
+}
+
+// renderRecipeMarkdown returns a Markdown document with title, type badge, a list of ingredients
+// and numbered steps of passed recipe. Markdown syntax and HTML in user content is escaped.
+func renderRecipeMarkdown(recipe model.Recipe) *string {
+
+	markdown := strings.Builder{}
+	markdown.WriteString(fmt.Sprintf("# %s\n\n", escapeMarkdown(recipe.Title)))
+	markdown.WriteString(fmt.Sprintf("`%s`\n", recipeTypeLabel(recipe.Type)))
+	if ingredients := recipeLines(recipe.Ingredients); len(ingredients) > 0 {
+		markdown.WriteString("\n## Ingredients\n\n")
+		for _, ingredient := range ingredients {
+			markdown.WriteString("- " + escapeMarkdown(ingredient) + "\n")
+		}
+	}
+	if steps := recipeSteps(recipe.Description); len(steps) > 0 {
+		markdown.WriteString("\n## Steps\n\n")
+		for idx, step := range steps {
+			markdown.WriteString(fmt.Sprintf("%d. %s\n", idx+1, escapeMarkdown(step)))
+		}
+	}
+	body := markdown.String()
+	return &body
+}
+
+// renderRecipeHtml returns a self-contained HTML page of passed recipe, which can be printed.
+func renderRecipeHtml(recipe model.Recipe) (*string, error) {
+
+	page := bytes.Buffer{}
+	err := printTemplate.Execute(&page, map[string]interface{}{
+		"Title":       recipe.Title,
+		"Type":        fromRecipeType(recipe.Type),
+		"TypeLabel":   recipeTypeLabel(recipe.Type),
+		"Ingredients": recipeLines(recipe.Ingredients),
+		"Steps":       recipeSteps(recipe.Description),
+	})
+	if err != nil {
+		return nil, err
+	}
+	body := page.String()
+	return &body, nil
+}
+
+// renderRecipeText returns a plain text rendering of passed recipe.
+func renderRecipeText(recipe model.Recipe) *string {
+
+	text := strings.Builder{}
+	text.WriteString(recipe.Title + "\n")
+	text.WriteString(fmt.Sprintf("Type: %s\n", recipeTypeLabel(recipe.Type)))
+	if ingredients := recipeLines(recipe.Ingredients); len(ingredients) > 0 {
+		text.WriteString("\nIngredients:\n")
+		for _, ingredient := range ingredients {
+			text.WriteString("  " + ingredient + "\n")
+		}
+	}
+	if steps := recipeSteps(recipe.Description); len(steps) > 0 {
+		text.WriteString("\nSteps:\n")
+		for idx, step := range steps {
+			text.WriteString(fmt.Sprintf("  %d. %s\n", idx+1, step))
+		}
+	}
+	body := text.String()
+	return &body
+}
+
+// recipeLines splits passed text into lines and skips all empty lines.
+func recipeLines(text string) []string {
+
+	lines := []string{}
+	for _, line := range strings.Split(text, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
+
+// recipeSteps splits passed description into steps, one per line. Existing numbering is removed.
+func recipeSteps(description string) []string {
+
+	steps := []string{}
+	for _, line := range recipeLines(description) {
+		if step := strings.TrimSpace(stepNumberPattern.ReplaceAllString(line, "")); step != "" {
+			steps = append(steps, step)
+		}
+	}
+	return steps
+}
+
+// recipeTypeLabel returns a human readable name of passed recipe type.
+func recipeTypeLabel(recipeType model.RecipeType) string {
+	return strings.Title(fromRecipeType(recipeType))
+}
+
+// escapeMarkdown escapes Markdown syntax and HTML in passed text.
+func escapeMarkdown(text string) string {
+	return markdownSpecialChars.Replace(text)
+}
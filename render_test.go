@@ -0,0 +1,96 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+	yaml "gopkg.in/yaml.v2"
+)
+
+// Test suite for recipe renderings.
+type RenderTestSuite struct {
+	suite.Suite
+}
+
+func TestRenderTestSuite(t *testing.T) {
+	suite.Run(t, new(RenderTestSuite))
+}
+
+// Test rendering a recipe as YAML.
+func (suite *RenderTestSuite) TestRenderYaml() {
+
+	recipe := recipeForTest()
+	body, err := renderRecipe(recipe, nil, yamlMediaType)
+	suite.Nil(err)
+	values := make(map[string]interface{})
+	suite.Nil(yaml.Unmarshal([]byte(*body), &values))
+	suite.Equal(recipe.Id, values["Id"])
+	suite.Equal(recipe.Title, values["Title"])
+	suite.Equal(recipe.Ingredients, values["Ingredients"])
+
+	body, err = renderRecipe(recipe, []string{"title"}, yamlMediaType)
+	suite.Nil(err)
+	suite.Equal("Title: Bake a Cake\n", *body)
+}
+
+// Test rendering a recipe as Markdown.
+func (suite *RenderTestSuite) TestRenderMarkdown() {
+
+	body, err := renderRecipe(recipeForTest(), nil, markdownMediaType)
+	suite.Nil(err)
+	suite.Equal("# Bake a Cake\n\n`Baking`\n\n## Ingredients\n\n- 100g Mehl\n- 100g Zucker\n- 50ml Wasser\n\n## Steps\n\n1. Einrühren.\n2. Backen.\n3. Fertig!\n", *body)
+
+	recipe := recipeForTest()
+	recipe.Title = "<b>Cake</b> *best*"
+	recipe.Ingredients = "[link](http://example.com)"
+	recipe.Description = "1. Mix\n2) Bake"
+	body, err = renderRecipe(recipe, nil, markdownMediaType)
+	suite.Nil(err)
+	suite.Contains(*body, "# &lt;b&gt;Cake&lt;/b&gt; \\*best\\*\n")
+	suite.Contains(*body, "- \\[link\\](http://example.com)\n")
+	suite.Contains(*body, "1. Mix\n2. Bake\n")
+}
+
+// Test rendering a recipe as printable HTML page.
+func (suite *RenderTestSuite) TestRenderHtml() {
+
+	recipe := recipeForTest()
+	recipe.Title = "Cake <script>alert(1)</script>"
+	recipe.Ingredients = "100g Mehl\n2 Eier & Milch"
+	body, err := renderRecipe(recipe, nil, htmlMediaType)
+	suite.Nil(err)
+	suite.True(strings.HasPrefix(*body, "<!DOCTYPE html>"))
+	suite.NotContains(*body, "<script>")
+	suite.Contains(*body, "<title>Cake &lt;script&gt;alert(1)&lt;/script&gt;</title>")
+	suite.Contains(*body, `<span class="badge badge-baking">Baking</span>`)
+	suite.Contains(*body, "<li>2 Eier &amp; Milch</li>")
+	suite.Contains(*body, "<ol class=\"steps\">\n<li>Einrühren.</li>\n<li>Backen.</li>\n<li>Fertig!</li>\n</ol>")
+
+	recipe.Description = ""
+	body, err = renderRecipe(recipe, nil, htmlMediaType)
+	suite.Nil(err)
+	suite.NotContains(*body, "Steps")
+}
+
+// Test rendering a recipe as plain text.
+func (suite *RenderTestSuite) TestRenderText() {
+
+	recipe := recipeForTest()
+	recipe.Description = ""
+	body, err := renderRecipe(recipe, []string{"title"}, plainTextMediaType)
+	suite.Nil(err)
+	suite.Equal("Bake a Cake\nType: Baking\n\nIngredients:\n  100g Mehl\n  100g Zucker\n  50ml Wasser\n", *body)
+}
+
+// Test splitting descriptions into steps.
+func (suite *RenderTestSuite) TestRecipeSteps() {
+	suite.Equal([]string{"Mix", "Bake", "Serve"}, recipeSteps("1. Mix\n\n2) Bake\nServe"))
+	suite.Len(recipeSteps(" "), 0)
+}
+
+// Test splitting texts into lines.
+func (suite *RenderTestSuite) TestRecipeLines() {
+	suite.Equal([]string{"a", "b"}, recipeLines("  a\n\n b \n"))
+	suite.Len(recipeLines(""), 0)
+}
@@ -0,0 +1,356 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	log "github.com/tommzn/go-log"
+	core "github.com/tommzn/recipeboard-core"
+	model "github.com/tommzn/recipeboard-core/model"
+)
+
+const (
+
+	// revisionCollection is the document store collection for recipe revisions.
+	revisionCollection = "revisions"
+
+	// revisionHeadCollection is the document store collection for the latest revision number of each recipe.
+	revisionHeadCollection = "revisionheads"
+
+	// revisionCreated is the action of revisions written on creating a recipe.
+	revisionCreated = "created"
+
+	// revisionUpdated is the action of revisions written on updating a recipe.
+	revisionUpdated = "updated"
+
+	// revisionDeleted is the action of revisions written on deleting a recipe.
+	revisionDeleted = "deleted"
+
+	// anonymousAuthor is used as author of revisions for requests without an identity.
+	anonymousAuthor = "anonymous"
+
+	// maxRevisionAttempts is the max number of revision numbers tried if revisions are written concurrently.
+	maxRevisionAttempts = 10
+)
+
+// newDocumentRevisionLog returns a revision log which persists revisions in passed document store.
+// Each revision is kept in it's own document, so the history of a recipe isn't limited by the max document size.
+func newDocumentRevisionLog(store documentStore) revisionLog {
+	return &documentRevisionLog{store: store}
+}
+
+// append adds passed revision to the revisions of it's recipe. Revisions are numbered consecutively, starting with 1.
+// Each revision is created only if there's no revision with the same number, so concurrent writers, e.g. other
+// Lambda instances, never overwrite a revision. If a revision number is already in use the next one is tried.
+// Latest revision number of a recipe is updated afterwards. It may lag behind if it's updated concurrently,
+// so it's used as starting point only.
+func (revisions *documentRevisionLog) append(revision recipeRevision) error {
+
+	revisions.mutex.Lock()
+	defer revisions.mutex.Unlock()
+
+	head, err := revisions.head(revision.Recipe.Id)
+	if err != nil {
+		return err
+	}
+	revision.Rev = head.Latest
+	for attempt := 1; ; attempt++ {
+		revision.Rev++
+		err := revisions.store.create(revisionCollection, revisionDocumentId(revision.Recipe.Id, revision.Rev), revision)
+		if err == nil {
+			break
+		}
+		if _, ok := err.(*conflictError); !ok || attempt >= maxRevisionAttempts {
+			return err
+		}
+	}
+	head.Latest = revision.Rev
+	return revisions.store.set(revisionHeadCollection, head.Id, head)
+}
+
+// list returns all revisions of the recipe with passed id, oldest first. Revisions after the latest revision
+// number are listed as well, in case it lags behind because of concurrent writes.
+func (revisions *documentRevisionLog) list(recipeId string) ([]recipeRevision, error) {
+
+	head, err := revisions.head(recipeId)
+	if err != nil {
+		return nil, err
+	}
+	history := []recipeRevision{}
+	for rev := 1; ; rev++ {
+		revision, err := revisions.get(recipeId, rev)
+		if err != nil {
+			if _, ok := err.(*notFoundError); ok && rev > head.Latest {
+				return history, nil
+			}
+			return nil, err
+		}
+		history = append(history, *revision)
+	}
+}
+
+// get returns a single revision of the recipe with passed id.
+func (revisions *documentRevisionLog) get(recipeId string, rev int) (*recipeRevision, error) {
+
+	revision := recipeRevision{}
+	if err := revisions.store.get(revisionCollection, revisionDocumentId(recipeId, rev), &revision); err != nil {
+		if _, ok := err.(*notFoundError); ok {
+			return nil, newNotFoundError(fmt.Sprintf("Revision %d of recipe %s not found.", rev, recipeId), nil)
+		}
+		return nil, err
+	}
+	return &revision, nil
+}
+
+// head loads the latest revision number of the recipe with passed id. It's zero if there're no revisions.
+func (revisions *documentRevisionLog) head(recipeId string) (revisionHead, error) {
+
+	head := revisionHead{Id: recipeId}
+	if err := revisions.store.get(revisionHeadCollection, recipeId, &head); err != nil {
+		if _, ok := err.(*notFoundError); ok {
+			return head, nil
+		}
+		return head, err
+	}
+	return head, nil
+}
+
+// revisionDocumentId returns the document id of a revision, combined from recipe id and revision number.
+func revisionDocumentId(recipeId string, rev int) string {
+	return fmt.Sprintf("%s/%d", recipeId, rev)
+}
+
+// newRevisioningRecipeService wraps passed recipe service to write a revision for each created, updated
+// or deleted recipe. It's created for each request, passed author is used for all revisions.
+func newRevisioningRecipeService(recipeService core.RecipeService, revisions revisionLog, author string, logger log.Logger) core.RecipeService {
+	return &revisioningRecipeService{
+		RecipeService: recipeService,
+		revisions:     revisions,
+		author:        author,
+		logger:        logger,
+	}
+}
+
+// Create a new recipe and write it's first revision.
+// Revision errors are only logged, because the recipe itself has been persisted successfully.
+func (service *revisioningRecipeService) Create(recipe model.Recipe) (model.Recipe, error) {
+
+	newRecipe, err := service.RecipeService.Create(recipe)
+	if err == nil {
+		service.appendRevision(revisionCreated, newRecipe)
+	}
+	return newRecipe, err
+}
+
+// Update an existing recipe and write a revision with it's new values.
+func (service *revisioningRecipeService) Update(recipe model.Recipe) error {
+
+	err := service.RecipeService.Update(recipe)
+	if err == nil {
+		service.appendRevision(revisionUpdated, recipe)
+	}
+	return err
+}
+
+// Delete passed recipe and write a revision with it's last values.
+func (service *revisioningRecipeService) Delete(recipe model.Recipe) error {
+
+	err := service.RecipeService.Delete(recipe)
+	if err == nil {
+		service.appendRevision(revisionDeleted, recipe)
+	}
+	return err
+}
+
+// appendRevision writes a revision for passed action and recipe. It's called after a recipe has been changed,
+// so errors are only logged and the revision is missing in the history of the recipe.
+func (service *revisioningRecipeService) appendRevision(action string, recipe model.Recipe) {
+
+	author := anonymousAuthor
+	if service.author != "" {
+		author = service.author
+	}
+	revision := recipeRevision{Action: action, Author: author, CreatedAt: time.Now(), Recipe: recipe}
+	if err := service.revisions.append(revision); err != nil && service.logger != nil {
+		service.logger.Errorf("Unable to write revision for recipe %s: %s", recipe.Id, err)
+	}
+}
+
+// requestAuthor returns the identity of the caller of passed request. Principal and user name from an authorizer
+// are preferred, followed by the IAM or Cognito identity. It's empty for unauthenticated requests.
+func requestAuthor(request events.APIGatewayProxyRequest) string {
+
+	authorizer := request.RequestContext.Authorizer
+	if claims, ok := authorizer["claims"].(map[string]interface{}); ok {
+		for _, claim := range []string{"cognito:username", "email", "sub"} {
+			if value, ok := claims[claim].(string); ok && value != "" {
+				return value
+			}
+		}
+	}
+	if principalId, ok := authorizer["principalId"].(string); ok && principalId != "" {
+		return principalId
+	}
+	identity := request.RequestContext.Identity
+	for _, value := range []string{identity.User, identity.CognitoIdentityID, identity.Caller} {
+		if value != "" {
+			return value
+		}
+	}
+	return ""
+}
+
+// parseRequest will extract the recipe id from path.
+func (handler *apiGatewayRevisionListRequestHandler) parseRequest(request events.APIGatewayProxyRequest) error {
+
+	if recipeId, ok := request.PathParameters["id"]; ok {
+		handler.recipeId = &recipeId
+		return nil
+	}
+	return errors.New("Missing recipe id.")
+}
+
+// handle requests to list all revisions of a recipe, oldest first. Listed revisions doesn't contain recipe values.
+// If there're no revisions, an empty list is returned for existing recipes and a not found error otherwise.
+func (handler *apiGatewayRevisionListRequestHandler) handle() (*string, error) {
+
+	if handler.recipeId == nil {
+		return nil, errors.New("Missing recipe id.")
+	}
+	revisions, err := handler.revisions.list(*handler.recipeId)
+	if err != nil {
+		return nil, err
+	}
+	if len(revisions) == 0 {
+		if _, err := handler.recipeService.Get(*handler.recipeId); err != nil {
+			return nil, fromServiceError(err)
+		}
+	}
+
+	response := revisionListResponse{Items: []revisionResult{}}
+	for _, revision := range revisions {
+		response.Items = append(response.Items, newRevisionResult(revision))
+	}
+	b, err := json.Marshal(response)
+	jsonStr := string(b)
+	return &jsonStr, err
+}
+
+// parseRequest will extract recipe id and revision number from path.
+func (handler *apiGatewayRevisionGetRequestHandler) parseRequest(request events.APIGatewayProxyRequest) error {
+
+	recipeId, ok := request.PathParameters["id"]
+	if !ok {
+		return errors.New("Missing recipe id.")
+	}
+	handler.recipeId = &recipeId
+
+	rev, err := parseRevision(request.PathParameters["rev"])
+	if err != nil {
+		return err
+	}
+	handler.rev = rev
+	return nil
+}
+
+// handle requests to get a single revision of a recipe, including all recipe values of this revision.
+func (handler *apiGatewayRevisionGetRequestHandler) handle() (*string, error) {
+
+	if handler.recipeId == nil {
+		return nil, errors.New("Missing recipe id.")
+	}
+	revision, err := handler.revisions.get(*handler.recipeId, handler.rev)
+	if err != nil {
+		return nil, err
+	}
+	result := newRevisionResult(*revision)
+	body, err := marshalRecipe(revision.Recipe, nil)
+	if err != nil {
+		return nil, err
+	}
+	result.Recipe = json.RawMessage(*body)
+	b, err := json.Marshal(result)
+	jsonStr := string(b)
+	return &jsonStr, err
+}
+
+// parseRequest will extract recipe id from path, revision number from rev query param and conditional headers.
+func (handler *apiGatewayRollbackRequestHandler) parseRequest(request events.APIGatewayProxyRequest) error {
+
+	recipeId, ok := request.PathParameters["id"]
+	if !ok {
+		return errors.New("Missing recipe id.")
+	}
+	handler.recipeId = &recipeId
+	handler.conditions.parse(request)
+
+	rev, err := parseRevision(request.QueryStringParameters["rev"])
+	if err != nil {
+		return err
+	}
+	handler.rev = rev
+	return nil
+}
+
+// handle rollback requests by updating a recipe with the values of an older revision. Rollback itself
+// is written as a new revision. Deleted recipes have to be restored from trash before.
+// If an If-Match header is passed, current recipe has to match one of it's entity tags.
+func (handler *apiGatewayRollbackRequestHandler) handle() (*string, error) {
+
+	if handler.recipeId == nil {
+		return nil, errors.New("Missing recipe id.")
+	}
+	revision, err := handler.revisions.get(*handler.recipeId, handler.rev)
+	if err != nil {
+		return nil, err
+	}
+	currentRecipe, err := handler.recipeService.Get(*handler.recipeId)
+	if err != nil {
+		return nil, fromServiceError(err)
+	}
+	if err := handler.conditions.checkIfMatch(*currentRecipe); err != nil {
+		return nil, err
+	}
+
+	recipe := revision.Recipe
+	recipe.Id = *handler.recipeId
+	if err := handler.recipeService.Update(recipe); err != nil {
+		return nil, fromServiceError(err)
+	}
+	handler.logger.Infof("Recipe %s rolled back to revision %d.", recipe.Id, handler.rev)
+	body, etag, err := marshalRecipeWithETag(recipe, nil)
+	handler.etag = etag
+	return body, err
+}
+
+// responseHeaders returns the ETag of the rolled back recipe.
+func (handler *apiGatewayRollbackRequestHandler) responseHeaders() map[string]string {
+	return etagHeaders(handler.etag)
+}
+
+// parseRevision converts passed revision number. It fails if it's missing or not a positive number.
+func parseRevision(revStr string) (int, error) {
+
+	if revStr == "" {
+		return 0, newBadRequestError("Missing revision number.", nil)
+	}
+	rev, err := strconv.Atoi(revStr)
+	if err != nil || rev < 1 {
+		return 0, newBadRequestError(fmt.Sprintf("Invalid revision number: %s", revStr), err)
+	}
+	return rev, nil
+}
+
+// newRevisionResult creates a response item for passed revision, without recipe values.
+func newRevisionResult(revision recipeRevision) revisionResult {
+	return revisionResult{
+		Rev:       revision.Rev,
+		Action:    revision.Action,
+		Author:    revision.Author,
+		CreatedAt: revision.CreatedAt.Round(1 * time.Second),
+	}
+}
@@ -0,0 +1,215 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/stretchr/testify/suite"
+	"github.com/tommzn/recipeboard-core/mock"
+	model "github.com/tommzn/recipeboard-core/model"
+)
+
+// Test suite for recipe revisions and rollback.
+type RevisionsTestSuite struct {
+	suite.Suite
+	repo    *mock.RepositoryMock
+	factory *requestHandlerFactory
+	router  LambdaRequestHandler
+}
+
+func TestRevisionsTestSuite(t *testing.T) {
+	suite.Run(t, new(RevisionsTestSuite))
+}
+
+// Setup test.
+func (suite *RevisionsTestSuite) SetupTest() {
+	suite.repo = repositoryForTest()
+	suite.factory = factoryForTest(suite.repo, publisherForTest(), loggerForTest())
+	suite.router = routerWithFactoryForTest(suite.factory, loggerForTest())
+}
+
+// Test revisions are written on create, update and delete.
+func (suite *RevisionsTestSuite) TestRevisionsOfRecipe() {
+
+	body := `{"type": "baking", "title": "Cake", "ingredients": "Mehl"}`
+	request := apiGatewayRequestForTest(http.MethodPost, &body, nil)
+	request.RequestContext.Authorizer = map[string]interface{}{"claims": map[string]interface{}{"cognito:username": "alice"}}
+	response := suite.handle(request)
+	suite.Equal(http.StatusOK, response.StatusCode)
+	recipe, err := getRecipeFromResponse(response)
+	suite.Nil(err)
+
+	body = `{"type": "baking", "title": "Chocolate Cake", "ingredients": "Mehl\nKakao"}`
+	request = apiGatewayRequestForTest(http.MethodPut, &body, &recipe.Id)
+	request.RequestContext.Identity.User = "bob"
+	suite.Equal(http.StatusOK, suite.handle(request).StatusCode)
+
+	response = suite.handle(apiGatewayRequestWithPathForTest(http.MethodGet, "/recipes/"+recipe.Id+"/revisions"))
+	suite.Equal(http.StatusOK, response.StatusCode)
+	revisions := revisionListResponse{}
+	suite.Nil(json.Unmarshal([]byte(response.Body), &revisions))
+	suite.Len(revisions.Items, 2)
+	suite.Equal(1, revisions.Items[0].Rev)
+	suite.Equal(revisionCreated, revisions.Items[0].Action)
+	suite.Equal("alice", revisions.Items[0].Author)
+	suite.Equal(revisionUpdated, revisions.Items[1].Action)
+	suite.Equal("bob", revisions.Items[1].Author)
+	suite.Nil(revisions.Items[0].Recipe)
+
+	response = suite.handle(apiGatewayRequestWithPathForTest(http.MethodGet, "/recipes/"+recipe.Id+"/revisions/1"))
+	suite.Equal(http.StatusOK, response.StatusCode)
+	revision := revisionResult{}
+	suite.Nil(json.Unmarshal([]byte(response.Body), &revision))
+	revisionRecipe := model.Recipe{}
+	suite.Nil(json.Unmarshal(revision.Recipe, &revisionRecipe))
+	suite.Equal("Cake", revisionRecipe.Title)
+
+	suite.Equal(http.StatusOK, suite.handle(apiGatewayRequestForTest(http.MethodDelete, nil, &recipe.Id)).StatusCode)
+	history, err := suite.factory.getRevisionLog().list(recipe.Id)
+	suite.Nil(err)
+	suite.Len(history, 3)
+	suite.Equal(revisionDeleted, history[2].Action)
+	suite.Equal(anonymousAuthor, history[2].Author)
+
+	suite.Equal(http.StatusNotFound, suite.handle(apiGatewayRequestWithPathForTest(http.MethodGet, "/recipes/"+recipe.Id+"/revisions/4")).StatusCode)
+	suite.Equal(http.StatusBadRequest, suite.handle(apiGatewayRequestWithPathForTest(http.MethodGet, "/recipes/"+recipe.Id+"/revisions/x")).StatusCode)
+}
+
+// Test listing revisions of recipes without a revision history.
+func (suite *RevisionsTestSuite) TestRevisionsWithoutHistory() {
+
+	recipe := recipeForTest()
+	suite.repo.Recipes[recipe.Id] = recipe
+
+	response := suite.handle(apiGatewayRequestWithPathForTest(http.MethodGet, "/recipes/"+recipe.Id+"/revisions"))
+	suite.Equal(http.StatusOK, response.StatusCode)
+	suite.Equal(`{"items":[]}`, response.Body)
+
+	response = suite.handle(apiGatewayRequestWithPathForTest(http.MethodGet, "/recipes/xxx/revisions"))
+	suite.Equal(http.StatusNotFound, response.StatusCode)
+}
+
+// Test rolling back a recipe to an older revision.
+func (suite *RevisionsTestSuite) TestRollback() {
+
+	recipe := recipeForTest()
+	suite.repo.Recipes[recipe.Id] = recipe
+	revisions := suite.factory.getRevisionLog()
+	suite.Nil(revisions.append(recipeRevision{Action: revisionCreated, Recipe: recipe}))
+	changedRecipe := recipe
+	changedRecipe.Title = "Changed"
+	suite.Nil(suite.factory.getRecipeService().Update(changedRecipe))
+
+	request := apiGatewayRequestWithPathForTest(http.MethodPost, "/recipes/"+recipe.Id+":rollback")
+	request.QueryStringParameters["rev"] = "1"
+	response := suite.handle(request)
+	suite.Equal(http.StatusOK, response.StatusCode)
+	suite.NotEmpty(response.Headers["ETag"])
+	suite.Equal(recipe.Title, suite.repo.Recipes[recipe.Id].Title)
+
+	history, err := revisions.list(recipe.Id)
+	suite.Nil(err)
+	suite.Len(history, 3)
+	suite.Equal(recipe.Title, history[2].Recipe.Title)
+
+	request.QueryStringParameters["rev"] = "9"
+	suite.Equal(http.StatusNotFound, suite.handle(request).StatusCode)
+
+	request.QueryStringParameters["rev"] = "0"
+	suite.Equal(http.StatusBadRequest, suite.handle(request).StatusCode)
+
+	request.QueryStringParameters["rev"] = "1"
+	request.Headers = map[string]string{"If-Match": `"xxx"`}
+	suite.Equal(http.StatusPreconditionFailed, suite.handle(request).StatusCode)
+}
+
+// Test long revision histories of large recipes don't exceed the max document size of DynamoDb.
+func (suite *RevisionsTestSuite) TestLargeRevisionHistory() {
+
+	revisions := newDocumentRevisionLog(&sizeLimitedDocumentStore{documentStore: newMemoryDocumentStore(), maxSize: 400 * 1024})
+	recipe := recipeForTest()
+	recipe.Description = strings.Repeat("x", 20000)
+	for i := 0; i < 50; i++ {
+		suite.Nil(revisions.append(recipeRevision{Action: revisionUpdated, Recipe: recipe}))
+	}
+
+	history, err := revisions.list(recipe.Id)
+	suite.Nil(err)
+	suite.Len(history, 50)
+	suite.Equal(50, history[49].Rev)
+
+	revision, err := revisions.get(recipe.Id, 50)
+	suite.Nil(err)
+	suite.Equal(recipe.Description, revision.Recipe.Description)
+
+	_, err = revisions.get(recipe.Id, 51)
+	suite.IsType(&notFoundError{}, err)
+}
+
+// Test revisions written concurrently by other instances are never overwritten, even if the latest revision
+// number has been read before another instance has written a revision.
+func (suite *RevisionsTestSuite) TestConcurrentRevisions() {
+
+	store := newMemoryDocumentStore()
+	instance1 := newDocumentRevisionLog(store)
+	instance2 := newDocumentRevisionLog(store)
+	recipe := recipeForTest()
+
+	suite.Nil(instance1.append(recipeRevision{Action: revisionCreated, Author: "alice", Recipe: recipe}))
+	suite.Nil(store.set(revisionHeadCollection, recipe.Id, revisionHead{Id: recipe.Id}))
+	suite.Nil(instance2.append(recipeRevision{Action: revisionUpdated, Author: "bob", Recipe: recipe}))
+
+	history, err := instance1.list(recipe.Id)
+	suite.Nil(err)
+	suite.Len(history, 2)
+	suite.Equal("alice", history[0].Author)
+	suite.Equal("bob", history[1].Author)
+	suite.Equal(2, history[1].Rev)
+
+	suite.Nil(store.set(revisionHeadCollection, recipe.Id, revisionHead{Id: recipe.Id, Latest: 1}))
+	history, err = instance2.list(recipe.Id)
+	suite.Nil(err)
+	suite.Len(history, 2)
+}
+
+// Test revisions are written with the author of the request a recipe service has been created for.
+func (suite *RevisionsTestSuite) TestAuthorPerRequest() {
+
+	suite.factory.author = "alice"
+	recipeService := suite.factory.getRecipeService()
+	suite.factory.author = "bob"
+
+	newRecipe, err := recipeService.Create(recipeForTest())
+	suite.Nil(err)
+	history, err := suite.factory.getRevisionLog().list(newRecipe.Id)
+	suite.Nil(err)
+	suite.Len(history, 1)
+	suite.Equal("alice", history[0].Author)
+}
+
+// Test extracting the author from a request.
+func (suite *RevisionsTestSuite) TestRequestAuthor() {
+
+	request := events.APIGatewayProxyRequest{}
+	suite.Equal("", requestAuthor(request))
+
+	request.RequestContext.Identity.CognitoIdentityID = "eu-west-1:123"
+	suite.Equal("eu-west-1:123", requestAuthor(request))
+
+	request.RequestContext.Authorizer = map[string]interface{}{"principalId": "user-1"}
+	suite.Equal("user-1", requestAuthor(request))
+
+	request.RequestContext.Authorizer["claims"] = map[string]interface{}{"email": "alice@example.com"}
+	suite.Equal("alice@example.com", requestAuthor(request))
+}
+
+// handle passes a request to the router.
+func (suite *RevisionsTestSuite) handle(request events.APIGatewayProxyRequest) events.APIGatewayProxyResponse {
+	response, err := suite.router.handle(context.Background(), request)
+	suite.Nil(err)
+	return response
+}
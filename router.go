@@ -2,7 +2,11 @@ package main
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"net/http"
+	"net/url"
+	"strings"
 
 	"github.com/aws/aws-lambda-go/events"
 	config "github.com/tommzn/go-config"
@@ -14,11 +18,13 @@ func newRequestRouter(config config.Config, logger log.Logger) LambdaRequestHand
 
 	return &requestRouter{
 		factory: newRequestHandlerFactory(config, logger),
+		cors:    newCorsConfig(config),
 		logger:  logger,
 	}
 }
 
 // Handle requests from API Gateway to forward them suitable request handler for processing.
+// OPTIONS requests are answered by the router itself. CORS headers are added to all responses.
 func (router *requestRouter) handle(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
 
 	router.logger.WithContext(log.LogContextWithValues(ctx, contextValuesFromRequest(request)))
@@ -26,25 +32,64 @@ func (router *requestRouter) handle(ctx context.Context, request events.APIGatew
 
 	router.logger.Debugf("Recive request with body: %s, path params: %+v and query params: %+v", request.Body, request.PathParameters, request.QueryStringParameters)
 
+	if request.HTTPMethod == http.MethodOptions {
+		return preflightResponse(request, router.cors), nil
+	}
+	return withCorsHeaders(router.process(request), request, router.cors), nil
+}
+
+// process forwards passed request to it's request handler and creates a response from it's result.
+func (router *requestRouter) process(request events.APIGatewayProxyRequest) events.APIGatewayProxyResponse {
+
 	requestHandler, err := router.factory.handlerForRequest(request)
 	if err != nil {
 		router.logger.Error("Unable to get handler, reason: ", err)
-		return responseWithStatus(http.StatusNotImplemented), err
+		return responseWithError(err, http.StatusNotImplemented, request)
 	}
 
 	if err := requestHandler.parseRequest(request); err != nil {
 		router.logger.Error("Unable to parse request, reason: ", err)
-		return responseWithStatus(http.StatusBadRequest), err
+		return responseWithError(err, http.StatusBadRequest, request)
+	}
+
+	mediaTypes := []string{jsonMediaType}
+	negotiator, isNegotiator := requestHandler.(contentNegotiator)
+	if isNegotiator {
+		mediaTypes = negotiator.mediaTypes()
+	}
+	mediaType, err := negotiateMediaType(headerValue(request, "Accept"), mediaTypes)
+	if err != nil {
+		router.logger.Error("Unable to negotiate media type, reason: ", err)
+		return responseWithError(err, http.StatusNotAcceptable, request)
+	}
+	if isNegotiator {
+		negotiator.useMediaType(mediaType)
 	}
 
 	responseBody, err := requestHandler.handle()
 	if err != nil {
 		router.logger.Error("Unable to handle request, reason: ", err)
-		return responseWithStatus(http.StatusInternalServerError), err
+		return responseWithError(err, http.StatusInternalServerError, request)
 	}
 
 	router.logger.Debugf("Request has been processed successful", request.RequestContext.RequestID)
-	return responseWithBody(http.StatusOK, responseBody), nil
+	response := responseWithBody(http.StatusOK, responseBody)
+	response.Headers = make(map[string]string)
+	if headerProvider, ok := requestHandler.(responseHeaderProvider); ok {
+		for name, value := range headerProvider.responseHeaders() {
+			response.Headers[name] = value
+		}
+	}
+	if _, ok := response.Headers["Content-Type"]; !ok && responseBody != nil {
+		response.Headers["Content-Type"] = contentTypeHeader(mediaType)
+	}
+	if len(mediaTypes) > 1 {
+		response.Headers["Vary"] = "Accept"
+	}
+	if binaryProvider, ok := requestHandler.(binaryResponseProvider); ok {
+		response.IsBase64Encoded = binaryProvider.isBase64Encoded()
+	}
+	return response
 }
 
 // responseWithStatus returns a APIGatewayProxyResponse with given status code.
@@ -61,9 +106,93 @@ func responseWithBody(statusCode int, body *string) events.APIGatewayProxyRespon
 	return response
 }
 
+// responseWithError returns a APIGatewayProxyResponse with a RFC 7807 problem details body for passed error.
+// Status code is defined by passed error or given default status code is used for untyped errors.
+// For unsupported HTTP methods all allowed methods are added as Allow header.
+// Not modified responses contain the ETag of the current recipe, but no body.
+func responseWithError(err error, defaultStatusCode int, request events.APIGatewayProxyRequest) events.APIGatewayProxyResponse {
+
+	var notModifiedErr *notModifiedError
+	if errors.As(err, &notModifiedErr) {
+		response := responseWithStatus(http.StatusNotModified)
+		response.Headers = etagHeaders(notModifiedErr.etag)
+		return response
+	}
+
+	statusCode := statusCodeForError(err, defaultStatusCode)
+	response := responseWithStatus(statusCode)
+	response.Headers = map[string]string{"Content-Type": "application/problem+json"}
+	if body, marshalErr := json.Marshal(newProblemDetails(err, statusCode, requestPath(request), request.RequestContext.RequestID)); marshalErr == nil {
+		response.Body = string(body)
+	}
+
+	var methodErr *methodNotAllowedError
+	if errors.As(err, &methodErr) {
+		response.Headers["Allow"] = strings.Join(methodErr.allowedMethods, ", ")
+	}
+	var mediaTypeErr *unsupportedMediaTypeError
+	if errors.As(err, &mediaTypeErr) && request.HTTPMethod == http.MethodPatch {
+		response.Headers["Accept-Patch"] = strings.Join(mediaTypeErr.supportedTypes, ", ")
+	}
+	return response
+}
+
 // contextValuesFromRequest extracts relevant context values from passed request.
 func contextValuesFromRequest(request events.APIGatewayProxyRequest) map[string]string {
 	contextValues := make(map[string]string)
 	contextValues[log.LogCtxRequestId] = request.RequestContext.RequestID
 	return contextValues
 }
+
+// headerValue returns the value of a request header. Header names are compared case-insensitive.
+func headerValue(request events.APIGatewayProxyRequest, name string) string {
+
+	if value, ok := request.Headers[name]; ok {
+		return value
+	}
+	for key, value := range request.Headers {
+		if strings.EqualFold(key, name) {
+			return value
+		}
+	}
+	for key, values := range request.MultiValueHeaders {
+		if strings.EqualFold(key, name) && len(values) > 0 {
+			return strings.Join(values, ", ")
+		}
+	}
+	return ""
+}
+
+// queryParamValues returns all values of a query param. Multi value query params are preferred, because
+// API Gateway passes only the last value of a query param in single value query params.
+func queryParamValues(request events.APIGatewayProxyRequest, name string) []string {
+
+	if values, ok := request.MultiValueQueryStringParameters[name]; ok {
+		return values
+	}
+	if value, ok := request.QueryStringParameters[name]; ok {
+		return []string{value}
+	}
+	return []string{}
+}
+
+// queryValues returns all query params of passed request. Multi value query params are preferred,
+// single value query params are used for all params which are not available as multi value.
+func queryValues(request events.APIGatewayProxyRequest) url.Values {
+
+	values := url.Values{}
+	for name, paramValues := range request.MultiValueQueryStringParameters {
+		values[name] = append([]string{}, paramValues...)
+	}
+	for name, value := range request.QueryStringParameters {
+		if _, ok := values[name]; !ok {
+			values.Set(name, value)
+		}
+	}
+	return values
+}
+
+// mediaType returns passed content type in lower case and without parameters, e.g. charset.
+func mediaType(contentType string) string {
+	return strings.ToLower(strings.TrimSpace(strings.Split(contentType, ";")[0]))
+}
@@ -28,9 +28,28 @@ func (suite *RouterTestSuite) SetupTest() {
 func (suite *RouterTestSuite) TestUnsupportedHttpMethod() {
 
 	response, err := suite.router.handle(context.Background(), apiGatewayRequestForTest(http.MethodPatch, nil, nil))
-	suite.NotNil(err)
+	suite.Nil(err)
+	suite.NotNil(response)
+	suite.Equal(response.StatusCode, http.StatusMethodNotAllowed)
+	suite.Equal("GET, POST", response.Headers["Allow"])
+}
+
+// Test route request for an unknown path.
+func (suite *RouterTestSuite) TestUnknownPath() {
+
+	request := apiGatewayRequestWithPathForTest(http.MethodGet, "/recipes/123/images")
+	response, err := suite.router.handle(context.Background(), request)
+	suite.Nil(err)
 	suite.NotNil(response)
-	suite.Equal(response.StatusCode, http.StatusNotImplemented)
+	suite.Equal(response.StatusCode, http.StatusNotFound)
+
+	problem, err := getProblemDetailsFromResponse(response)
+	suite.Nil(err)
+	suite.Equal("urn:recipemanager:problem:not-found", problem.Type)
+	suite.Equal("Not Found", problem.Title)
+	suite.Equal(http.StatusNotFound, problem.Status)
+	suite.Equal("/recipes/123/images", problem.Instance)
+	suite.Equal(request.RequestContext.RequestID, problem.RequestId)
 }
 
 // Test router for failed request parsing.
@@ -40,10 +59,13 @@ func (suite *RouterTestSuite) TestRequestParseErrorFromHandler() {
 	router := routerWithParseErrorForTest(parseError, loggerForTest())
 
 	response, err := router.handle(context.Background(), apiGatewayRequestForTest(http.MethodGet, nil, nil))
-	suite.NotNil(err)
-	suite.Equal(parseError, err)
+	suite.Nil(err)
 	suite.NotNil(response)
 	suite.Equal(response.StatusCode, http.StatusBadRequest)
+
+	problem, err := getProblemDetailsFromResponse(response)
+	suite.Nil(err)
+	suite.Equal(parseError.Error(), problem.Detail)
 }
 
 // Test router in case of request handler errors.
@@ -53,10 +75,34 @@ func (suite *RouterTestSuite) TestErrorFromHandler() {
 	router := routerWithHandleErrorForTest(responseError, loggerForTest())
 
 	response, err := router.handle(context.Background(), apiGatewayRequestForTest(http.MethodGet, nil, nil))
-	suite.NotNil(err)
-	suite.Equal(responseError, err)
+	suite.Nil(err)
 	suite.NotNil(response)
 	suite.Equal(response.StatusCode, http.StatusInternalServerError)
+
+	problem, err := getProblemDetailsFromResponse(response)
+	suite.Nil(err)
+	suite.NotContains(problem.Detail, responseError.Error())
+}
+
+// Test status code mapping for typed request handler errors.
+func (suite *RouterTestSuite) TestTypedErrorFromHandler() {
+
+	expectedStatusCodes := map[error]int{
+		newBadRequestError("Invalid request.", nil):         http.StatusBadRequest,
+		newNotFoundError("", errors.New("Not found.")):      http.StatusNotFound,
+		newConflictError("Recipe already exists.", nil):     http.StatusConflict,
+		newValidationError("Title is missing.", nil):        http.StatusUnprocessableEntity,
+		fromServiceError(errors.New("Not found: xxx")):      http.StatusNotFound,
+		fromServiceError(errors.New("Connection timeout.")): http.StatusInternalServerError,
+	}
+	for responseError, expectedStatusCode := range expectedStatusCodes {
+
+		router := routerWithHandleErrorForTest(responseError, loggerForTest())
+		response, err := router.handle(context.Background(), apiGatewayRequestForTest(http.MethodGet, nil, nil))
+		suite.Nil(err)
+		suite.Equal(expectedStatusCode, response.StatusCode)
+		suite.Equal("application/problem+json", response.Headers["Content-Type"])
+	}
 }
 
 // Test router in case of request handler errors.
@@ -69,3 +115,13 @@ func (suite *RouterTestSuite) TestSuccessfulRequestHandler() {
 	suite.NotNil(response)
 	suite.Equal(response.StatusCode, http.StatusOK)
 }
+
+// Test OPTIONS requests are answered by the router with all allowed methods.
+func (suite *RouterTestSuite) TestOptionsRequest() {
+
+	response, err := suite.router.handle(context.Background(), apiGatewayRequestForTest(http.MethodOptions, nil, nil))
+	suite.Nil(err)
+	suite.Equal(http.StatusNoContent, response.StatusCode)
+	suite.Equal("GET, POST, OPTIONS", response.Headers["Allow"])
+	suite.Equal("", response.Headers["Access-Control-Allow-Origin"])
+}
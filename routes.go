@@ -0,0 +1,235 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+// routes defines all available resources and HTTP methods with the handler which should process a request.
+var routes = []route{
+	{method: http.MethodGet, resource: "/recipes", newHandler: (*requestHandlerFactory).newListRequestHandler},
+	{method: http.MethodPost, resource: "/recipes", newHandler: (*requestHandlerFactory).newPostRequestHandler},
+	{method: http.MethodPost, resource: "/recipes:import", newHandler: (*requestHandlerFactory).newImportRequestHandler},
+	{method: http.MethodPost, resource: "/recipes:batchGet", newHandler: (*requestHandlerFactory).newBatchGetRequestHandler},
+	{method: http.MethodPost, resource: "/recipes:batchDelete", newHandler: (*requestHandlerFactory).newBatchDeleteRequestHandler},
+	{method: http.MethodGet, resource: "/recipes:export", newHandler: (*requestHandlerFactory).newExportRequestHandler},
+	{method: http.MethodGet, resource: "/recipes/search", newHandler: (*requestHandlerFactory).newSearchRequestHandler},
+	{method: http.MethodGet, resource: "/recipes/{id}", newHandler: (*requestHandlerFactory).newGetRequestHandler},
+	{method: http.MethodPut, resource: "/recipes/{id}", newHandler: (*requestHandlerFactory).newPutRequestHandler},
+	{method: http.MethodPatch, resource: "/recipes/{id}", newHandler: (*requestHandlerFactory).newPatchRequestHandler},
+	{method: http.MethodDelete, resource: "/recipes/{id}", newHandler: (*requestHandlerFactory).newDeleteRequestHandler},
+	{method: http.MethodPost, resource: "/recipes/{id}:restore", newHandler: (*requestHandlerFactory).newRestoreRequestHandler},
+	{method: http.MethodPost, resource: "/recipes/{id}:rollback", newHandler: (*requestHandlerFactory).newRollbackRequestHandler},
+	{method: http.MethodGet, resource: "/recipes/{id}/revisions", newHandler: (*requestHandlerFactory).newRevisionListRequestHandler},
+	{method: http.MethodGet, resource: "/recipes/{id}/revisions/{rev}", newHandler: (*requestHandlerFactory).newRevisionGetRequestHandler},
+	{method: http.MethodGet, resource: "/recipes/{id}/print", newHandler: (*requestHandlerFactory).newPrintRequestHandler},
+	{method: http.MethodPost, resource: "/shopping-lists", newHandler: (*requestHandlerFactory).newShoppingListRequestHandler},
+	{method: http.MethodGet, resource: "/mealplans", newHandler: (*requestHandlerFactory).newMealPlanListRequestHandler},
+	{method: http.MethodPost, resource: "/mealplans", newHandler: (*requestHandlerFactory).newMealPlanPostRequestHandler},
+	{method: http.MethodGet, resource: "/mealplans/{week}", newHandler: (*requestHandlerFactory).newMealPlanGetRequestHandler},
+	{method: http.MethodPut, resource: "/mealplans/{week}", newHandler: (*requestHandlerFactory).newMealPlanPutRequestHandler},
+	{method: http.MethodDelete, resource: "/mealplans/{week}", newHandler: (*requestHandlerFactory).newMealPlanDeleteRequestHandler},
+	{method: http.MethodGet, resource: "/mealplans/{week}/shopping-list", newHandler: (*requestHandlerFactory).newMealPlanShoppingListRequestHandler},
+	{method: http.MethodGet, resource: "/trash", newHandler: (*requestHandlerFactory).newTrashListRequestHandler},
+	{method: http.MethodPost, resource: "/trash:purge", newHandler: (*requestHandlerFactory).newTrashPurgeRequestHandler},
+}
+
+// matchRoute looks up the route for passed request. API Gateway resource is used if it's defined in
+// route table and request path doesn't call a custom method, otherwise request path is matched against all route resource templates.
+// In this case path params will be extracted from request path. If multiple resources match a request path,
+// the one with the fewest path params is used, e.g. /recipes/search takes precedence over /recipes/{id}.
+// For the same number of path params the longer resource is used, e.g. /recipes/{id}:restore takes precedence over /recipes/{id}.
+// It returns a routeNotFoundError if there's no route for a request path and a methodNotAllowedError
+// if there're routes for this path, but not for the requested HTTP method.
+func matchRoute(request events.APIGatewayProxyRequest) (*route, map[string]string, error) {
+
+	resource, pathParams, err := matchRouteResource(request)
+	if err != nil {
+		return nil, nil, err
+	}
+	for idx := range routes {
+		if routes[idx].resource == resource && routes[idx].method == request.HTTPMethod {
+			return &routes[idx], pathParams, nil
+		}
+	}
+	return nil, nil, &methodNotAllowedError{method: request.HTTPMethod, allowedMethods: methodsForResource(resource)}
+}
+
+// allowedMethodsForRequest returns all HTTP methods routes are defined for on the path of passed request.
+func allowedMethodsForRequest(request events.APIGatewayProxyRequest) ([]string, error) {
+
+	resource, _, err := matchRouteResource(request)
+	if err != nil {
+		return nil, err
+	}
+	return methodsForResource(resource), nil
+}
+
+// matchRouteResource returns the route resource template for passed request, together with extracted path params.
+func matchRouteResource(request events.APIGatewayProxyRequest) (string, map[string]string, error) {
+
+	var matchedResource *string
+	var matchedPathParams map[string]string
+	for idx := range routes {
+		pathParams, ok := matchResource(routes[idx].resource, request)
+		if ok && (matchedResource == nil || len(pathParams) < len(matchedPathParams) ||
+			(len(pathParams) == len(matchedPathParams) && len(routes[idx].resource) > len(*matchedResource))) {
+			matchedResource = &routes[idx].resource
+			matchedPathParams = pathParams
+		}
+	}
+	if matchedResource == nil {
+		return "", nil, &routeNotFoundError{path: requestPath(request)}
+	}
+	return *matchedResource, matchedPathParams, nil
+}
+
+// methodsForResource returns all HTTP methods routes are defined for passed resource, sorted by name.
+func methodsForResource(resource string) []string {
+
+	methods := []string{}
+	for _, route := range routes {
+		if route.resource == resource {
+			methods = append(methods, route.method)
+		}
+	}
+	sort.Strings(methods)
+	return methods
+}
+
+// matchResource checks if passed resource template matches API Gateway resource or path of given request.
+// API Gateway doesn't allow colons in path parts, so custom methods like /recipes/{id}:restore are passed
+// with the resource they're defined on. In this case request path has to be matched.
+func matchResource(resource string, request events.APIGatewayProxyRequest) (map[string]string, bool) {
+
+	if request.Resource == resource && customMethod(request.Path) == customMethod(resource) {
+		return map[string]string{}, true
+	}
+	return matchPath(resource, request.Path)
+}
+
+// customMethod returns the custom method of passed path or resource, e.g. restore for /recipes/{id}:restore.
+// It's empty if there's no custom method.
+func customMethod(path string) string {
+
+	lastSegment := path[strings.LastIndex(path, "/")+1:]
+	if idx := strings.LastIndex(lastSegment, ":"); idx >= 0 {
+		return lastSegment[idx+1:]
+	}
+	return ""
+}
+
+// matchPath compares passed path segment by segment with given resource template
+// and returns all path params defined in this template.
+func matchPath(resource, path string) (map[string]string, bool) {
+
+	resourceSegments := strings.Split(strings.Trim(resource, "/"), "/")
+	pathSegments := strings.Split(strings.Trim(path, "/"), "/")
+	if len(resourceSegments) != len(pathSegments) {
+		return nil, false
+	}
+
+	pathParams := make(map[string]string)
+	for idx, resourceSegment := range resourceSegments {
+
+		start := strings.Index(resourceSegment, "{")
+		end := strings.Index(resourceSegment, "}")
+		if start < 0 || end < start {
+			if resourceSegment != pathSegments[idx] {
+				return nil, false
+			}
+			continue
+		}
+
+		prefix := resourceSegment[:start]
+		suffix := resourceSegment[end+1:]
+		pathSegment := pathSegments[idx]
+		if len(pathSegment) <= len(prefix)+len(suffix) ||
+			!strings.HasPrefix(pathSegment, prefix) || !strings.HasSuffix(pathSegment, suffix) {
+			return nil, false
+		}
+		pathParams[resourceSegment[start+1:end]] = pathSegment[len(prefix) : len(pathSegment)-len(suffix)]
+	}
+	return pathParams, true
+}
+
+// requestPath returns the path of passed request, or it's resource if path is empty.
+func requestPath(request events.APIGatewayProxyRequest) string {
+	if request.Path != "" {
+		return request.Path
+	}
+	return request.Resource
+}
+
+// parseRequest adds path params extracted during route matching to passed request and forwards it to the
+// request handler. Extracted path params take precedence, because path params of API Gateway contain
+// the custom method for requests like /recipes/{id}:restore.
+func (handler *routedRequestHandler) parseRequest(request events.APIGatewayProxyRequest) error {
+
+	if len(handler.pathParams) > 0 {
+		pathParams := make(map[string]string)
+		for key, value := range request.PathParameters {
+			pathParams[key] = value
+		}
+		for key, value := range handler.pathParams {
+			pathParams[key] = value
+		}
+		request.PathParameters = pathParams
+	}
+	return handler.apiGatewayRequestHandler.parseRequest(request)
+}
+
+// responseHeaders returns headers of the request handler if it provides any.
+func (handler *routedRequestHandler) responseHeaders() map[string]string {
+	if headerProvider, ok := handler.apiGatewayRequestHandler.(responseHeaderProvider); ok {
+		return headerProvider.responseHeaders()
+	}
+	return nil
+}
+
+// mediaTypes returns all media types supported by the request handler, or JSON only if it doesn't support content negotiation.
+func (handler *routedRequestHandler) mediaTypes() []string {
+	if negotiator, ok := handler.apiGatewayRequestHandler.(contentNegotiator); ok {
+		return negotiator.mediaTypes()
+	}
+	return []string{jsonMediaType}
+}
+
+// useMediaType passes the negotiated media type to the request handler.
+func (handler *routedRequestHandler) useMediaType(mediaType string) {
+	if negotiator, ok := handler.apiGatewayRequestHandler.(contentNegotiator); ok {
+		negotiator.useMediaType(mediaType)
+	}
+}
+
+// isBase64Encoded returns true if the request handler returns a base64 encoded binary response body.
+func (handler *routedRequestHandler) isBase64Encoded() bool {
+	if binaryProvider, ok := handler.apiGatewayRequestHandler.(binaryResponseProvider); ok {
+		return binaryProvider.isBase64Encoded()
+	}
+	return false
+}
+
+// Error returns a message with the path no route exists for.
+func (err *routeNotFoundError) Error() string {
+	return fmt.Sprintf("No route for path: %s", err.path)
+}
+
+// statusCode returns HTTP status 404.
+func (err *routeNotFoundError) statusCode() int {
+	return http.StatusNotFound
+}
+
+// Error returns a message with the requested and all allowed HTTP methods.
+func (err *methodNotAllowedError) Error() string {
+	return fmt.Sprintf("Unsupported HTTP method: %s, allowed: %s", err.method, strings.Join(err.allowedMethods, ", "))
+}
+
+// statusCode returns HTTP status 405.
+func (err *methodNotAllowedError) statusCode() int {
+	return http.StatusMethodNotAllowed
+}
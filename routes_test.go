@@ -0,0 +1,140 @@
+package main
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+)
+
+// Test suite for route matching.
+type RoutesTestSuite struct {
+	suite.Suite
+}
+
+func TestRoutesTestSuite(t *testing.T) {
+	suite.Run(t, new(RoutesTestSuite))
+}
+
+// Test matching request paths against resource templates.
+func (suite *RoutesTestSuite) TestMatchPath() {
+
+	pathParams, ok := matchPath("/recipes", "/recipes")
+	suite.True(ok)
+	suite.Len(pathParams, 0)
+
+	pathParams, ok = matchPath("/recipes/{id}", "/recipes/123")
+	suite.True(ok)
+	suite.Equal("123", pathParams["id"])
+
+	pathParams, ok = matchPath("/recipes/{id}/images/{image}", "/recipes/123/images/456/")
+	suite.True(ok)
+	suite.Equal("123", pathParams["id"])
+	suite.Equal("456", pathParams["image"])
+
+	pathParams, ok = matchPath("/recipes/{id}:restore", "/recipes/123:restore")
+	suite.True(ok)
+	suite.Equal("123", pathParams["id"])
+
+	_, ok = matchPath("/recipes/{id}:restore", "/recipes/123")
+	suite.False(ok)
+
+	_, ok = matchPath("/recipes/{id}", "/recipes")
+	suite.False(ok)
+
+	_, ok = matchPath("/recipes/{id}", "/ingredients/123")
+	suite.False(ok)
+}
+
+// Test custom methods requested with the API Gateway resource they're defined on.
+func (suite *RoutesTestSuite) TestMatchCustomMethodOfApiGatewayResource() {
+
+	recipeId := "123"
+	request := apiGatewayRequestForTest(http.MethodPost, nil, &recipeId)
+	request.Path = "/recipes/123:restore"
+	request.PathParameters["id"] = "123:restore"
+
+	route, pathParams, err := matchRoute(request)
+	suite.Nil(err)
+	suite.Equal("/recipes/{id}:restore", route.resource)
+	suite.Equal("123", pathParams["id"])
+
+	handler := &routedRequestHandler{apiGatewayRequestHandler: &apiGatewayRestoreRequestHandler{}, pathParams: pathParams}
+	suite.Nil(handler.parseRequest(request))
+	suite.Equal("123", *handler.apiGatewayRequestHandler.(*apiGatewayRestoreRequestHandler).recipeId)
+
+	request = apiGatewayRequestForTest(http.MethodGet, nil, &recipeId)
+	route, pathParams, err = matchRoute(request)
+	suite.Nil(err)
+	suite.Equal("/recipes/{id}", route.resource)
+	suite.Len(pathParams, 0)
+
+	suite.Equal("restore", customMethod("/recipes/{id}:restore"))
+	suite.Equal("purge", customMethod("/trash:purge"))
+	suite.Equal("", customMethod("/recipes/{id}"))
+}
+
+// Test route lookup for known and unknown paths and methods.
+func (suite *RoutesTestSuite) TestMatchRoute() {
+
+	route, _, err := matchRoute(apiGatewayRequestForTest(http.MethodGet, nil, nil))
+	suite.Nil(err)
+	suite.Equal("/recipes", route.resource)
+
+	recipeId := "123"
+	route, _, err = matchRoute(apiGatewayRequestForTest(http.MethodPut, nil, &recipeId))
+	suite.Nil(err)
+	suite.Equal("/recipes/{id}", route.resource)
+
+	route, pathParams, err := matchRoute(apiGatewayRequestWithPathForTest(http.MethodGet, "/recipes/123"))
+	suite.Nil(err)
+	suite.Equal("/recipes/{id}", route.resource)
+	suite.Equal("123", pathParams["id"])
+
+	route, pathParams, err = matchRoute(apiGatewayRequestWithPathForTest(http.MethodGet, "/recipes/search"))
+	suite.Nil(err)
+	suite.Equal("/recipes/search", route.resource)
+	suite.Len(pathParams, 0)
+
+	_, _, err = matchRoute(apiGatewayRequestWithPathForTest(http.MethodDelete, "/recipes/search"))
+	suite.IsType(&methodNotAllowedError{}, err)
+
+	route, pathParams, err = matchRoute(apiGatewayRequestWithPathForTest(http.MethodPost, "/recipes:import"))
+	suite.Nil(err)
+	suite.Equal("/recipes:import", route.resource)
+	suite.Len(pathParams, 0)
+
+	route, pathParams, err = matchRoute(apiGatewayRequestWithPathForTest(http.MethodPost, "/recipes:batchDelete"))
+	suite.Nil(err)
+	suite.Equal("/recipes:batchDelete", route.resource)
+	suite.Len(pathParams, 0)
+
+	route, pathParams, err = matchRoute(apiGatewayRequestWithPathForTest(http.MethodPost, "/recipes/123:restore"))
+	suite.Nil(err)
+	suite.Equal("/recipes/{id}:restore", route.resource)
+	suite.Equal("123", pathParams["id"])
+
+	route, pathParams, err = matchRoute(apiGatewayRequestWithPathForTest(http.MethodGet, "/recipes/123/print"))
+	suite.Nil(err)
+	suite.Equal("/recipes/{id}/print", route.resource)
+	suite.Equal("123", pathParams["id"])
+
+	_, _, err = matchRoute(apiGatewayRequestForTest(http.MethodPost, nil, &recipeId))
+	suite.NotNil(err)
+	methodErr, ok := err.(*methodNotAllowedError)
+	suite.True(ok)
+	suite.Equal([]string{http.MethodDelete, http.MethodGet, http.MethodPatch, http.MethodPut}, methodErr.allowedMethods)
+
+	route, _, err = matchRoute(apiGatewayRequestWithPathForTest(http.MethodPost, "/shopping-lists"))
+	suite.Nil(err)
+	suite.Equal("/shopping-lists", route.resource)
+
+	route, pathParams, err = matchRoute(apiGatewayRequestWithPathForTest(http.MethodGet, "/mealplans/2021-W07/shopping-list"))
+	suite.Nil(err)
+	suite.Equal("/mealplans/{week}/shopping-list", route.resource)
+	suite.Equal("2021-W07", pathParams["week"])
+
+	_, _, err = matchRoute(apiGatewayRequestWithPathForTest(http.MethodGet, "/ingredients"))
+	suite.NotNil(err)
+	suite.IsType(&routeNotFoundError{}, err)
+}
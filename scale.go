@@ -0,0 +1,188 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+
+	model "github.com/tommzn/recipeboard-core/model"
+)
+
+// servingsPatterns match the number of servings in recipe texts, e.g. "Serves 4", "4 servings" or "für 4 Personen".
+var servingsPatterns = []*regexp.Regexp{
+	regexp.MustCompile(`(?i)\b(?:serves|servings|yield|makes|für)\s*:?\s*(\d+)`),
+	regexp.MustCompile(`(?i)\b(\d+)\s*(?:servings|portions|portionen|personen|people|persons)\b`),
+}
+
+// unitFamilies contains units which can be replaced by each other after scaling, smallest unit first.
+var unitFamilies = [][]scalingUnit{
+	{{name: "tsp", size: 1, minQuantity: 0}, {name: "tbsp", size: 3, minQuantity: 1}, {name: "cup", size: 48, minQuantity: 0.25}},
+	{{name: "ml", size: 1, minQuantity: 0}, {name: "l", size: 1000, minQuantity: 1}},
+	{{name: "g", size: 1, minQuantity: 0}, {name: "kg", size: 1000, minQuantity: 1}},
+	{{name: "oz", size: 1, minQuantity: 0}, {name: "lb", size: 16, minQuantity: 1}},
+}
+
+// parseScaling reads scale, servings and baseservings query params. It returns nil if a recipe shouldn't be scaled.
+// Scale factors can be decimals or fractions, e.g. "1/2". Scale and servings can't be used together.
+func parseScaling(queryParams map[string]string) (*recipeScaling, error) {
+
+	scaleParam, hasScale := queryParams["scale"]
+	servingsParam, hasServings := queryParams["servings"]
+	if !hasScale && !hasServings {
+		return nil, nil
+	}
+	if hasScale && hasServings {
+		return nil, newBadRequestError("Use either scale or servings to scale a recipe.", nil)
+	}
+
+	scaling := &recipeScaling{}
+	if hasScale {
+		factor, err := parseQuantity(strings.TrimSpace(scaleParam))
+		if err != nil || factor <= 0 {
+			return nil, newBadRequestError(fmt.Sprintf("Invalid scale factor: %s", scaleParam), err)
+		}
+		scaling.factor = &factor
+		return scaling, nil
+	}
+
+	servings, err := strconv.Atoi(strings.TrimSpace(servingsParam))
+	if err != nil || servings < 1 {
+		return nil, newBadRequestError(fmt.Sprintf("Invalid number of servings: %s", servingsParam), err)
+	}
+	scaling.servings = servings
+	if baseServingsParam, ok := queryParams["baseservings"]; ok {
+		baseServings, err := strconv.Atoi(strings.TrimSpace(baseServingsParam))
+		if err != nil || baseServings < 1 {
+			return nil, newBadRequestError(fmt.Sprintf("Invalid number of base servings: %s", baseServingsParam), err)
+		}
+		scaling.baseServings = baseServings
+	}
+	return scaling, nil
+}
+
+// scaleFactor returns the factor to scale passed recipe with. If a number of servings is requested, servings
+// of the recipe are read from it's title, description or ingredients. It fails if they can't be found.
+func (scaling recipeScaling) scaleFactor(recipe model.Recipe) (float64, error) {
+
+	if scaling.factor != nil {
+		return *scaling.factor, nil
+	}
+	baseServings := scaling.baseServings
+	if baseServings == 0 {
+		baseServings = recipeServings(recipe)
+	}
+	if baseServings == 0 {
+		return 0, newValidationError("Number of servings of this recipe is unknown, use baseservings or scale instead.", nil)
+	}
+	return float64(scaling.servings) / float64(baseServings), nil
+}
+
+// recipeServings returns the number of servings mentioned in passed recipe, or zero if there's none.
+func recipeServings(recipe model.Recipe) int {
+
+	for _, text := range []string{recipe.Title, recipe.Description, recipe.Ingredients} {
+		for _, pattern := range servingsPatterns {
+			if match := pattern.FindStringSubmatch(text); match != nil {
+				if servings, err := strconv.Atoi(match[1]); err == nil && servings > 0 {
+					return servings
+				}
+			}
+		}
+	}
+	return 0
+}
+
+// scaleRecipe multiplies quantities of passed ingredients of given recipe with a factor. It returns the
+// recipe with scaled ingredients text and all scaled ingredients. Ingredients without quantity are kept unchanged.
+func scaleRecipe(recipe model.Recipe, ingredients []ingredient, factor float64) (model.Recipe, []ingredient) {
+
+	scaled := []ingredient{}
+	lines := []string{}
+	for _, parsed := range ingredients {
+		parsed = scaleIngredient(parsed, factor)
+		scaled = append(scaled, parsed)
+		lines = append(lines, parsed.Text)
+	}
+	recipe.Ingredients = strings.Join(lines, "\n")
+	return recipe, scaled
+}
+
+// scaleIngredient multiplies quantities of passed ingredient with given factor and promotes it's unit
+// if a quantity can be expressed better by another unit, e.g. 16 tbsp by 1 cup.
+// Ingredients without quantity are flagged as unscaled and returned unchanged.
+func scaleIngredient(parsed ingredient, factor float64) ingredient {
+
+	if parsed.Quantity == nil {
+		parsed.Unscaled = true
+		return parsed
+	}
+	quantity := *parsed.Quantity * factor
+	parsed.Quantity = &quantity
+	if parsed.QuantityMax != nil {
+		quantityMax := *parsed.QuantityMax * factor
+		parsed.QuantityMax = &quantityMax
+	}
+	promoteUnit(&parsed)
+	parsed.Text = formatIngredient(parsed)
+	return parsed
+}
+
+// promoteUnit replaces the unit of passed ingredient by the largest unit of it's family
+// which expresses all quantities as a nice number. Unit is kept if there's no such unit.
+func promoteUnit(parsed *ingredient) {
+
+	family, current := unitFamily(parsed.Unit)
+	if family == nil {
+		return
+	}
+	for idx := len(family) - 1; idx >= 0; idx-- {
+		unit := family[idx]
+		quantity := *parsed.Quantity * current.size / unit.size
+		if quantity < unit.minQuantity || !isNiceQuantity(quantity, unit.name) {
+			continue
+		}
+		var quantityMax *float64
+		if parsed.QuantityMax != nil {
+			value := *parsed.QuantityMax * current.size / unit.size
+			if !isNiceQuantity(value, unit.name) {
+				continue
+			}
+			quantityMax = &value
+		}
+		parsed.Unit = unit.name
+		parsed.Quantity = &quantity
+		parsed.QuantityMax = quantityMax
+		return
+	}
+}
+
+// unitFamily returns the family of passed unit and the unit itself. Family is nil for units which can't be promoted.
+func unitFamily(name string) ([]scalingUnit, scalingUnit) {
+
+	for _, family := range unitFamilies {
+		for _, unit := range family {
+			if unit.name == name {
+				return family, unit
+			}
+		}
+	}
+	return nil, scalingUnit{}
+}
+
+// isNiceQuantity returns true if passed quantity can be written without rounding. Metric quantities can have
+// up to two decimal places, all others have to be whole numbers or end with a half, a third or a quarter.
+func isNiceQuantity(quantity float64, unit string) bool {
+
+	if metricUnits[unit] {
+		return math.Abs(quantity*100-math.Round(quantity*100)) < 0.001
+	}
+	remainder := quantity - math.Floor(quantity)
+	for _, fraction := range []float64{0, 1.0 / 4, 1.0 / 3, 1.0 / 2, 2.0 / 3, 3.0 / 4, 1} {
+		if math.Abs(remainder-fraction) < 0.01 {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,132 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/stretchr/testify/suite"
+	"github.com/tommzn/recipeboard-core/mock"
+)
+
+// Test suite for scaling recipes.
+type ScaleTestSuite struct {
+	suite.Suite
+	repo   *mock.RepositoryMock
+	router LambdaRequestHandler
+}
+
+func TestScaleTestSuite(t *testing.T) {
+	suite.Run(t, new(ScaleTestSuite))
+}
+
+// Setup test.
+func (suite *ScaleTestSuite) SetupTest() {
+	suite.repo = repositoryForTest()
+	suite.router = routerWithFactoryForTest(factoryForTest(suite.repo, publisherForTest(), loggerForTest()), loggerForTest())
+}
+
+// Test scaling single ingredients, including unit promotion.
+func (suite *ScaleTestSuite) TestScaleIngredient() {
+
+	suite.Equal("1 cup butter", scaleIngredient(parseIngredient("8 tbsp butter"), 2).Text)
+	suite.Equal("1 1/2 tsp salt", scaleIngredient(parseIngredient("1 tbsp salt"), 0.5).Text)
+	suite.Equal("6 tbsp sugar", scaleIngredient(parseIngredient("3 tbsp sugar"), 2).Text)
+	suite.Equal("5 cups flour, sifted", scaleIngredient(parseIngredient("2 1/2 cups flour, sifted"), 2).Text)
+	suite.Equal("1.5 kg Mehl", scaleIngredient(parseIngredient("500g Mehl"), 3).Text)
+	suite.Equal("750 g Mehl", scaleIngredient(parseIngredient("250 g Mehl"), 3).Text)
+	suite.Equal("1 1/2 lb beef", scaleIngredient(parseIngredient("12 oz beef"), 2).Text)
+	suite.Equal("4-6 cloves garlic, minced", scaleIngredient(parseIngredient("2-3 cloves garlic, minced"), 2).Text)
+	suite.Equal("1 egg", scaleIngredient(parseIngredient("2 egg"), 0.5).Text)
+
+	salt := scaleIngredient(parseIngredient("Salt, to taste"), 2)
+	suite.True(salt.Unscaled)
+	suite.Equal("Salt, to taste", salt.Text)
+}
+
+// Test reading scaling params from query.
+func (suite *ScaleTestSuite) TestParseScaling() {
+
+	scaling, err := parseScaling(map[string]string{})
+	suite.Nil(err)
+	suite.Nil(scaling)
+
+	scaling, err = parseScaling(map[string]string{"scale": "1/2"})
+	suite.Nil(err)
+	suite.Equal(0.5, *scaling.factor)
+
+	scaling, err = parseScaling(map[string]string{"servings": "8", "baseservings": "4"})
+	suite.Nil(err)
+	suite.Equal(8, scaling.servings)
+	suite.Equal(4, scaling.baseServings)
+
+	for _, params := range []map[string]string{
+		{"scale": "0"}, {"scale": "x"}, {"servings": "-1"}, {"servings": "2", "baseservings": "x"}, {"scale": "2", "servings": "4"},
+	} {
+		_, err = parseScaling(params)
+		suite.NotNil(err)
+	}
+}
+
+// Test detecting servings of a recipe.
+func (suite *ScaleTestSuite) TestRecipeServings() {
+
+	recipe := recipeForTest()
+	suite.Equal(0, recipeServings(recipe))
+
+	recipe.Description = "Serves 4.\nBacken."
+	suite.Equal(4, recipeServings(recipe))
+
+	recipe.Description = "Rezept für 6 Personen."
+	suite.Equal(6, recipeServings(recipe))
+
+	recipe.Title = "Pancakes (2 servings)"
+	suite.Equal(2, recipeServings(recipe))
+}
+
+// Test scaling recipes in GET requests.
+func (suite *ScaleTestSuite) TestGetScaledRecipe() {
+
+	recipe := recipeForTest()
+	recipe.Ingredients = "500g Mehl\n8 tbsp butter\nSalt, to taste"
+	recipe.Description = "Serves 4.\nBacken."
+	suite.repo.Recipes[recipe.Id] = recipe
+
+	request := apiGatewayRequestForTest(http.MethodGet, nil, &recipe.Id)
+	request.QueryStringParameters["servings"] = "8"
+	response := suite.handle(request)
+	suite.Equal(http.StatusOK, response.StatusCode)
+	suite.NotEmpty(response.Headers["ETag"])
+
+	values := struct {
+		Ingredients       string
+		IngredientsParsed []ingredient `json:"ingredientsParsed"`
+	}{}
+	suite.Nil(json.Unmarshal([]byte(response.Body), &values))
+	suite.Equal("1 kg Mehl\n1 cup butter\nSalt, to taste", values.Ingredients)
+	suite.Len(values.IngredientsParsed, 3)
+	suite.False(values.IngredientsParsed[0].Unscaled)
+	suite.True(values.IngredientsParsed[2].Unscaled)
+	suite.Equal(recipe.Ingredients, suite.repo.Recipes[recipe.Id].Ingredients)
+
+	request.Headers = map[string]string{"Accept": "text/markdown"}
+	response = suite.handle(request)
+	suite.Equal(http.StatusOK, response.StatusCode)
+	suite.Contains(response.Body, "- 1 kg Mehl")
+
+	recipe.Description = "Backen."
+	suite.repo.Recipes[recipe.Id] = recipe
+	suite.Equal(http.StatusUnprocessableEntity, suite.handle(request).StatusCode)
+
+	request.QueryStringParameters = map[string]string{"scale": "x"}
+	suite.Equal(http.StatusBadRequest, suite.handle(request).StatusCode)
+}
+
+// handle passes a request to the router.
+func (suite *ScaleTestSuite) handle(request events.APIGatewayProxyRequest) events.APIGatewayProxyResponse {
+	response, err := suite.router.handle(context.Background(), request)
+	suite.Nil(err)
+	return response
+}
@@ -0,0 +1,331 @@
+package main
+
+import (
+	"encoding/json"
+	"html"
+	"math"
+	"sort"
+	"strings"
+	"unicode"
+
+	core "github.com/tommzn/recipeboard-core"
+	model "github.com/tommzn/recipeboard-core/model"
+)
+
+const (
+
+	// searchIndexCollection is the document store collection for indexed recipes.
+	searchIndexCollection = "searchindex"
+
+	// searchIndexStateCollection is the document store collection for the state of the search index.
+	searchIndexStateCollection = "searchindexstate"
+
+	// searchIndexStateId is the id of the search index state document.
+	searchIndexStateId = "state"
+
+	// searchIndexVersion is the current version of indexed terms. It has to be increased if terms
+	// of existing recipes change, e.g. because of a changed stemmer, to rebuild the search index.
+	searchIndexVersion = 1
+
+	// snippetWords is the number of words before and after a match included in a highlighted snippet.
+	snippetWords = 8
+
+	// highlightStart is inserted before each match in a snippet.
+	highlightStart = "<mark>"
+
+	// highlightEnd is inserted after each match in a snippet.
+	highlightEnd = "</mark>"
+)
+
+// searchFieldWeights defines all searchable recipe fields and the weight of a match in each of them.
+var searchFieldWeights = map[string]float64{
+	"title":       3,
+	"ingredients": 2,
+	"description": 1,
+}
+
+// stopWords are common english words which are not indexed.
+var stopWords = map[string]bool{
+	"a": true, "an": true, "and": true, "are": true, "as": true, "at": true, "be": true, "by": true,
+	"can": true, "for": true, "from": true, "i": true, "in": true, "into": true, "is": true, "it": true,
+	"make": true, "of": true, "on": true, "or": true, "the": true, "to": true, "what": true, "with": true,
+}
+
+// newDocumentSearchIndex returns a search index which persists indexed recipes in passed document store.
+func newDocumentSearchIndex(store documentStore) searchIndex {
+	return &documentSearchIndex{store: store}
+}
+
+// index adds passed recipe to the index or replaces it if it's already indexed.
+func (idx *documentSearchIndex) index(recipe model.Recipe) error {
+
+	indexed := indexedRecipe{Id: recipe.Id, Terms: make(map[string]map[string]int)}
+	for field, text := range searchableFields(recipe) {
+		termCounts := make(map[string]int)
+		for _, term := range searchTerms(text) {
+			termCounts[term]++
+		}
+		if len(termCounts) > 0 {
+			indexed.Terms[field] = termCounts
+		}
+	}
+	return idx.store.set(searchIndexCollection, recipe.Id, indexed)
+}
+
+// remove deletes the recipe with passed id from the index.
+func (idx *documentSearchIndex) remove(id string) error {
+	return idx.store.delete(searchIndexCollection, id)
+}
+
+// search returns all recipes which contain at least one term of passed query. Relevance is calculated
+// by TF-IDF of all matching terms, weighted by the field they're found in. Recipes which contain more
+// query terms are ranked higher.
+func (idx *documentSearchIndex) search(query string, max int) ([]searchHit, error) {
+
+	queryTerms := uniqueStrings(searchTerms(query))
+	if len(queryTerms) == 0 {
+		return []searchHit{}, nil
+	}
+
+	documents, err := idx.store.list(searchIndexCollection)
+	if err != nil {
+		return nil, err
+	}
+
+	postings := make(map[string]map[string]map[string]int)
+	for id, document := range documents {
+		indexed := indexedRecipe{}
+		if err := json.Unmarshal(document, &indexed); err != nil {
+			return nil, err
+		}
+		for field, termCounts := range indexed.Terms {
+			for _, term := range queryTerms {
+				if count, ok := termCounts[term]; ok {
+					if _, ok := postings[term]; !ok {
+						postings[term] = make(map[string]map[string]int)
+					}
+					if _, ok := postings[term][id]; !ok {
+						postings[term][id] = make(map[string]int)
+					}
+					postings[term][id][field] = count
+				}
+			}
+		}
+	}
+
+	hitsById := make(map[string]*searchHit)
+	for _, term := range queryTerms {
+		idf := math.Log(1 + float64(len(documents))/float64(len(postings[term])))
+		for id, fieldCounts := range postings[term] {
+			hit, ok := hitsById[id]
+			if !ok {
+				hit = &searchHit{id: id}
+				hitsById[id] = hit
+			}
+			for field, count := range fieldCounts {
+				hit.score += idf * searchFieldWeights[field] * (1 + math.Log(float64(count)))
+			}
+			hit.terms = append(hit.terms, term)
+		}
+	}
+
+	hits := []searchHit{}
+	for _, hit := range hitsById {
+		hit.score = hit.score * float64(len(hit.terms)) / float64(len(queryTerms))
+		hits = append(hits, *hit)
+	}
+	sort.Slice(hits, func(i, j int) bool {
+		if hits[i].score != hits[j].score {
+			return hits[i].score > hits[j].score
+		}
+		return hits[i].id < hits[j].id
+	})
+	if len(hits) > max {
+		hits = hits[:max]
+	}
+	return hits, nil
+}
+
+// version returns the version the search index has been built with. It's zero if the index has never been built.
+func (idx *documentSearchIndex) version() (int, error) {
+
+	state := searchIndexState{}
+	if err := idx.store.get(searchIndexStateCollection, searchIndexStateId, &state); err != nil {
+		if _, ok := err.(*notFoundError); ok {
+			return 0, nil
+		}
+		return 0, err
+	}
+	return state.Version, nil
+}
+
+// setVersion persists the version the search index has been built with.
+func (idx *documentSearchIndex) setVersion(version int) error {
+	return idx.store.set(searchIndexStateCollection, searchIndexStateId, searchIndexState{Version: version})
+}
+
+// rebuildSearchIndex adds all recipes to passed search index if it hasn't been built with the current
+// index version, e.g. because recipes have been created before search has been available.
+// Recipes indexed by single changes before are indexed again.
+func rebuildSearchIndex(index searchIndex, recipeService core.RecipeService) error {
+
+	version, err := index.version()
+	if err != nil || version >= searchIndexVersion {
+		return err
+	}
+	recipes, err := listRecipes(recipeService, allRecipeTypes())
+	if err != nil {
+		return err
+	}
+	for _, recipe := range recipes {
+		if err := index.index(recipe); err != nil {
+			return err
+		}
+	}
+	return index.setVersion(searchIndexVersion)
+}
+
+// searchableFields returns texts of all searchable fields of passed recipe, mapped by field name.
+func searchableFields(recipe model.Recipe) map[string]string {
+	return map[string]string{
+		"title":       recipe.Title,
+		"ingredients": recipe.Ingredients,
+		"description": recipe.Description,
+	}
+}
+
+// searchTerms returns stemmed terms of all words in passed text, except stop words.
+func searchTerms(text string) []string {
+
+	terms := []string{}
+	for _, token := range textTokens(text) {
+		if token.term != "" {
+			terms = append(terms, token.term)
+		}
+	}
+	return terms
+}
+
+// textTokens splits passed text into words. Words are sequences of letters and digits. Term of each token
+// is the lower case stem of a word, or empty if it's a stop word.
+func textTokens(text string) []textToken {
+
+	tokens := []textToken{}
+	start := -1
+	for pos, char := range text + " " {
+		isWordChar := unicode.IsLetter(char) || unicode.IsDigit(char)
+		if isWordChar && start < 0 {
+			start = pos
+		}
+		if !isWordChar && start >= 0 {
+			word := strings.ToLower(text[start:pos])
+			term := ""
+			if !stopWords[word] {
+				term = stem(word)
+			}
+			tokens = append(tokens, textToken{term: term, start: start, end: pos})
+			start = -1
+		}
+	}
+	return tokens
+}
+
+// stem reduces passed lower case word to it's stem by removing common english suffixes,
+// so e.g. leek and leeks or slice and sliced end up in the same term.
+func stem(word string) string {
+
+	if len(word) <= 3 {
+		return word
+	}
+
+	switch {
+	case strings.HasSuffix(word, "ies") && len(word) > 4:
+		word = word[:len(word)-3] + "y"
+	case strings.HasSuffix(word, "sses"), strings.HasSuffix(word, "shes"), strings.HasSuffix(word, "ches"),
+		strings.HasSuffix(word, "xes"), strings.HasSuffix(word, "oes"):
+		word = word[:len(word)-2]
+	case strings.HasSuffix(word, "s") && !strings.HasSuffix(word, "ss") &&
+		!strings.HasSuffix(word, "us") && !strings.HasSuffix(word, "is"):
+		word = word[:len(word)-1]
+	}
+
+	switch {
+	case strings.HasSuffix(word, "ing") && len(word) > 5:
+		word = undouble(word[:len(word)-3])
+	case strings.HasSuffix(word, "ed") && len(word) > 4:
+		word = undouble(word[:len(word)-2])
+	case strings.HasSuffix(word, "e") && len(word) > 3:
+		word = word[:len(word)-1]
+	}
+	return word
+}
+
+// undouble removes a trailing double consonant, e.g. chopp becomes chop.
+func undouble(word string) string {
+
+	length := len(word)
+	if length > 2 && word[length-1] == word[length-2] && !strings.ContainsRune("aeiouls", rune(word[length-1])) {
+		return word[:length-1]
+	}
+	return word
+}
+
+// highlightMatches returns a snippet of passed text around the first occurrence of given terms.
+// Text is HTML escaped and all matches in a snippet are wrapped in mark elements.
+// It returns an empty string if none of given terms occurs in passed text.
+func highlightMatches(text string, terms []string) string {
+
+	tokens := textTokens(text)
+	first := -1
+	for idx, token := range tokens {
+		if token.term != "" && containsString(terms, token.term) {
+			first = idx
+			break
+		}
+	}
+	if first < 0 {
+		return ""
+	}
+
+	startToken := first - snippetWords
+	if startToken < 0 {
+		startToken = 0
+	}
+	endToken := first + snippetWords
+	if endToken >= len(tokens) {
+		endToken = len(tokens) - 1
+	}
+
+	snippet := strings.Builder{}
+	if startToken > 0 {
+		snippet.WriteString("… ")
+	}
+	pos := tokens[startToken].start
+	for _, token := range tokens[startToken : endToken+1] {
+		snippet.WriteString(html.EscapeString(text[pos:token.start]))
+		if token.term != "" && containsString(terms, token.term) {
+			snippet.WriteString(highlightStart + html.EscapeString(text[token.start:token.end]) + highlightEnd)
+		} else {
+			snippet.WriteString(html.EscapeString(text[token.start:token.end]))
+		}
+		pos = token.end
+	}
+	if endToken < len(tokens)-1 {
+		snippet.WriteString(" …")
+	} else {
+		snippet.WriteString(html.EscapeString(strings.TrimSpace(text[pos:])))
+	}
+	return snippet.String()
+}
+
+// uniqueStrings returns all distinct values of passed list, keeping their order.
+func uniqueStrings(values []string) []string {
+
+	unique := []string{}
+	for _, value := range values {
+		if !containsString(unique, value) {
+			unique = append(unique, value)
+		}
+	}
+	return unique
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+	model "github.com/tommzn/recipeboard-core/model"
+)
+
+// Test suite for recipe search.
+type SearchTestSuite struct {
+	suite.Suite
+}
+
+func TestSearchTestSuite(t *testing.T) {
+	suite.Run(t, new(SearchTestSuite))
+}
+
+// Test splitting texts into stemmed search terms.
+func (suite *SearchTestSuite) TestSearchTerms() {
+
+	suite.Equal([]string{"leek", "potato", "soup"}, searchTerms("What can I make with Leeks, potatoes & soup?"))
+	suite.Equal([]string{"slic", "slic", "chop", "berry", "bak", "bak"}, searchTerms("slice sliced chopped berries baking bake"))
+	suite.Equal([]string{"glass", "hummus", "crèm", "brûlé"}, searchTerms("glass hummus Crème-Brûlée"))
+	suite.Len(searchTerms("with the and"), 0)
+}
+
+// Test ranking of search results.
+func (suite *SearchTestSuite) TestSearch() {
+
+	index := newDocumentSearchIndex(newMemoryDocumentStore())
+	suite.Nil(index.index(model.Recipe{Id: "1", Title: "Leek Soup", Ingredients: "2 leeks\n3 potatoes"}))
+	suite.Nil(index.index(model.Recipe{Id: "2", Title: "Potato Salad", Ingredients: "1kg potatoes", Description: "Serve with fried leek."}))
+	suite.Nil(index.index(model.Recipe{Id: "3", Title: "Apple Pie", Ingredients: "Apples"}))
+
+	hits, err := index.search("leeks", 10)
+	suite.Nil(err)
+	suite.Equal([]string{"1", "2"}, searchHitIds(hits))
+	suite.Equal([]string{"leek"}, hits[0].terms)
+
+	hits, err = index.search("potato salad", 10)
+	suite.Nil(err)
+	suite.Equal([]string{"2", "1"}, searchHitIds(hits))
+
+	hits, err = index.search("potato salad", 1)
+	suite.Nil(err)
+	suite.Equal([]string{"2"}, searchHitIds(hits))
+
+	hits, err = index.search("the", 10)
+	suite.Nil(err)
+	suite.Len(hits, 0)
+
+	suite.Nil(index.index(model.Recipe{Id: "1", Title: "Onion Soup", Ingredients: "Onions"}))
+	hits, err = index.search("leek", 10)
+	suite.Nil(err)
+	suite.Equal([]string{"2"}, searchHitIds(hits))
+
+	suite.Nil(index.remove("2"))
+	hits, err = index.search("leek", 10)
+	suite.Nil(err)
+	suite.Len(hits, 0)
+}
+
+// Test search index is rebuilt once for each index version.
+func (suite *SearchTestSuite) TestRebuildSearchIndex() {
+
+	repo := repositoryForTest()
+	recipe := recipeForTest()
+	recipe.Title = "Leek Soup"
+	repo.Recipes[recipe.Id] = recipe
+	recipeService := recipeManagerForTest(repo, publisherForTest(), loggerForTest())
+
+	index := newDocumentSearchIndex(newMemoryDocumentStore())
+	suite.Nil(index.index(model.Recipe{Id: "1", Title: "Potato Salad"}))
+	version, err := index.version()
+	suite.Nil(err)
+	suite.Equal(0, version)
+
+	suite.Nil(rebuildSearchIndex(index, recipeService))
+	version, err = index.version()
+	suite.Nil(err)
+	suite.Equal(searchIndexVersion, version)
+	hits, err := index.search("leek", 10)
+	suite.Nil(err)
+	suite.Equal([]string{recipe.Id}, searchHitIds(hits))
+
+	suite.Nil(index.remove(recipe.Id))
+	suite.Nil(rebuildSearchIndex(index, recipeService))
+	hits, err = index.search("leek", 10)
+	suite.Nil(err)
+	suite.Len(hits, 0)
+}
+
+// Test highlighting of search terms in snippets.
+func (suite *SearchTestSuite) TestHighlightMatches() {
+
+	suite.Equal("2 <mark>leeks</mark> &amp; <mark>Leek</mark> greens", highlightMatches("2 leeks & Leek greens", []string{"leek"}))
+	suite.Equal("", highlightMatches("2 onions", []string{"leek"}))
+	suite.Equal("<mark>Leek</mark> &lt;b&gt;soup&lt;/b&gt;", highlightMatches("Leek <b>soup</b>", []string{"leek"}))
+
+	text := "one two three four five six seven eight nine ten leek eleven twelve thirteen fourteen fifteen sixteen seventeen eighteen nineteen"
+	suite.Equal("… three four five six seven eight nine ten <mark>leek</mark> eleven twelve thirteen fourteen fifteen sixteen seventeen eighteen …",
+		highlightMatches(text, []string{"leek"}))
+}
+
+// searchHitIds returns ids of all passed search hits.
+func searchHitIds(hits []searchHit) []string {
+	ids := []string{}
+	for _, hit := range hits {
+		ids = append(ids, hit.id)
+	}
+	return ids
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	log "github.com/tommzn/go-log"
+	core "github.com/tommzn/recipeboard-core"
+	model "github.com/tommzn/recipeboard-core/model"
+)
+
+// newIndexingRecipeService wraps passed recipe service to add, update or remove recipes in given search index.
+func newIndexingRecipeService(recipeService core.RecipeService, searchIndex searchIndex, logger log.Logger) core.RecipeService {
+	return &indexingRecipeService{
+		RecipeService: recipeService,
+		searchIndex:   searchIndex,
+		logger:        logger,
+	}
+}
+
+// Create a new recipe and add it to the search index.
+// Indexing errors are only logged, because the recipe itself has been persisted successfully.
+func (service *indexingRecipeService) Create(recipe model.Recipe) (model.Recipe, error) {
+
+	newRecipe, err := service.RecipeService.Create(recipe)
+	if err == nil {
+		service.logIndexError(service.searchIndex.index(newRecipe), newRecipe.Id)
+	}
+	return newRecipe, err
+}
+
+// Update an existing recipe and replace it in the search index.
+func (service *indexingRecipeService) Update(recipe model.Recipe) error {
+
+	err := service.RecipeService.Update(recipe)
+	if err == nil {
+		service.logIndexError(service.searchIndex.index(recipe), recipe.Id)
+	}
+	return err
+}
+
+// Delete passed recipe and remove it from the search index.
+func (service *indexingRecipeService) Delete(recipe model.Recipe) error {
+
+	err := service.RecipeService.Delete(recipe)
+	if err == nil {
+		service.logIndexError(service.searchIndex.remove(recipe.Id), recipe.Id)
+	}
+	return err
+}
+
+// newCreationTimeRecipeService wraps passed recipe service to keep the creation time of created recipes.
+func newCreationTimeRecipeService(recipeService core.RecipeService) core.RecipeService {
+	return &creationTimeRecipeService{RecipeService: recipeService}
+}
+
+// Create a new recipe. Wrapped recipe service always sets current time as creation time, so if passed recipe
+// has a creation time, e.g. because it's imported from an export, the created recipe is updated with it.
+func (service *creationTimeRecipeService) Create(recipe model.Recipe) (model.Recipe, error) {
+
+	newRecipe, err := service.RecipeService.Create(recipe)
+	if err != nil || recipe.CreatedAt.IsZero() || newRecipe.CreatedAt.Equal(recipe.CreatedAt) {
+		return newRecipe, err
+	}
+	newRecipe.CreatedAt = recipe.CreatedAt
+	return newRecipe, service.RecipeService.Update(newRecipe)
+}
+
+// logIndexError writes passed search index error to the log.
+func (service *indexingRecipeService) logIndexError(err error, recipeId string) {
+	if err != nil && service.logger != nil {
+		service.logger.Errorf("Unable to update search index for recipe %s: %s", recipeId, err)
+	}
+}
@@ -0,0 +1,468 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/aws/aws-lambda-go/events"
+	core "github.com/tommzn/recipeboard-core"
+)
+
+// otherCategory is used for shopping list items which doesn't match any aisle category.
+const otherCategory = "other"
+
+// aisleCategories contains all aisle categories in the order they're listed on a shopping list, with their label.
+var aisleCategories = []struct {
+	name  string
+	label string
+}{
+	{"produce", "Produce"}, {"bakery", "Bakery"}, {"meat", "Meat"}, {"seafood", "Seafood"}, {"dairy", "Dairy & Eggs"},
+	{"baking", "Baking"}, {"pantry", "Pantry"}, {"spices", "Spices"}, {"frozen", "Frozen"}, {"beverages", "Beverages"},
+	{otherCategory, "Other"},
+}
+
+// aisleKeywords maps lower case english and german ingredient names to their aisle category.
+var aisleKeywords = map[string]string{
+	"onion": "produce", "garlic": "produce", "tomato": "produce", "potato": "produce", "carrot": "produce",
+	"celery": "produce", "lettuce": "produce", "spinach": "produce", "cucumber": "produce", "zucchini": "produce",
+	"eggplant": "produce", "bell pepper": "produce", "mushroom": "produce", "broccoli": "produce", "cabbage": "produce",
+	"leek": "produce", "apple": "produce", "banana": "produce", "lemon": "produce", "lime": "produce", "orange": "produce",
+	"berry": "produce", "parsley": "produce", "basil": "produce", "cilantro": "produce", "chive": "produce",
+	"ginger": "produce", "avocado": "produce", "zwiebel": "produce", "knoblauch": "produce", "tomate": "produce",
+	"kartoffel": "produce", "karotte": "produce", "möhre": "produce", "sellerie": "produce", "salat": "produce",
+	"spinat": "produce", "gurke": "produce", "paprika": "produce", "pilz": "produce", "champignon": "produce",
+	"brokkoli": "produce", "kohl": "produce", "lauch": "produce", "apfel": "produce", "äpfel": "produce",
+	"banane": "produce", "zitrone": "produce", "limette": "produce", "beere": "produce", "petersilie": "produce",
+	"basilikum": "produce", "schnittlauch": "produce", "ingwer": "produce",
+	"bread": "bakery", "roll": "bakery", "bun": "bakery", "tortilla": "bakery", "baguette": "bakery",
+	"brot": "bakery", "brötchen": "bakery", "toast": "bakery",
+	"beef": "meat", "pork": "meat", "chicken": "meat", "turkey": "meat", "bacon": "meat", "ham": "meat",
+	"sausage": "meat", "mince": "meat", "lamb": "meat", "rind": "meat", "schwein": "meat", "hähnchen": "meat",
+	"huhn": "meat", "pute": "meat", "speck": "meat", "schinken": "meat", "wurst": "meat", "hackfleisch": "meat",
+	"fleisch": "meat", "lamm": "meat",
+	"fish": "seafood", "salmon": "seafood", "tuna": "seafood", "shrimp": "seafood", "prawn": "seafood",
+	"cod": "seafood", "fisch": "seafood", "lachs": "seafood", "thunfisch": "seafood", "garnele": "seafood",
+	"milk": "dairy", "butter": "dairy", "cheese": "dairy", "cream": "dairy", "yogurt": "dairy", "yoghurt": "dairy",
+	"egg": "dairy", "milch": "dairy", "sahne": "dairy", "käse": "dairy", "quark": "dairy", "joghurt": "dairy",
+	"schmand": "dairy", "ei": "dairy", "mozzarella": "dairy", "parmesan": "dairy",
+	"flour": "baking", "sugar": "baking", "baking powder": "baking", "baking soda": "baking", "yeast": "baking",
+	"cocoa": "baking", "chocolate": "baking", "vanilla": "baking", "cornstarch": "baking", "almond": "baking",
+	"nut": "baking", "raisin": "baking", "mehl": "baking", "zucker": "baking", "backpulver": "baking",
+	"natron": "baking", "hefe": "baking", "kakao": "baking", "schokolade": "baking", "vanille": "baking",
+	"stärke": "baking", "mandel": "baking", "nüsse": "baking", "rosine": "baking",
+	"oil": "pantry", "vinegar": "pantry", "rice": "pantry", "pasta": "pantry", "noodle": "pantry",
+	"spaghetti": "pantry", "bean": "pantry", "lentil": "pantry", "stock": "pantry", "broth": "pantry",
+	"honey": "pantry", "mustard": "pantry", "ketchup": "pantry", "soy sauce": "pantry", "oat": "pantry",
+	"tomato paste": "pantry", "peanut butter": "pantry", "öl": "pantry", "essig": "pantry", "reis": "pantry",
+	"nudel": "pantry", "bohne": "pantry", "linse": "pantry", "brühe": "pantry", "honig": "pantry", "senf": "pantry",
+	"haferflocken": "pantry", "tomatenmark": "pantry", "erdnussbutter": "pantry",
+	"salt": "spices", "pepper": "spices", "cinnamon": "spices", "cumin": "spices", "oregano": "spices",
+	"thyme": "spices", "rosemary": "spices", "nutmeg": "spices", "curry": "spices", "chili": "spices",
+	"salz": "spices", "pfeffer": "spices", "zimt": "spices", "kreuzkümmel": "spices", "thymian": "spices",
+	"rosmarin": "spices", "muskat": "spices", "paprikapulver": "spices",
+	"frozen": "frozen", "ice cream": "frozen", "tiefkühl": "frozen",
+	"wine": "beverages", "beer": "beverages", "juice": "beverages", "coffee": "beverages",
+	"tea": "beverages", "wein": "beverages", "bier": "beverages", "saft": "beverages", "kaffee": "beverages", "tee": "beverages",
+}
+
+// parseRequest will extract recipes from request body and the unit system from units query param.
+func (handler *apiGatewayShoppingListRequestHandler) parseRequest(request events.APIGatewayProxyRequest) error {
+
+	units, err := parseUnitSystem(request.QueryStringParameters)
+	if err != nil {
+		return err
+	}
+	handler.units = units
+
+	recipes, err := parseShoppingListRecipes(request.Body, handler.maxRecipes)
+	if err != nil {
+		return err
+	}
+	handler.recipes = recipes
+	return nil
+}
+
+// handle requests to create a shopping list for multiple recipes, rendered as JSON or Markdown checklist.
+func (handler *apiGatewayShoppingListRequestHandler) handle() (*string, error) {
+
+	list, err := buildShoppingList(handler.recipeService, handler.recipes, handler.units)
+	if err != nil {
+		return nil, err
+	}
+	if handler.mediaType == markdownMediaType {
+		return renderShoppingListMarkdown(list), nil
+	}
+	return marshalShoppingList(list)
+}
+
+// marshalShoppingList returns passed shopping list as JSON string.
+func marshalShoppingList(list *shoppingList) (*string, error) {
+	b, err := json.Marshal(list)
+	jsonStr := string(b)
+	return &jsonStr, err
+}
+
+// mediaTypes returns all media types a shopping list can be rendered in.
+func (handler *apiGatewayShoppingListRequestHandler) mediaTypes() []string {
+	return shoppingListMediaTypes
+}
+
+// useMediaType defines the media type the shopping list is rendered in.
+func (handler *apiGatewayShoppingListRequestHandler) useMediaType(mediaType string) {
+	handler.mediaType = mediaType
+}
+
+// parseShoppingListRecipes decodes passed request body, which is a list of recipes or an object with recipes
+// as property. Recipes can be passed as id or as object with an id and an optional scale factor.
+// Scale factors of duplicate recipes are added. It fails if there're no recipes or more recipes than allowed.
+func parseShoppingListRecipes(requestBody string, maxRecipes int) ([]shoppingListRecipe, error) {
+
+	items := []interface{}{}
+	if err := decodeJson(requestBody, &items); err != nil {
+		body := struct {
+			Recipes []interface{} `json:"recipes"`
+		}{}
+		if err := decodeJson(requestBody, &body); err != nil {
+			return nil, newBadRequestError("Request body is not a list of recipes", err)
+		}
+		items = body.Recipes
+	}
+
+	recipes := []shoppingListRecipe{}
+	fieldErrors := []fieldError{}
+	for idx, item := range items {
+		field := fmt.Sprintf("recipes[%d]", idx)
+		recipe := shoppingListRecipe{Scale: 1}
+		switch value := item.(type) {
+		case string:
+			recipe.Id = value
+		case map[string]interface{}:
+			recipe.Id, _ = value["id"].(string)
+			if scaleValue, ok := value["scale"]; ok {
+				scale, err := quantityFromValue(scaleValue)
+				if err != nil || scale == nil {
+					fieldErrors = append(fieldErrors, fieldError{Field: field + ".scale", Message: "must be a positive number"})
+					continue
+				}
+				recipe.Scale = *scale
+			}
+		default:
+			fieldErrors = append(fieldErrors, fieldError{Field: field, Message: "must be a recipe id or an object"})
+			continue
+		}
+		if strings.TrimSpace(recipe.Id) == "" {
+			fieldErrors = append(fieldErrors, fieldError{Field: field + ".id", Message: "is required"})
+			continue
+		}
+		recipes = addShoppingListRecipe(recipes, recipe)
+	}
+	if len(fieldErrors) > 0 {
+		return nil, newFieldValidationError(fieldErrors)
+	}
+	if len(recipes) == 0 {
+		return nil, newBadRequestError("Request body doesn't contain any recipes.", nil)
+	}
+	if len(recipes) > maxRecipes {
+		return nil, newBadRequestError(fmt.Sprintf("Too many recipes: %d, max: %d", len(recipes), maxRecipes), nil)
+	}
+	return recipes, nil
+}
+
+// addShoppingListRecipe appends passed recipe to given list. If it's already part of the list, it's scale factor is added.
+func addShoppingListRecipe(recipes []shoppingListRecipe, recipe shoppingListRecipe) []shoppingListRecipe {
+
+	for idx := range recipes {
+		if recipes[idx].Id == recipe.Id {
+			recipes[idx].Scale += recipe.Scale
+			return recipes
+		}
+	}
+	return append(recipes, recipe)
+}
+
+// buildShoppingList loads all passed recipes, scales their ingredients and merges identical ingredients.
+// Volumes and masses are normalized before they're added, ingredients are grouped by aisle category.
+// If a unit system is passed, all quantities are converted to it. Not existing recipes are reported by a validation error.
+func buildShoppingList(recipeService core.RecipeService, recipes []shoppingListRecipe, units string) (*shoppingList, error) {
+
+	list := &shoppingList{Recipes: []shoppingListRecipe{}, Categories: []shoppingListCategory{}}
+	entries := []*shoppingListEntry{}
+	fieldErrors := []fieldError{}
+	for idx, listRecipe := range recipes {
+		recipe, err := recipeService.Get(listRecipe.Id)
+		if err != nil {
+			if _, ok := fromServiceError(err).(*notFoundError); ok {
+				fieldErrors = append(fieldErrors, fieldError{Field: fmt.Sprintf("recipes[%d].id", idx), Message: "must be an existing recipe"})
+				continue
+			}
+			return nil, err
+		}
+		listRecipe.Title = recipe.Title
+		list.Recipes = append(list.Recipes, listRecipe)
+		for _, parsed := range parseIngredients(recipe.Ingredients) {
+			entries = addShoppingListIngredient(entries, scaleIngredient(parsed, listRecipe.Scale), recipe.Id)
+		}
+	}
+	if len(fieldErrors) > 0 {
+		return nil, newFieldValidationError(fieldErrors)
+	}
+
+	itemsByCategory := make(map[string][]shoppingListItem)
+	for _, entry := range mergeUnquantifiedEntries(entries) {
+		item := entry.item(units)
+		category := aisleCategory(entry.name)
+		itemsByCategory[category] = append(itemsByCategory[category], item)
+	}
+	for _, category := range aisleCategories {
+		items, ok := itemsByCategory[category.name]
+		if !ok {
+			continue
+		}
+		sort.SliceStable(items, func(i, j int) bool {
+			return strings.ToLower(items[i].Name) < strings.ToLower(items[j].Name)
+		})
+		list.Categories = append(list.Categories, shoppingListCategory{Name: category.name, Label: category.label, Items: items})
+	}
+	return list, nil
+}
+
+// addShoppingListIngredient adds passed ingredient of given recipe to a matching entry, or appends a new entry.
+// Entries match if they have the same name and the same dimension, e.g. volume or mass. Volumes of
+// ingredients with a known density are converted to masses, so cups and grams of flour are merged.
+func addShoppingListIngredient(entries []*shoppingListEntry, parsed ingredient, recipeId string) []*shoppingListEntry {
+
+	if parsed.Name == "" {
+		return entries
+	}
+	quantity := 0.0
+	if parsed.QuantityMax != nil {
+		quantity = *parsed.QuantityMax
+	} else if parsed.Quantity != nil {
+		quantity = *parsed.Quantity
+	}
+	density, hasDensity := ingredientDensity(parsed.Name)
+	dimension, amount := "none", 0.0
+	switch {
+	case parsed.Quantity == nil:
+	case massUnits[parsed.Unit] > 0:
+		dimension, amount = "mass", quantity*massUnits[parsed.Unit]
+	case volumeUnits[parsed.Unit] > 0 && hasDensity:
+		dimension, amount = "mass", quantity*volumeUnits[parsed.Unit]*density/cupSize
+	case volumeUnits[parsed.Unit] > 0:
+		dimension, amount = "volume", quantity*volumeUnits[parsed.Unit]
+	default:
+		dimension, amount = "unit:"+parsed.Unit, quantity
+	}
+
+	key := shoppingListKey(parsed.Name) + "|" + dimension
+	for _, entry := range entries {
+		if entry.key == key {
+			entry.amount += amount
+			entry.unitQuantity += quantity
+			entry.sameUnit = entry.sameUnit && entry.unit == parsed.Unit
+			entry.addRecipe(recipeId)
+			return entries
+		}
+	}
+	return append(entries, &shoppingListEntry{
+		key:          key,
+		name:         parsed.Name,
+		dimension:    dimension,
+		unit:         parsed.Unit,
+		sameUnit:     true,
+		unitQuantity: quantity,
+		amount:       amount,
+		density:      density,
+		recipeIds:    []string{recipeId},
+	})
+}
+
+// mergeUnquantifiedEntries merges entries without quantity, e.g. "Salt, to taste", into entries
+// with the same name and a quantity. Order of all other entries is kept.
+func mergeUnquantifiedEntries(entries []*shoppingListEntry) []*shoppingListEntry {
+
+	merged := []*shoppingListEntry{}
+	for _, entry := range entries {
+		if entry.dimension == "none" {
+			if quantified := findQuantifiedEntry(entries, entry); quantified != nil {
+				for _, recipeId := range entry.recipeIds {
+					quantified.addRecipe(recipeId)
+				}
+				continue
+			}
+		}
+		merged = append(merged, entry)
+	}
+	return merged
+}
+
+// findQuantifiedEntry returns an entry with a quantity and the same name as passed entry, or nil if there's none.
+func findQuantifiedEntry(entries []*shoppingListEntry, entry *shoppingListEntry) *shoppingListEntry {
+
+	name := shoppingListKey(entry.name)
+	for _, candidate := range entries {
+		if candidate.dimension != "none" && shoppingListKey(candidate.name) == name {
+			return candidate
+		}
+	}
+	return nil
+}
+
+// addRecipe adds passed recipe id to the recipes of an entry, if it's not already part of it.
+func (entry *shoppingListEntry) addRecipe(recipeId string) {
+	if !containsString(entry.recipeIds, recipeId) {
+		entry.recipeIds = append(entry.recipeIds, recipeId)
+	}
+}
+
+// item returns the shopping list item for an entry. Quantities of a single unit are added and promoted to a larger
+// unit if possible. Mixed units are expressed in the unit system of the first unit. If a unit system is passed,
+// quantities are converted to it.
+func (entry *shoppingListEntry) item(units string) shoppingListItem {
+
+	parsed := ingredient{Name: entry.name}
+	switch {
+	case entry.dimension == "none":
+	case entry.sameUnit:
+		quantity := entry.unitQuantity
+		parsed.Quantity = &quantity
+		parsed.Unit = entry.unit
+		promoteUnit(&parsed)
+	default:
+		amount := entry.amount
+		isVolume := volumeUnits[entry.unit] > 0
+		if isVolume && entry.dimension == "mass" {
+			amount = amount * cupSize / entry.density
+		}
+		targetUnits := usMassUnits
+		switch {
+		case isVolume && metricUnits[entry.unit]:
+			targetUnits = metricVolumeUnits
+		case isVolume:
+			targetUnits = usVolumeUnits
+		case metricUnits[entry.unit]:
+			targetUnits = metricMassUnits
+		}
+		unit := selectConversionUnit(amount, targetUnits)
+		quantity := roundConvertedQuantity(amount/unit.size, unit)
+		parsed.Quantity = &quantity
+		parsed.Unit = unit.name
+	}
+	if units != "" {
+		parsed = convertIngredient(parsed, units)
+	}
+	return shoppingListItem{
+		Text:     formatIngredient(parsed),
+		Quantity: parsed.Quantity,
+		Unit:     parsed.Unit,
+		Name:     parsed.Name,
+		Recipes:  entry.recipeIds,
+	}
+}
+
+// shoppingListKey normalizes passed ingredient name to merge ingredients of different recipes.
+// Names are compared case insensitive and english plurals of the last word are removed, e.g. tomatoes to tomato.
+func shoppingListKey(name string) string {
+
+	words := strings.Fields(strings.ToLower(name))
+	if len(words) == 0 {
+		return ""
+	}
+	words[len(words)-1] = singularize(words[len(words)-1])
+	return strings.Join(words, " ")
+}
+
+// singularize removes common english plural suffixes from passed word.
+func singularize(word string) string {
+
+	switch {
+	case len(word) > 4 && strings.HasSuffix(word, "oes"):
+		return strings.TrimSuffix(word, "es")
+	case len(word) > 4 && strings.HasSuffix(word, "ies"):
+		return strings.TrimSuffix(word, "ies") + "y"
+	case len(word) > 3 && strings.HasSuffix(word, "s") && !strings.HasSuffix(word, "ss"):
+		return strings.TrimSuffix(word, "s")
+	default:
+		return word
+	}
+}
+
+// aisleCategory returns the aisle category of passed ingredient name. Keywords of multiple words are preferred,
+// e.g. "tomato paste", followed by keywords matching the last word, e.g. "broth" in "chicken broth".
+// Keywords can be part of compound words, e.g. "Weizenmehl", or have a short plural suffix, e.g. "Zwiebeln".
+func aisleCategory(name string) string {
+
+	name = strings.ToLower(name)
+	if keyword := longestKeyword(func(keyword string) bool {
+		return strings.Contains(keyword, " ") && strings.Contains(name, keyword)
+	}); keyword != "" {
+		return aisleKeywords[keyword]
+	}
+
+	words := strings.FieldsFunc(name, func(r rune) bool {
+		return !unicode.IsLetter(r)
+	})
+	for idx := len(words) - 1; idx >= 0; idx-- {
+		if keyword := longestKeyword(func(keyword string) bool {
+			return !strings.Contains(keyword, " ") && matchesKeyword(words[idx], keyword)
+		}); keyword != "" {
+			return aisleKeywords[keyword]
+		}
+	}
+	return otherCategory
+}
+
+// longestKeyword returns the longest aisle keyword passed function matches, or an empty string if there's none.
+// Alphabetical order is used for keywords of the same length to get stable results.
+func longestKeyword(matches func(keyword string) bool) string {
+
+	match := ""
+	for keyword := range aisleKeywords {
+		if len(keyword) < len(match) || (len(keyword) == len(match) && keyword > match) {
+			continue
+		}
+		if matches(keyword) {
+			match = keyword
+		}
+	}
+	return match
+}
+
+// matchesKeyword returns true if passed word ends with given keyword, followed by at most two letters
+// for plurals. Short keywords have to be at the beginning of a word, to avoid matches inside of other words.
+func matchesKeyword(word, keyword string) bool {
+
+	idx := strings.LastIndex(word, keyword)
+	if idx < 0 || len(word)-idx-len(keyword) > 2 {
+		return false
+	}
+	return idx == 0 || len([]rune(keyword)) >= 4
+}
+
+// renderShoppingListMarkdown returns passed shopping list as Markdown checklist, grouped by aisle category.
+func renderShoppingListMarkdown(list *shoppingList) *string {
+
+	markdown := strings.Builder{}
+	markdown.WriteString("# Shopping List\n")
+	if len(list.Recipes) > 0 {
+		markdown.WriteString("\n")
+		for _, recipe := range list.Recipes {
+			title := escapeMarkdown(recipe.Title)
+			if recipe.Scale != 1 {
+				title += " (x" + formatQuantity(recipe.Scale) + ")"
+			}
+			markdown.WriteString("- " + title + "\n")
+		}
+	}
+	for _, category := range list.Categories {
+		markdown.WriteString(fmt.Sprintf("\n## %s\n\n", category.Label))
+		for _, item := range category.Items {
+			markdown.WriteString("- [ ] " + escapeMarkdown(item.Text) + "\n")
+		}
+	}
+	body := markdown.String()
+	return &body
+}
@@ -0,0 +1,155 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/stretchr/testify/suite"
+	"github.com/tommzn/recipeboard-core/mock"
+	model "github.com/tommzn/recipeboard-core/model"
+)
+
+// Test suite for shopping lists.
+type ShoppingListTestSuite struct {
+	suite.Suite
+	repo    *mock.RepositoryMock
+	factory *requestHandlerFactory
+	router  LambdaRequestHandler
+}
+
+func TestShoppingListTestSuite(t *testing.T) {
+	suite.Run(t, new(ShoppingListTestSuite))
+}
+
+// Setup test.
+func (suite *ShoppingListTestSuite) SetupTest() {
+	suite.repo = repositoryForTest()
+	suite.factory = factoryForTest(suite.repo, publisherForTest(), loggerForTest())
+	suite.router = routerWithFactoryForTest(suite.factory, loggerForTest())
+}
+
+// Test merging ingredients of multiple recipes.
+func (suite *ShoppingListTestSuite) TestBuildShoppingList() {
+
+	cake := suite.recipeForTest("Cake", "250 g flour\n1/2 cup sugar\n2 eggs\n4 tbsp butter\nSalt")
+	cookies := suite.recipeForTest("Cookies", "1 cup flour\n100 g sugar\n1 egg\n4 tbsp butter\n1 pinch salt\n2 tomatoes")
+
+	list, err := buildShoppingList(suite.factory.getRecipeService(), []shoppingListRecipe{{Id: cake.Id, Scale: 2}, {Id: cookies.Id, Scale: 1}}, "")
+	suite.Nil(err)
+	suite.Equal("Cake", list.Recipes[0].Title)
+
+	items := suite.itemsByText(list)
+	suite.Contains(items, "625 g flour")
+	suite.Contains(items, "1 1/2 cups sugar")
+	suite.Contains(items, "5 eggs")
+	suite.Contains(items, "3/4 cup butter")
+	suite.Contains(items, "1 pinch salt")
+	suite.Contains(items, "2 tomatoes")
+	suite.Len(items, 6)
+	suite.ElementsMatch([]string{cake.Id, cookies.Id}, items["1 pinch salt"].Recipes)
+
+	categories := []string{}
+	for _, category := range list.Categories {
+		categories = append(categories, category.Name)
+	}
+	suite.Equal([]string{"produce", "dairy", "baking", "spices"}, categories)
+
+	list, err = buildShoppingList(suite.factory.getRecipeService(), []shoppingListRecipe{{Id: cookies.Id, Scale: 1}}, usUnitSystem)
+	suite.Nil(err)
+	suite.Contains(suite.itemsByText(list), "1/2 cup sugar")
+
+	salad := suite.recipeForTest("Salad", "1 cup sugar snap peas\n2 tbsp rice vinegar")
+	list, err = buildShoppingList(suite.factory.getRecipeService(), []shoppingListRecipe{{Id: salad.Id, Scale: 1}}, "")
+	suite.Nil(err)
+	suite.Contains(suite.itemsByText(list), "1 cup sugar snap peas")
+	suite.Contains(suite.itemsByText(list), "2 tbsp rice vinegar")
+
+	_, err = buildShoppingList(suite.factory.getRecipeService(), []shoppingListRecipe{{Id: "xxx", Scale: 1}}, "")
+	suite.NotNil(err)
+	_, ok := err.(*validationError)
+	suite.True(ok)
+}
+
+// Test parsing recipes of shopping list requests.
+func (suite *ShoppingListTestSuite) TestParseShoppingListRecipes() {
+
+	recipes, err := parseShoppingListRecipes(`["a", {"id": "b", "scale": "1/2"}, {"id": "a", "scale": 2}]`, 10)
+	suite.Nil(err)
+	suite.Equal([]shoppingListRecipe{{Id: "a", Scale: 3}, {Id: "b", Scale: 0.5}}, recipes)
+
+	recipes, err = parseShoppingListRecipes(`{"recipes": ["a"]}`, 10)
+	suite.Nil(err)
+	suite.Len(recipes, 1)
+
+	for _, body := range []string{`xxx`, `[]`, `[1]`, `[{"scale": 2}]`, `[{"id": "a", "scale": 0}]`, `["a", "b"]`} {
+		_, err = parseShoppingListRecipes(body, 1)
+		suite.NotNil(err, body)
+	}
+}
+
+// Test assigning aisle categories.
+func (suite *ShoppingListTestSuite) TestAisleCategory() {
+
+	suite.Equal("produce", aisleCategory("Zwiebeln"))
+	suite.Equal("baking", aisleCategory("Weizenmehl"))
+	suite.Equal("pantry", aisleCategory("chicken broth"))
+	suite.Equal("pantry", aisleCategory("tomato paste"))
+	suite.Equal("produce", aisleCategory("red bell pepper"))
+	suite.Equal("dairy", aisleCategory("large eggs"))
+	suite.Equal("produce", aisleCategory("eggplant"))
+	suite.Equal("pantry", aisleCategory("rolled oats"))
+	suite.Equal("other", aisleCategory("Gelatine"))
+}
+
+// Test shopping list requests.
+func (suite *ShoppingListTestSuite) TestShoppingListRequest() {
+
+	recipe := suite.recipeForTest("Cake", "250 g Mehl\n2 Eier")
+
+	request := apiGatewayRequestWithPathForTest(http.MethodPost, "/shopping-lists")
+	request.Body = `{"recipes": [{"id": "` + recipe.Id + `", "scale": 2}]}`
+	response := suite.handle(request)
+	suite.Equal(http.StatusOK, response.StatusCode)
+	list := shoppingList{}
+	suite.Nil(json.Unmarshal([]byte(response.Body), &list))
+	suite.Len(list.Categories, 2)
+	suite.Equal("500 g Mehl", list.Categories[1].Items[0].Text)
+
+	request.Headers = map[string]string{"Accept": "text/markdown"}
+	response = suite.handle(request)
+	suite.Equal(http.StatusOK, response.StatusCode)
+	suite.Equal("# Shopping List\n\n- Cake (x2)\n\n## Dairy & Eggs\n\n- [ ] 4 Eier\n\n## Baking\n\n- [ ] 500 g Mehl\n", response.Body)
+
+	request.Body = `["xxx"]`
+	suite.Equal(http.StatusUnprocessableEntity, suite.handle(request).StatusCode)
+}
+
+// recipeForTest creates a recipe with passed title and ingredients.
+func (suite *ShoppingListTestSuite) recipeForTest(title, ingredients string) model.Recipe {
+	recipe := recipeForTest()
+	recipe.Title = title
+	recipe.Ingredients = ingredients
+	suite.repo.Recipes[recipe.Id] = recipe
+	return recipe
+}
+
+// itemsByText returns all items of passed shopping list, mapped by their text.
+func (suite *ShoppingListTestSuite) itemsByText(list *shoppingList) map[string]shoppingListItem {
+	items := make(map[string]shoppingListItem)
+	for _, category := range list.Categories {
+		for _, item := range category.Items {
+			items[item.Text] = item
+		}
+	}
+	return items
+}
+
+// handle passes a request to the router.
+func (suite *ShoppingListTestSuite) handle(request events.APIGatewayProxyRequest) events.APIGatewayProxyResponse {
+	response, err := suite.router.handle(context.Background(), request)
+	suite.Nil(err)
+	return response
+}
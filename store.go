@@ -0,0 +1,356 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/session"
+	awsdynamodb "github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbattribute"
+	dynamodb "github.com/tommzn/aws-dynamodb"
+	config "github.com/tommzn/go-config"
+	log "github.com/tommzn/go-log"
+)
+
+// documentObjectTypePrefix is used to build DynamoDb object types for document collections.
+const documentObjectTypePrefix = "RECIPEMANAGER_"
+
+// newDocumentStoreFromConfig creates a document store defined by store.type in passed config.
+// Supported types are memory, file and dynamodb. If no type is defined, DynamoDb is used if a table
+// is configured, otherwise documents are kept in memory.
+func newDocumentStoreFromConfig(conf config.Config, logger log.Logger) documentStore {
+
+	storeType := "memory"
+	if conf == nil {
+		return newMemoryDocumentStore()
+	}
+	if conf.Get("aws.dynamodb.tablename", nil) != nil {
+		storeType = "dynamodb"
+	}
+	storeType = *conf.Get("store.type", &storeType)
+
+	switch strings.ToLower(storeType) {
+	case "file":
+		defaultPath := filepath.Join(os.TempDir(), "recipemanager")
+		return newFileDocumentStore(*conf.Get("store.path", &defaultPath))
+	case "dynamodb":
+		tableName := *conf.Get("aws.dynamodb.tablename", config.AsStringPtr(dynamodb.DEFAULT_TABLENAME))
+		return newDynamoDbDocumentStore(dynamodb.NewRepository(conf, logger), newDynamoDbItemWriter(conf), tableName)
+	default:
+		return newMemoryDocumentStore()
+	}
+}
+
+// newMemoryDocumentStore returns an empty in-memory document store.
+func newMemoryDocumentStore() documentStore {
+	return &memoryDocumentStore{documents: make(map[string]map[string][]byte)}
+}
+
+// get will load the document identified by passed collection and id into given value.
+func (store *memoryDocumentStore) get(collection, id string, value interface{}) error {
+
+	store.mutex.RLock()
+	defer store.mutex.RUnlock()
+
+	document, ok := store.documents[collection][id]
+	if !ok {
+		return newNotFoundError(fmt.Sprintf("Document not found: %s/%s", collection, id), nil)
+	}
+	return json.Unmarshal(document, value)
+}
+
+// set will create or replace a document.
+func (store *memoryDocumentStore) set(collection, id string, value interface{}) error {
+
+	document, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	if _, ok := store.documents[collection]; !ok {
+		store.documents[collection] = make(map[string][]byte)
+	}
+	store.documents[collection][id] = document
+	return nil
+}
+
+// create will add a new document, if there's no document with passed id.
+func (store *memoryDocumentStore) create(collection, id string, value interface{}) error {
+
+	document, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	if _, ok := store.documents[collection]; !ok {
+		store.documents[collection] = make(map[string][]byte)
+	}
+	if _, ok := store.documents[collection][id]; ok {
+		return newDocumentExistsError(collection, id)
+	}
+	store.documents[collection][id] = document
+	return nil
+}
+
+// delete will remove a document.
+func (store *memoryDocumentStore) delete(collection, id string) error {
+
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	delete(store.documents[collection], id)
+	return nil
+}
+
+// list returns all documents of a collection.
+func (store *memoryDocumentStore) list(collection string) (map[string][]byte, error) {
+
+	store.mutex.RLock()
+	defer store.mutex.RUnlock()
+
+	documents := make(map[string][]byte)
+	for id, document := range store.documents[collection] {
+		documents[id] = document
+	}
+	return documents, nil
+}
+
+// newFileDocumentStore returns a document store which writes all files to passed directory.
+func newFileDocumentStore(path string) documentStore {
+	return &fileDocumentStore{path: path}
+}
+
+// get will load the document identified by passed collection and id into given value.
+func (store *fileDocumentStore) get(collection, id string, value interface{}) error {
+
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	documents, err := store.readCollection(collection)
+	if err != nil {
+		return err
+	}
+	document, ok := documents[id]
+	if !ok {
+		return newNotFoundError(fmt.Sprintf("Document not found: %s/%s", collection, id), nil)
+	}
+	return json.Unmarshal(document, value)
+}
+
+// set will create or replace a document.
+func (store *fileDocumentStore) set(collection, id string, value interface{}) error {
+
+	document, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	documents, err := store.readCollection(collection)
+	if err != nil {
+		return err
+	}
+	documents[id] = document
+	return store.writeCollection(collection, documents)
+}
+
+// create will add a new document, if there's no document with passed id.
+func (store *fileDocumentStore) create(collection, id string, value interface{}) error {
+
+	document, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	documents, err := store.readCollection(collection)
+	if err != nil {
+		return err
+	}
+	if _, ok := documents[id]; ok {
+		return newDocumentExistsError(collection, id)
+	}
+	documents[id] = document
+	return store.writeCollection(collection, documents)
+}
+
+// delete will remove a document.
+func (store *fileDocumentStore) delete(collection, id string) error {
+
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	documents, err := store.readCollection(collection)
+	if err != nil {
+		return err
+	}
+	if _, ok := documents[id]; !ok {
+		return nil
+	}
+	delete(documents, id)
+	return store.writeCollection(collection, documents)
+}
+
+// list returns all documents of a collection.
+func (store *fileDocumentStore) list(collection string) (map[string][]byte, error) {
+
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	documents, err := store.readCollection(collection)
+	if err != nil {
+		return nil, err
+	}
+	result := make(map[string][]byte)
+	for id, document := range documents {
+		result[id] = document
+	}
+	return result, nil
+}
+
+// collectionFile returns the name of the file all documents of passed collection are written to.
+func (store *fileDocumentStore) collectionFile(collection string) string {
+	return filepath.Join(store.path, collection+".json")
+}
+
+// readCollection reads all documents of passed collection. A missing file is treated as an empty collection.
+func (store *fileDocumentStore) readCollection(collection string) (map[string]json.RawMessage, error) {
+
+	documents := make(map[string]json.RawMessage)
+	content, err := ioutil.ReadFile(store.collectionFile(collection))
+	if os.IsNotExist(err) {
+		return documents, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return documents, json.Unmarshal(content, &documents)
+}
+
+// writeCollection writes all passed documents to the file of given collection. Documents are written
+// to a temporary file first, which replaces the collection file afterwards, so readers never see partial content.
+func (store *fileDocumentStore) writeCollection(collection string, documents map[string]json.RawMessage) error {
+
+	if err := os.MkdirAll(store.path, 0755); err != nil {
+		return err
+	}
+	content, err := json.Marshal(documents)
+	if err != nil {
+		return err
+	}
+	tempFile := store.collectionFile(collection) + ".tmp"
+	if err := ioutil.WriteFile(tempFile, content, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tempFile, store.collectionFile(collection))
+}
+
+// newDynamoDbDocumentStore returns a document store which uses passed DynamoDb repository and item writer
+// to persist documents in given table.
+func newDynamoDbDocumentStore(client dynamodb.Repository, itemWriter dynamoDbItemWriter, tableName string) documentStore {
+	return &dynamoDbDocumentStore{client: client, itemWriter: itemWriter, tableName: tableName}
+}
+
+// newDynamoDbItemWriter creates a DynamoDb client for region and endpoint defined in passed config.
+func newDynamoDbItemWriter(conf config.Config) dynamoDbItemWriter {
+	awsConfig := &aws.Config{
+		Region:   conf.Get("aws.dynamodb.region", config.AsStringPtr(dynamodb.DEFAULT_AWS_REGION)),
+		Endpoint: conf.Get("aws.dynamodb.endpoint", nil),
+	}
+	return awsdynamodb.New(session.Must(session.NewSession(awsConfig)))
+}
+
+// get will load the document identified by passed collection and id into given value.
+func (store *dynamoDbDocumentStore) get(collection, id string, value interface{}) error {
+
+	item := newDocumentItem(collection, id, "")
+	if err := store.client.Get(item); err != nil {
+		return fromServiceError(err)
+	}
+	return json.Unmarshal([]byte(item.Document), value)
+}
+
+// set will create or replace a document.
+func (store *dynamoDbDocumentStore) set(collection, id string, value interface{}) error {
+
+	document, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	return store.client.Add(newDocumentItem(collection, id, string(document)))
+}
+
+// create will add a new document by a conditional put, which fails if there's already an item with passed id.
+func (store *dynamoDbDocumentStore) create(collection, id string, value interface{}) error {
+
+	document, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	item, err := dynamodbattribute.MarshalMap(newDocumentItem(collection, id, string(document)))
+	if err != nil {
+		return err
+	}
+	_, err = store.itemWriter.PutItem(&awsdynamodb.PutItemInput{
+		Item:                item,
+		TableName:           aws.String(store.tableName),
+		ConditionExpression: aws.String("attribute_not_exists(Id)"),
+	})
+	if awsErr, ok := err.(awserr.Error); ok && awsErr.Code() == awsdynamodb.ErrCodeConditionalCheckFailedException {
+		return newDocumentExistsError(collection, id)
+	}
+	return err
+}
+
+// delete will remove a document.
+func (store *dynamoDbDocumentStore) delete(collection, id string) error {
+	return store.client.Delete(newDocumentItem(collection, id, ""))
+}
+
+// list returns all documents of a collection.
+func (store *dynamoDbDocumentStore) list(collection string) (map[string][]byte, error) {
+
+	items := []documentItem{}
+	if err := store.client.Query(documentObjectType(collection), &items); err != nil {
+		return nil, err
+	}
+	documents := make(map[string][]byte)
+	for _, item := range items {
+		documents[item.Id] = []byte(item.Document)
+	}
+	return documents, nil
+}
+
+// newDocumentExistsError returns a conflict error for a document which already exists.
+func newDocumentExistsError(collection, id string) error {
+	return newConflictError(fmt.Sprintf("Document already exists: %s/%s", collection, id), nil)
+}
+
+// newDocumentItem creates a DynamoDb item for passed document.
+func newDocumentItem(collection, id, document string) *documentItem {
+	return &documentItem{
+		ItemIdentifier: dynamodb.NewItemIdentifier(id, documentObjectType(collection)),
+		Document:       document,
+	}
+}
+
+// documentObjectType returns the DynamoDb object type for passed collection.
+func documentObjectType(collection string) string {
+	return documentObjectTypePrefix + strings.ToUpper(collection)
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+)
+
+// Test suite for document stores.
+type DocumentStoreTestSuite struct {
+	suite.Suite
+}
+
+func TestDocumentStoreTestSuite(t *testing.T) {
+	suite.Run(t, new(DocumentStoreTestSuite))
+}
+
+// Test get, set, list and delete documents in memory.
+func (suite *DocumentStoreTestSuite) TestMemoryDocumentStore() {
+	suite.assertDocumentStore(newMemoryDocumentStore())
+}
+
+// Test get, set, list and delete documents in a local file.
+func (suite *DocumentStoreTestSuite) TestFileDocumentStore() {
+
+	path, err := ioutil.TempDir("", "recipemanager")
+	suite.Nil(err)
+	defer os.RemoveAll(path)
+
+	suite.assertDocumentStore(newFileDocumentStore(path))
+
+	store := newFileDocumentStore(path)
+	suite.Nil(store.set("persisted", "1", indexedRecipe{Id: "1"}))
+	documents, err := newFileDocumentStore(path).list("persisted")
+	suite.Nil(err)
+	suite.Len(documents, 1)
+}
+
+// Test creating documents in DynamoDb by conditional puts.
+func (suite *DocumentStoreTestSuite) TestDynamoDbDocumentStoreCreate() {
+
+	itemWriter := &dynamoDbItemWriterMock{items: make(map[string]bool)}
+	store := newDynamoDbDocumentStore(nil, itemWriter, "DynamoDbTestTable")
+
+	suite.Nil(store.create("revisions", "1/1", recipeRevision{Rev: 1}))
+	suite.IsType(&conflictError{}, store.create("revisions", "1/1", recipeRevision{Rev: 1}))
+	suite.Nil(store.create("revisions", "1/2", recipeRevision{Rev: 2}))
+	suite.Equal("DynamoDbTestTable", *itemWriter.lastInput.TableName)
+	suite.Equal("attribute_not_exists(Id)", *itemWriter.lastInput.ConditionExpression)
+
+	itemWriter.err = errors.New("Throttled")
+	err := store.create("revisions", "1/3", recipeRevision{Rev: 3})
+	suite.NotNil(err)
+	suite.NotEqual(http.StatusConflict, statusCodeForError(err, http.StatusInternalServerError))
+}
+
+// Test creating document stores from config.
+func (suite *DocumentStoreTestSuite) TestDocumentStoreFromConfig() {
+
+	suite.IsType(&memoryDocumentStore{}, newDocumentStoreFromConfig(nil, loggerForTest()))
+	suite.IsType(&dynamoDbDocumentStore{}, newDocumentStoreFromConfig(loadConfigForTest(), loggerForTest()))
+	suite.IsType(&fileDocumentStore{}, newDocumentStoreFromConfig(configForTest("store:\n  type: file\n  path: /tmp/recipemanager\n"), loggerForTest()))
+	suite.IsType(&memoryDocumentStore{}, newDocumentStoreFromConfig(configForTest("store:\n  type: memory\n"), loggerForTest()))
+}
+
+// assertDocumentStore runs basic operations on passed document store.
+func (suite *DocumentStoreTestSuite) assertDocumentStore(store documentStore) {
+
+	document := indexedRecipe{}
+	suite.IsType(&notFoundError{}, store.get("recipes", "1", &document))
+
+	suite.Nil(store.set("recipes", "1", indexedRecipe{Id: "1", Terms: map[string]map[string]int{"title": {"leek": 1}}}))
+	suite.Nil(store.set("recipes", "2", indexedRecipe{Id: "2"}))
+	suite.Nil(store.set("others", "3", indexedRecipe{Id: "3"}))
+	suite.IsType(&conflictError{}, store.create("recipes", "2", indexedRecipe{Id: "2"}))
+	suite.Nil(store.create("others", "4", indexedRecipe{Id: "4"}))
+	suite.Nil(store.delete("others", "4"))
+
+	suite.Nil(store.get("recipes", "1", &document))
+	suite.Equal("1", document.Id)
+	suite.Equal(1, document.Terms["title"]["leek"])
+
+	documents, err := store.list("recipes")
+	suite.Nil(err)
+	suite.Len(documents, 2)
+
+	suite.Nil(store.delete("recipes", "1"))
+	suite.Nil(store.delete("recipes", "1"))
+	suite.IsType(&notFoundError{}, store.get("recipes", "1", &document))
+
+	documents, err = store.list("recipes")
+	suite.Nil(err)
+	suite.Len(documents, 1)
+
+	documents, err = store.list("unknown")
+	suite.Nil(err)
+	suite.Len(documents, 0)
+}
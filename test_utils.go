@@ -2,6 +2,7 @@ package main
 
 import (
 	"encoding/json"
+	"net/http"
 	"os"
 	"time"
 
@@ -26,6 +27,12 @@ func loadConfigForTest() config.Config {
 	return config
 }
 
+// configForTest creates a config from passed YAML.
+func configForTest(yamlConfig string) config.Config {
+	config, _ := config.NewStaticConfigSource(yamlConfig).Load()
+	return config
+}
+
 // repositoryForTest returns a repository mock for testing.
 func repositoryForTest() *mock.RepositoryMock {
 	return mock.NewRepository()
@@ -84,19 +91,25 @@ func routerWithSuccessfulResponseForTest(logger log.Logger) LambdaRequestHandler
 }
 
 // factoryForTest returns a new request handler factor with given dependencies.
+// Recipe service is wrapped to maintain an in-memory search index, trash and revision log.
 func factoryForTest(repo model.Repository, publisher model.MessagePublisher, logger log.Logger) *requestHandlerFactory {
-	return &requestHandlerFactory{
-		recipeService: recipeManagerForTest(repo, publisher, logger),
+	documentStore := newMemoryDocumentStore()
+	factory := &requestHandlerFactory{
+		documentStore: documentStore,
+		searchIndex:   newDocumentSearchIndex(documentStore),
+		trash:         newDocumentTrash(documentStore),
+		revisions:     newDocumentRevisionLog(documentStore),
+		mealPlans:     newDocumentMealPlanStore(documentStore),
 		logger:        logger,
 	}
+	recipeService := newIndexingRecipeService(newCreationTimeRecipeService(recipeManagerForTest(repo, publisher, logger)), factory.searchIndex, logger)
+	factory.recipeService = newTrashingRecipeService(recipeService, factory.trash, logger)
+	return factory
 }
 
 // mockedFactoryForTest returns a new factory with repository and publisher mock.
 func mockedFactoryForTest(logger log.Logger) *requestHandlerFactory {
-	return &requestHandlerFactory{
-		recipeService: recipeManagerForTest(repositoryForTest(), publisherForTest(), logger),
-		logger:        logger,
-	}
+	return factoryForTest(repositoryForTest(), publisherForTest(), logger)
 }
 
 // loggerForTest creates a new stdout logger for testing.
@@ -133,6 +146,8 @@ func apiGatewayRequestForTest(httpMethod string, body, recipeId *string) events.
 			RequestID: utils.NewId(),
 		},
 		HTTPMethod:            httpMethod,
+		Resource:              "/recipes",
+		Path:                  "/recipes",
 		QueryStringParameters: make(map[string]string),
 		PathParameters:        make(map[string]string),
 	}
@@ -140,6 +155,8 @@ func apiGatewayRequestForTest(httpMethod string, body, recipeId *string) events.
 		request.Body = *body
 	}
 	if recipeId != nil {
+		request.Resource = "/recipes/{id}"
+		request.Path = "/recipes/" + *recipeId
 		request.PathParameters["id"] = *recipeId
 	}
 	return request
@@ -152,12 +169,27 @@ func apiGatewayRequestWithQueryParamForTest(httpMethod, queryKey, queryValue str
 			RequestID: utils.NewId(),
 		},
 		HTTPMethod:            httpMethod,
+		Resource:              "/recipes",
+		Path:                  "/recipes",
 		QueryStringParameters: make(map[string]string),
 	}
 	request.QueryStringParameters[queryKey] = queryValue
 	return request
 }
 
+// apiGatewayRequestWithPathForTest returns a new API Gateway request for given path, without resource and path params.
+func apiGatewayRequestWithPathForTest(httpMethod, path string) events.APIGatewayProxyRequest {
+	return events.APIGatewayProxyRequest{
+		RequestContext: events.APIGatewayProxyRequestContext{
+			RequestID: utils.NewId(),
+		},
+		HTTPMethod:            httpMethod,
+		Path:                  path,
+		QueryStringParameters: make(map[string]string),
+		PathParameters:        make(map[string]string),
+	}
+}
+
 // apiGatewayRequestHandlerMockForTest returns a new request handler mock with given parse and handle return values.
 func apiGatewayRequestHandlerMockForTest(parseError error, responseBody *string, handleError error) apiGatewayRequestHandler {
 	return &apiGatewayRequestHandlerMock{
@@ -196,3 +228,44 @@ func getRecipeListFromResponse(response events.APIGatewayProxyResponse) ([]model
 	err := json.Unmarshal([]byte(response.Body), &recipes)
 	return recipes, err
 }
+
+// getRecipePageFromResponse tries to unmarshal response body to a recipe page
+// and returns all recipes on this page and the link to the next page.
+func getRecipePageFromResponse(response events.APIGatewayProxyResponse) ([]model.Recipe, *string, error) {
+	var page struct {
+		Items []model.Recipe `json:"items"`
+		Next  *string        `json:"next"`
+	}
+	err := json.Unmarshal([]byte(response.Body), &page)
+	return page.Items, page.Next, err
+}
+
+// getSearchResponseFromResponse extracts search results from passed response.
+func getSearchResponseFromResponse(response events.APIGatewayProxyResponse) (searchResponse, error) {
+	var result searchResponse
+	err := json.Unmarshal([]byte(response.Body), &result)
+	return result, err
+}
+
+// recipeTitles returns titles of all passed recipes.
+func recipeTitles(recipes []model.Recipe) []string {
+	titles := []string{}
+	for _, recipe := range recipes {
+		titles = append(titles, recipe.Title)
+	}
+	return titles
+}
+
+// getProblemDetailsFromResponse tries to unmarshal response body to problem details.
+func getProblemDetailsFromResponse(response events.APIGatewayProxyResponse) (problemDetails, error) {
+	var problem problemDetails
+	err := json.Unmarshal([]byte(response.Body), &problem)
+	return problem, err
+}
+
+// apiGatewayPatchRequestForTest returns a new PATCH request with given content type and patch document.
+func apiGatewayPatchRequestForTest(contentType, patch, recipeId string) events.APIGatewayProxyRequest {
+	request := apiGatewayRequestForTest(http.MethodPatch, &patch, &recipeId)
+	request.Headers = map[string]string{"content-type": contentType}
+	return request
+}
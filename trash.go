@@ -0,0 +1,220 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	log "github.com/tommzn/go-log"
+	core "github.com/tommzn/recipeboard-core"
+	model "github.com/tommzn/recipeboard-core/model"
+)
+
+const (
+
+	// trashCollection is the document store collection for deleted recipes.
+	trashCollection = "trash"
+
+	// defaultTrashRetention is the time deleted recipes are kept in the trash if no retention is configured.
+	defaultTrashRetention = 30 * 24 * time.Hour
+)
+
+// newDocumentTrash returns a trash which keeps deleted recipes in passed document store.
+func newDocumentTrash(store documentStore) recipeTrash {
+	return &documentTrash{store: store}
+}
+
+// add moves passed recipe to the trash.
+func (trash *documentTrash) add(recipe model.Recipe, deletedAt time.Time) error {
+	return trash.store.set(trashCollection, recipe.Id, trashedRecipe{Recipe: recipe, DeletedAt: deletedAt})
+}
+
+// get returns the trashed recipe with passed id.
+func (trash *documentTrash) get(id string) (*trashedRecipe, error) {
+
+	trashed := trashedRecipe{}
+	if err := trash.store.get(trashCollection, id, &trashed); err != nil {
+		if _, ok := err.(*notFoundError); ok {
+			return nil, newNotFoundError(fmt.Sprintf("Recipe %s is not in trash.", id), nil)
+		}
+		return nil, err
+	}
+	return &trashed, nil
+}
+
+// remove deletes the recipe with passed id from the trash.
+func (trash *documentTrash) remove(id string) error {
+	return trash.store.delete(trashCollection, id)
+}
+
+// list returns all trashed recipes, most recently deleted first.
+func (trash *documentTrash) list() ([]trashedRecipe, error) {
+
+	documents, err := trash.store.list(trashCollection)
+	if err != nil {
+		return nil, err
+	}
+	trashedRecipes := []trashedRecipe{}
+	for _, document := range documents {
+		trashed := trashedRecipe{}
+		if err := json.Unmarshal(document, &trashed); err != nil {
+			return nil, err
+		}
+		trashedRecipes = append(trashedRecipes, trashed)
+	}
+	sort.Slice(trashedRecipes, func(i, j int) bool {
+		if trashedRecipes[i].DeletedAt.Equal(trashedRecipes[j].DeletedAt) {
+			return trashedRecipes[i].Recipe.Id < trashedRecipes[j].Recipe.Id
+		}
+		return trashedRecipes[i].DeletedAt.After(trashedRecipes[j].DeletedAt)
+	})
+	return trashedRecipes, nil
+}
+
+// newTrashingRecipeService wraps passed recipe service to move deleted recipes to given trash.
+func newTrashingRecipeService(recipeService core.RecipeService, trash recipeTrash, logger log.Logger) core.RecipeService {
+	return &trashingRecipeService{
+		RecipeService: recipeService,
+		trash:         trash,
+		logger:        logger,
+	}
+}
+
+// Delete moves passed recipe to the trash before it's deleted by the wrapped recipe service,
+// so it's hidden from GET and list requests. If deleting fails, the recipe is removed from the trash again.
+func (service *trashingRecipeService) Delete(recipe model.Recipe) error {
+
+	if err := service.trash.add(recipe, time.Now()); err != nil {
+		return err
+	}
+	err := service.RecipeService.Delete(recipe)
+	if err != nil {
+		if removeErr := service.trash.remove(recipe.Id); removeErr != nil && service.logger != nil {
+			service.logger.Errorf("Unable to remove recipe %s from trash: %s", recipe.Id, removeErr)
+		}
+	}
+	return err
+}
+
+// restoreRecipe creates the trashed recipe with passed id again and removes it from the trash.
+// Restored recipes keep their id and creation time, which is kept by the recipe service on creating a recipe,
+// so a single revision is written. It fails with a conflict if a recipe with the same id exists.
+func restoreRecipe(recipeService core.RecipeService, trash recipeTrash, id string) (model.Recipe, error) {
+
+	trashed, err := trash.get(id)
+	if err != nil {
+		return model.Recipe{}, err
+	}
+	recipe, err := createRecipe(recipeService, trashed.Recipe)
+	if err != nil {
+		return recipe, fromServiceError(err)
+	}
+	return recipe, trash.remove(id)
+}
+
+// purgeTrash removes all recipes from the trash which have been deleted before passed retention
+// has been elapsed. It returns the ids of all purged recipes.
+func purgeTrash(trash recipeTrash, retention time.Duration, now time.Time) ([]string, error) {
+
+	trashedRecipes, err := trash.list()
+	if err != nil {
+		return nil, err
+	}
+	purgedIds := []string{}
+	for _, trashed := range trashedRecipes {
+		if trashed.DeletedAt.Add(retention).After(now) {
+			continue
+		}
+		if err := trash.remove(trashed.Recipe.Id); err != nil {
+			return purgedIds, err
+		}
+		purgedIds = append(purgedIds, trashed.Recipe.Id)
+	}
+	return purgedIds, nil
+}
+
+// parseRequest will extract the recipe id from path.
+func (handler *apiGatewayRestoreRequestHandler) parseRequest(request events.APIGatewayProxyRequest) error {
+
+	if recipeId, ok := request.PathParameters["id"]; ok {
+		handler.recipeId = &recipeId
+		return nil
+	}
+	return errors.New("Missing recipe id.")
+}
+
+// handle restore requests by moving a recipe from the trash back to all recipes.
+func (handler *apiGatewayRestoreRequestHandler) handle() (*string, error) {
+
+	if handler.recipeId == nil {
+		return nil, errors.New("Missing recipe id.")
+	}
+	recipe, err := restoreRecipe(handler.recipeService, handler.trash, *handler.recipeId)
+	if err != nil {
+		return nil, err
+	}
+	handler.logger.Infof("Recipe %s restored from trash.", recipe.Id)
+	body, etag, err := marshalRecipeWithETag(recipe, nil)
+	handler.etag = etag
+	return body, err
+}
+
+// responseHeaders returns the ETag of the restored recipe.
+func (handler *apiGatewayRestoreRequestHandler) responseHeaders() map[string]string {
+	return etagHeaders(handler.etag)
+}
+
+// parseRequest for trash lists doesn't need any values from a request.
+func (handler *apiGatewayTrashListRequestHandler) parseRequest(request events.APIGatewayProxyRequest) error {
+	return nil
+}
+
+// handle requests to list all recipes in the trash, most recently deleted first.
+// Each recipe contains the time it will be purged.
+func (handler *apiGatewayTrashListRequestHandler) handle() (*string, error) {
+
+	trashedRecipes, err := handler.trash.list()
+	if err != nil {
+		return nil, err
+	}
+	response := trashResponse{Items: []trashResult{}}
+	for _, trashed := range trashedRecipes {
+		body, err := marshalRecipe(trashed.Recipe, nil)
+		if err != nil {
+			return nil, err
+		}
+		response.Items = append(response.Items, trashResult{
+			Recipe:    json.RawMessage(*body),
+			DeletedAt: trashed.DeletedAt.Round(1 * time.Second),
+			PurgeAt:   trashed.DeletedAt.Add(handler.retention).Round(1 * time.Second),
+		})
+	}
+	b, err := json.Marshal(response)
+	jsonStr := string(b)
+	return &jsonStr, err
+}
+
+// parseRequest rejects purge requests from API Gateway. Trash is purged by a scheduled rule only,
+// which invokes the Lambda function directly without an API Gateway request context.
+func (handler *apiGatewayTrashPurgeRequestHandler) parseRequest(request events.APIGatewayProxyRequest) error {
+	if request.RequestContext.APIID != "" {
+		return newForbiddenError("Trash is purged by a scheduled rule only.")
+	}
+	return nil
+}
+
+// handle purge requests by removing all recipes from the trash which are older than configured retention.
+func (handler *apiGatewayTrashPurgeRequestHandler) handle() (*string, error) {
+
+	purgedIds, err := purgeTrash(handler.trash, handler.retention, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	handler.logger.Infof("%d recipes purged from trash.", len(purgedIds))
+	b, err := json.Marshal(trashPurgeResponse{Purged: len(purgedIds), Ids: purgedIds})
+	jsonStr := string(b)
+	return &jsonStr, err
+}
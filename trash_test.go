@@ -0,0 +1,152 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/stretchr/testify/suite"
+	"github.com/tommzn/recipeboard-core/mock"
+	model "github.com/tommzn/recipeboard-core/model"
+)
+
+// Test suite for trash, restore and purge of deleted recipes.
+type TrashTestSuite struct {
+	suite.Suite
+	repo    *mock.RepositoryMock
+	factory *requestHandlerFactory
+	router  LambdaRequestHandler
+}
+
+func TestTrashTestSuite(t *testing.T) {
+	suite.Run(t, new(TrashTestSuite))
+}
+
+// Setup test.
+func (suite *TrashTestSuite) SetupTest() {
+	suite.repo = repositoryForTest()
+	suite.factory = factoryForTest(suite.repo, publisherForTest(), loggerForTest())
+	suite.router = routerWithFactoryForTest(suite.factory, loggerForTest())
+}
+
+// Test deleting a recipe moves it to the trash and restoring it brings it back.
+func (suite *TrashTestSuite) TestDeleteAndRestore() {
+
+	recipe := recipeForTest()
+	recipe.CreatedAt = time.Now().Add(-48 * time.Hour).Round(1 * time.Second)
+	suite.repo.Recipes[recipe.Id] = recipe
+
+	response := suite.handle(apiGatewayRequestForTest(http.MethodDelete, nil, &recipe.Id))
+	suite.Equal(http.StatusOK, response.StatusCode)
+	suite.Len(suite.repo.Recipes, 0)
+
+	response = suite.handle(apiGatewayRequestForTest(http.MethodGet, nil, &recipe.Id))
+	suite.Equal(http.StatusNotFound, response.StatusCode)
+
+	response = suite.handle(apiGatewayRequestWithPathForTest(http.MethodGet, "/trash"))
+	suite.Equal(http.StatusOK, response.StatusCode)
+	trashList := trashResponse{}
+	suite.Nil(json.Unmarshal([]byte(response.Body), &trashList))
+	suite.Len(trashList.Items, 1)
+	trashedRecipe := model.Recipe{}
+	suite.Nil(json.Unmarshal(trashList.Items[0].Recipe, &trashedRecipe))
+	suite.Equal(recipe.Id, trashedRecipe.Id)
+	suite.Equal(defaultTrashRetention, trashList.Items[0].PurgeAt.Sub(trashList.Items[0].DeletedAt))
+
+	response = suite.handle(apiGatewayRequestWithPathForTest(http.MethodPost, "/recipes/"+recipe.Id+":restore"))
+	suite.Equal(http.StatusOK, response.StatusCode)
+	suite.NotEmpty(response.Headers["ETag"])
+	restoredRecipe, err := getRecipeFromResponse(response)
+	suite.Nil(err)
+	suite.Equal(recipe.Id, restoredRecipe.Id)
+	suite.True(recipe.CreatedAt.Equal(restoredRecipe.CreatedAt))
+	suite.True(recipe.CreatedAt.Equal(suite.repo.Recipes[recipe.Id].CreatedAt))
+
+	trashedRecipes, err := suite.factory.getRecipeTrash().list()
+	suite.Nil(err)
+	suite.Len(trashedRecipes, 0)
+
+	revisions, err := suite.factory.getRevisionLog().list(recipe.Id)
+	suite.Nil(err)
+	suite.Len(revisions, 2)
+	suite.Equal(revisionDeleted, revisions[0].Action)
+	suite.Equal(revisionCreated, revisions[1].Action)
+
+	response = suite.handle(apiGatewayRequestWithPathForTest(http.MethodPost, "/recipes/"+recipe.Id+":restore"))
+	suite.Equal(http.StatusNotFound, response.StatusCode)
+}
+
+// Test restoring a recipe whose id is in use again.
+func (suite *TrashTestSuite) TestRestoreWithConflict() {
+
+	recipe := recipeForTest()
+	suite.Nil(suite.factory.getRecipeTrash().add(recipe, time.Now()))
+	suite.repo.Recipes[recipe.Id] = recipe
+
+	response := suite.handle(apiGatewayRequestWithPathForTest(http.MethodPost, "/recipes/"+recipe.Id+":restore"))
+	suite.Equal(http.StatusConflict, response.StatusCode)
+
+	_, err := suite.factory.getRecipeTrash().get(recipe.Id)
+	suite.Nil(err)
+}
+
+// Test purging recipes which are longer in trash than configured retention.
+func (suite *TrashTestSuite) TestPurgeTrash() {
+
+	trash := suite.factory.getRecipeTrash()
+	expiredRecipe := recipeForTest()
+	suite.Nil(trash.add(expiredRecipe, time.Now().Add(-25*time.Hour)))
+	keptRecipe := recipeForTest()
+	suite.Nil(trash.add(keptRecipe, time.Now().Add(-23*time.Hour)))
+
+	suite.factory.config = configForTest("trash:\n  retention: 24h\n")
+	response := suite.handle(apiGatewayRequestWithPathForTest(http.MethodPost, "/trash:purge"))
+	suite.Equal(http.StatusOK, response.StatusCode)
+	purgeResponse := trashPurgeResponse{}
+	suite.Nil(json.Unmarshal([]byte(response.Body), &purgeResponse))
+	suite.Equal(trashPurgeResponse{Purged: 1, Ids: []string{expiredRecipe.Id}}, purgeResponse)
+
+	trashedRecipes, err := trash.list()
+	suite.Nil(err)
+	suite.Len(trashedRecipes, 1)
+	suite.Equal(keptRecipe.Id, trashedRecipes[0].Recipe.Id)
+
+	apiGatewayRequest := apiGatewayRequestWithPathForTest(http.MethodPost, "/trash:purge")
+	apiGatewayRequest.RequestContext.APIID = "api-1"
+	response = suite.handle(apiGatewayRequest)
+	suite.Equal(http.StatusForbidden, response.StatusCode)
+}
+
+// Test batch deletes move recipes to the trash, too.
+func (suite *TrashTestSuite) TestBatchDeleteMovesToTrash() {
+
+	recipe := recipeForTest()
+	suite.repo.Recipes[recipe.Id] = recipe
+
+	response := suite.handle(batchRequestForTest("/recipes:batchDelete", `["`+recipe.Id+`"]`))
+	suite.Equal(http.StatusOK, response.StatusCode)
+	suite.Len(suite.repo.Recipes, 0)
+
+	trashed, err := suite.factory.getRecipeTrash().get(recipe.Id)
+	suite.Nil(err)
+	suite.Equal(recipe.Title, trashed.Recipe.Title)
+}
+
+// Test trash retention from config.
+func (suite *TrashTestSuite) TestTrashRetentionFromConfig() {
+
+	suite.Equal(defaultTrashRetention, suite.factory.trashRetention())
+
+	suite.factory.config = configForTest("trash:\n  retention: 72h\n")
+	suite.Equal(72*time.Hour, suite.factory.trashRetention())
+}
+
+// handle passes a request to the router.
+func (suite *TrashTestSuite) handle(request events.APIGatewayProxyRequest) events.APIGatewayProxyResponse {
+	response, err := suite.router.handle(context.Background(), request)
+	suite.Nil(err)
+	return response
+}
@@ -1,6 +1,12 @@
 package main
 
 import (
+	"encoding/json"
+	"net/url"
+	"sync"
+	"time"
+
+	dynamodb "github.com/tommzn/aws-dynamodb"
 	config "github.com/tommzn/go-config"
 	log "github.com/tommzn/go-log"
 	core "github.com/tommzn/recipeboard-core"
@@ -14,6 +20,9 @@ type requestRouter struct {
 	// factory to get handler for an API Gateway request.
 	factory handlerFactory
 
+	// cors contains CORS settings. CORS is disabled if it's nil.
+	cors *corsConfig
+
 	// logger is a centralized log handler.
 	logger log.Logger
 }
@@ -21,9 +30,28 @@ type requestRouter struct {
 // requestHandlerFactory is used to create a handler based on current request.
 type requestHandlerFactory struct {
 
-	// recipeService provides core components to handle recipe life circle.
+	// recipeService provides core components to handle recipe life circle. It's shared by all requests,
+	// revisions are written by a recipe service created for each request on top of it.
 	recipeService core.RecipeService
 
+	// documentStore persists additional data, e.g. the search index.
+	documentStore documentStore
+
+	// searchIndex is used to search for recipes.
+	searchIndex searchIndex
+
+	// trash keeps deleted recipes until they're restored or purged.
+	trash recipeTrash
+
+	// revisions is the log of all recipe revisions.
+	revisions revisionLog
+
+	// mealPlans persists weekly meal plans.
+	mealPlans mealPlanStore
+
+	// author is the identity of the caller of current request, used as author of recipe revisions.
+	author string
+
 	// config contains runtime params. e.g. persistence connections settings.
 	config config.Config
 
@@ -31,14 +59,66 @@ type requestHandlerFactory struct {
 	logger log.Logger
 }
 
-// apiGatewayGetRequestHandler will handle GET request send from API Gateway.
+// apiGatewayGetRequestHandler will handle GET request send from API Gateway to get a single recipe.
 type apiGatewayGetRequestHandler struct {
 
+	// fields contains all requested recipe fields. All fields are returned if it's nil.
+	fields []string
+
+	// scaling defines how ingredient quantities should be scaled. Recipe is returned unchanged if it's nil.
+	scaling *recipeScaling
+
+	// units is the unit system ingredients and temperatures should be converted to. They're not converted if it's empty.
+	units string
+
+	// conditions contains conditional request headers.
+	conditions conditionalRequest
+
+	// representations contains all media types the recipe can be rendered in.
+	representations []string
+
+	// mediaType is the negotiated media type the recipe is rendered in.
+	mediaType string
+
+	// etag is the entity tag of the returned recipe.
+	etag string
+
 	// recipeId is the id passed as path param.
 	recipeId *string
 
-	// recipeType ist used to list recipes.
-	recipeType *model.RecipeType
+	// Core service which handles recipe life circle.
+	recipeService core.RecipeService
+
+	// logger is a centralized log handler.
+	logger log.Logger
+}
+
+// apiGatewayListRequestHandler will handle GET request send from API Gateway to list recipes.
+type apiGatewayListRequestHandler struct {
+
+	// recipeTypes are used to list recipes. Recipes of all types are listed if it's empty.
+	recipeTypes []model.RecipeType
+
+	// sortOrder defines the order of listed recipes.
+	sortOrder string
+
+	// filter narrows listed recipes by creation time and title.
+	filter recipeFilter
+
+	// fields contains all requested recipe fields. All fields are returned if it's nil.
+	fields []string
+
+	// page is used to return a single page of recipes.
+	page pageRequest
+
+	// path is the request path, used to create links to other pages.
+	path string
+
+	// queryParams contains all query params of current request, used to create links to other pages.
+	queryParams url.Values
+
+	// next is the link to the next page. It's nil on the last page.
+	next *string
 
 	// Core service which handles recipe life circle.
 	recipeService core.RecipeService
@@ -53,6 +133,9 @@ type apiGatewayPostRequestHandler struct {
 	// recipe which should be created.
 	recipe *model.Recipe
 
+	// etag is the entity tag of the created recipe.
+	etag string
+
 	// Core service which handles recipe life circle.
 	recipeService core.RecipeService
 
@@ -69,6 +152,12 @@ type apiGatewayPutRequestHandler struct {
 	// recipeId is the id passed as path param.
 	recipeId *string
 
+	// conditions contains conditional request headers.
+	conditions conditionalRequest
+
+	// etag is the entity tag of the updated recipe.
+	etag string
+
 	// Core service which handles recipe life circle.
 	recipeService core.RecipeService
 
@@ -82,6 +171,1272 @@ type apiGatewayDeleteRequestHandler struct {
 	// recipeId is the id passed as path param.
 	recipeId *string
 
+	// conditions contains conditional request headers.
+	conditions conditionalRequest
+
+	// Core service which handles recipe life circle.
+	recipeService core.RecipeService
+
+	// logger is a centralized log handler.
+	logger log.Logger
+}
+
+// route maps a HTTP method and a resource path template to a request handler.
+type route struct {
+
+	// method is the HTTP method this route is defined for.
+	method string
+
+	// resource is a path template, e.g. /recipes/{id}. Path params are defined in curly brackets.
+	resource string
+
+	// newHandler creates a new request handler for this route.
+	newHandler func(*requestHandlerFactory) apiGatewayRequestHandler
+}
+
+// routedRequestHandler passes path params extracted during route matching to a request handler.
+type routedRequestHandler struct {
+
+	// handler is the request handler for a matched route.
+	apiGatewayRequestHandler
+
+	// pathParams have been extracted from request path by route matching.
+	pathParams map[string]string
+}
+
+// routeNotFoundError is returned if there's no route for a request path.
+type routeNotFoundError struct {
+
+	// path is the requested resource path.
+	path string
+}
+
+// methodNotAllowedError is returned if a route exists for a request path,
+// but not for the requested HTTP method.
+type methodNotAllowedError struct {
+
+	// method is the requested HTTP method.
+	method string
+
+	// allowedMethods contains all HTTP methods with a route for requested path.
+	allowedMethods []string
+}
+
+// problemDetails is a RFC 7807 error response body.
+type problemDetails struct {
+
+	// Type is an URI reference to identify the problem type.
+	Type string `json:"type"`
+
+	// Title is a short summary of the problem type.
+	Title string `json:"title"`
+
+	// Status is the HTTP status code of the response.
+	Status int `json:"status"`
+
+	// Detail is an explanation specific to this occurrence of the problem.
+	Detail string `json:"detail,omitempty"`
+
+	// Instance is the request path this problem occurred for.
+	Instance string `json:"instance,omitempty"`
+
+	// RequestId is the API Gateway request id.
+	RequestId string `json:"requestid,omitempty"`
+
+	// Errors contains validation errors for single fields of a request body.
+	Errors []fieldError `json:"errors,omitempty"`
+}
+
+// badRequestError is returned if a request is malformed or misses required values.
+type badRequestError struct {
+
+	// message describes the problem.
+	message string
+
+	// cause is an optional underlying error.
+	cause error
+}
+
+// notFoundError is returned if a requested recipe doesn't exist.
+type notFoundError struct {
+
+	// message describes the problem.
+	message string
+
+	// cause is an optional underlying error.
+	cause error
+}
+
+// validationError is returned if a request is well formed, but contains invalid values.
+type validationError struct {
+
+	// message describes the problem.
+	message string
+
+	// cause is an optional underlying error.
+	cause error
+
+	// fieldErrors contains validation errors for single fields of a request body.
+	fieldErrors []fieldError
+}
+
+// conflictError is returned if a request conflicts with the current state of a recipe.
+type conflictError struct {
+
+	// message describes the problem.
+	message string
+
+	// cause is an optional underlying error.
+	cause error
+}
+
+// forbiddenError is used for requests which aren't allowed for the caller, e.g. scheduled operations called by API Gateway.
+type forbiddenError struct {
+
+	// message describes the problem.
+	message string
+}
+
+// payloadTooLargeError is used if a response would exceed the max response size of a Lambda function, e.g. large exports.
+type payloadTooLargeError struct {
+
+	// message describes the problem.
+	message string
+}
+
+// preconditionFailedError is used for conditional requests which doesn't match the current state of a recipe.
+type preconditionFailedError struct {
+
+	// message describes the problem.
+	message string
+}
+
+// preconditionRequiredError is used for requests without a mandatory If-Match header.
+type preconditionRequiredError struct {
+
+	// message describes the problem.
+	message string
+}
+
+// notModifiedError is used for conditional GET requests if the requested recipe hasn't been changed.
+type notModifiedError struct {
+
+	// etag is the entity tag of the current recipe.
+	etag string
+}
+
+// recipeSchema contains validation rules for a recipe request body.
+// Rules have to match the corresponding schema in aws/openapi.yml.
+type recipeSchema struct {
+
+	// name of the schema in aws/openapi.yml.
+	name string
+
+	// required contains names of all mandatory properties.
+	required []string
+
+	// properties contains validation rules for all supported properties.
+	properties map[string]propertyRule
+}
+
+// propertyRule defines validation rules for a single property of a request body.
+type propertyRule struct {
+
+	// jsonType is the expected JSON type of a property value, e.g. string.
+	jsonType string
+
+	// format is an optional string format, e.g. date-time.
+	format string
+
+	// enum is a list of allowed values.
+	enum []string
+
+	// minLength is the minimal number of characters of a string value.
+	minLength int
+
+	// maxLength is the maximal number of characters of a string value. Zero means there's no limit.
+	maxLength int
+
+	// oneOf contains alternative rules, a value is valid if it matches at least one of them.
+	oneOf []propertyRule
+
+	// items is the rule for all elements of an array value.
+	items *propertyRule
+
+	// ref is the name of the schema in aws/openapi.yml object values have to match.
+	ref string
+}
+
+// fieldError describes a validation error for a single field of a request body.
+type fieldError struct {
+
+	// Field is the name of an invalid field.
+	Field string `json:"field"`
+
+	// Message describes the validation error.
+	Message string `json:"message"`
+}
+
+// apiGatewayPatchRequestHandler will handle PATCH request send from API Gateway.
+type apiGatewayPatchRequestHandler struct {
+
+	// recipeId is the id passed as path param.
+	recipeId *string
+
+	// mergePatch is a JSON merge patch document, RFC 7396.
+	mergePatch map[string]interface{}
+
+	// jsonPatch is a list of JSON patch operations, RFC 6902.
+	jsonPatch []jsonPatchOperation
+
+	// conditions contains conditional request headers.
+	conditions conditionalRequest
+
+	// etag is the entity tag of the patched recipe.
+	etag string
+
+	// Core service which handles recipe life circle.
+	recipeService core.RecipeService
+
+	// logger is a centralized log handler.
+	logger log.Logger
+}
+
+// jsonPatchOperation is a single operation of a JSON patch document.
+type jsonPatchOperation struct {
+
+	// Op is the operation, one of add, remove, replace, move, copy or test.
+	Op string `json:"op"`
+
+	// Path is a JSON pointer to the target location of an operation.
+	Path string `json:"path"`
+
+	// From is a JSON pointer to the source location for move and copy operations.
+	From string `json:"from"`
+
+	// Value is used by add, replace and test operations.
+	Value json.RawMessage `json:"value"`
+}
+
+// notAcceptableError is returned if a response can't be rendered in any media type accepted by a client.
+type notAcceptableError struct {
+
+	// accept is the Accept header of a request.
+	accept string
+
+	// supportedTypes contains all media types a response can be rendered in.
+	supportedTypes []string
+}
+
+// unsupportedMediaTypeError is returned if a request body has an unsupported content type.
+type unsupportedMediaTypeError struct {
+
+	// contentType is the content type of a request.
+	contentType string
+
+	// supportedTypes contains all supported content types.
+	supportedTypes []string
+}
+
+// pageRequest defines a single page of a recipe list.
+type pageRequest struct {
+
+	// limit is the max number of recipes on a page.
+	limit int
+
+	// cursor is the position after which a page starts. It's nil for the first page.
+	cursor *listCursor
+
+	// paged is set if a limit or cursor has been passed. Only these lists are returned page by page,
+	// with a link to the next page. Other lists contain all recipes and are returned as plain list.
+	paged bool
+}
+
+// listCursor is the position in a sorted list of recipes. It's passed to clients as an opaque token.
+type listCursor struct {
+
+	// Sort is the sort order the cursor has been created for.
+	Sort string `json:"s"`
+
+	// Key is the sort key of the last recipe on previous page.
+	Key string `json:"k"`
+
+	// Id is the id of the last recipe on previous page.
+	Id string `json:"i"`
+}
+
+// recipePage is the response envelope for a single page of recipes.
+type recipePage struct {
+
+	// Items contains JSON values of all recipes of current page, reduced to requested fields.
+	Items []map[string]json.RawMessage `json:"items"`
+
+	// Next is a link to the next page. It's omitted on the last page.
+	Next *string `json:"next,omitempty"`
+}
+
+// memoryDocumentStore keeps all documents in memory. It's used for testing or if no persistent store is configured.
+type memoryDocumentStore struct {
+
+	// documents contains JSON documents, grouped by collection and mapped by their id.
+	documents map[string]map[string][]byte
+
+	// mutex to synchronize access to documents.
+	mutex sync.RWMutex
+}
+
+// fileDocumentStore persists documents in local JSON files, one file for each collection.
+type fileDocumentStore struct {
+
+	// path is the directory all files are written to.
+	path string
+
+	// mutex to synchronize file access.
+	mutex sync.Mutex
+}
+
+// dynamoDbDocumentStore persists documents in AWS DynamoDb, in the same table as used for recipes.
+type dynamoDbDocumentStore struct {
+
+	// DynamoDb client.
+	client dynamodb.Repository
+
+	// itemWriter is used for conditional writes, which aren't supported by the DynamoDb client.
+	itemWriter dynamoDbItemWriter
+
+	// tableName is the DynamoDb table documents are written to.
+	tableName string
+}
+
+// documentItem is a DynamoDb item for a JSON document.
+type documentItem struct {
+
+	// Id for an item in DynamoDb.
+	*dynamodb.ItemIdentifier
+
+	// Document is the JSON document.
+	Document string
+}
+
+// documentSearchIndex is a search index for recipes. For each indexed recipe term frequencies are persisted
+// in a document store. On search all indexed recipes are scanned for query terms.
+type documentSearchIndex struct {
+
+	// store persists term frequencies of indexed recipes.
+	store documentStore
+}
+
+// indexedRecipe contains frequencies of all terms of a recipe, grouped by field.
+type indexedRecipe struct {
+
+	// Id of an indexed recipe.
+	Id string `json:"id"`
+
+	// Terms contains number of occurrences for each term, grouped by field name.
+	Terms map[string]map[string]int `json:"terms"`
+}
+
+// searchIndexState contains the version a search index has been built with.
+type searchIndexState struct {
+
+	// Version of indexed terms.
+	Version int `json:"version"`
+}
+
+// textToken is a single word of a text.
+type textToken struct {
+
+	// term is the normalized and stemmed word.
+	term string
+
+	// start is the byte offset of a word in it's text.
+	start int
+
+	// end is the byte offset after a word in it's text.
+	end int
+}
+
+// searchHit is a recipe which matches a search query.
+type searchHit struct {
+
+	// id of a matching recipe.
+	id string
+
+	// score is the relevance of a recipe for a search query.
+	score float64
+
+	// terms contains all matching terms.
+	terms []string
+}
+
+// searchResult is a single recipe in a search response.
+type searchResult struct {
+
+	// Recipe is the matching recipe.
+	Recipe model.Recipe `json:"recipe"`
+
+	// Score is the relevance of a recipe for a search query.
+	Score float64 `json:"score"`
+
+	// Highlights contains text snippets with highlighted matches, mapped by field name.
+	Highlights map[string]string `json:"highlights"`
+}
+
+// searchResponse is the response body for search requests.
+type searchResponse struct {
+
+	// Query is the search query.
+	Query string `json:"query"`
+
+	// Items contains all matching recipes ordered by their relevance.
+	Items []searchResult `json:"items"`
+}
+
+// creationTimeRecipeService keeps the creation time of recipes created by a recipe service.
+type creationTimeRecipeService struct {
+
+	// Core service which handles recipe life circle.
+	core.RecipeService
+}
+
+// indexingRecipeService keeps the search index in sync with all recipes created, updated or deleted by a recipe service.
+type indexingRecipeService struct {
+
+	// Core service which handles recipe life circle.
+	core.RecipeService
+
+	// searchIndex is updated for each recipe change.
+	searchIndex searchIndex
+
+	// logger is a centralized log handler.
+	logger log.Logger
+}
+
+// apiGatewaySearchRequestHandler will handle search requests send from API Gateway.
+type apiGatewaySearchRequestHandler struct {
+
+	// query is the search query.
+	query string
+
+	// limit is the max number of returned recipes.
+	limit int
+
+	// searchIndex is used to search for recipes.
+	searchIndex searchIndex
+
+	// Core service which handles recipe life circle.
+	recipeService core.RecipeService
+
+	// logger is a centralized log handler.
+	logger log.Logger
+}
+
+// recipeFilter narrows recipe lists by creation time and title.
+type recipeFilter struct {
+
+	// createdAfter is the earliest creation time of listed recipes.
+	createdAfter *time.Time
+
+	// createdBefore excludes all recipes created at or after this time.
+	createdBefore *time.Time
+
+	// titleContains is a lower case text listed recipes have in their title.
+	titleContains string
+}
+
+// conditionalRequest contains conditional request headers as defined in RFC 7232.
+type conditionalRequest struct {
+
+	// ifMatch contains entity tags a recipe has to match before it's changed.
+	ifMatch string
+
+	// ifNoneMatch contains entity tags of recipes a client has already received.
+	ifNoneMatch string
+
+	// ifMatchRequired defines if a If-Match header is mandatory to change a recipe.
+	ifMatchRequired bool
+}
+
+// corsConfig contains settings for cross-origin resource sharing.
+type corsConfig struct {
+
+	// allowedOrigins contains all origins browser requests are allowed from.
+	allowedOrigins []string
+
+	// allowedMethods contains all methods allowed in cross-origin requests. Methods of requested route are used if it's empty.
+	allowedMethods []string
+
+	// allowedHeaders contains all request headers allowed in cross-origin requests.
+	allowedHeaders []string
+
+	// exposedHeaders contains all response headers browsers are allowed to read.
+	exposedHeaders []string
+
+	// maxAge is the time browsers can cache preflight responses.
+	maxAge time.Duration
+}
+
+// mediaRange is a single media range of an Accept header.
+type mediaRange struct {
+
+	// mediaType is a media type or a wildcard, e.g. text/* or */*.
+	mediaType string
+
+	// quality is the relative preference of a media range, between 0 and 1.
+	quality float64
+}
+
+// apiGatewayImportRequestHandler will handle POST requests to import recipes from other formats.
+type apiGatewayImportRequestHandler struct {
+
+	// items contains values of all recipes which should be imported.
+	items []importItem
+
+	// maxSize is the max number of recipes in a single import request.
+	maxSize int
+
+	// Core service which handles recipe life circle.
+	recipeService core.RecipeService
+
+	// logger is a centralized log handler.
+	logger log.Logger
+}
+
+// importItem is a single recipe decoded from an imported document.
+type importItem struct {
+
+	// values are request body values of a new recipe.
+	values map[string]interface{}
+
+	// title is used to identify a recipe in an import report.
+	title string
+
+	// row is the row of a recipe in a CSV document or the line in a NDJSON document, starting with 1.
+	row int
+
+	// err is set if a recipe can't be decoded.
+	err error
+}
+
+// importReport contains the result of an import.
+type importReport struct {
+
+	// Created is the number of recipes which have been created.
+	Created int `json:"created"`
+
+	// Failed is the number of recipes which couldn't be imported.
+	Failed int `json:"failed"`
+
+	// Items contains the result for each recipe of an imported document.
+	Items []importResult `json:"items"`
+}
+
+// importResult is the result of importing a single recipe.
+type importResult struct {
+
+	// Index is the position of a recipe in an imported document, starting with 0.
+	Index int `json:"index"`
+
+	// Row is the row of a recipe in a CSV document, including the header row, or the line in a NDJSON document.
+	Row int `json:"row,omitempty"`
+
+	// Status is a HTTP status code, 201 for created recipes.
+	Status int `json:"status"`
+
+	// Id of a created recipe.
+	Id string `json:"id,omitempty"`
+
+	// Title of an imported recipe.
+	Title string `json:"title,omitempty"`
+
+	// Error describes why a recipe couldn't be imported.
+	Error string `json:"error,omitempty"`
+
+	// Errors contains validation errors for single fields of a recipe.
+	Errors []fieldError `json:"errors,omitempty"`
+}
+
+// schemaOrgRecipe is a recipe in schema.org vocabulary, see https://schema.org/Recipe.
+type schemaOrgRecipe struct {
+
+	// Context is the JSON-LD context.
+	Context string `json:"@context"`
+
+	// Type is the schema.org type.
+	Type string `json:"@type"`
+
+	// Identifier is the recipe id.
+	Identifier string `json:"identifier,omitempty"`
+
+	// Name is the recipe title.
+	Name string `json:"name"`
+
+	// RecipeCategory is the recipe type.
+	RecipeCategory string `json:"recipeCategory"`
+
+	// RecipeIngredient contains all ingredients, one per line.
+	RecipeIngredient []string `json:"recipeIngredient"`
+
+	// RecipeInstructions contains all steps of the recipe description.
+	RecipeInstructions []schemaOrgHowToStep `json:"recipeInstructions"`
+
+	// DateCreated is the creation time of a recipe.
+	DateCreated string `json:"dateCreated,omitempty"`
+}
+
+// schemaOrgHowToStep is a single step of recipe instructions, see https://schema.org/HowToStep.
+type schemaOrgHowToStep struct {
+
+	// Type is the schema.org type.
+	Type string `json:"@type"`
+
+	// Text describes the step.
+	Text string `json:"text"`
+}
+
+// apiGatewayExportRequestHandler will handle GET requests to export recipes.
+type apiGatewayExportRequestHandler struct {
+
+	// recipeTypes are used to export recipes. Recipes of all types are exported if it's empty.
+	recipeTypes []model.RecipeType
+
+	// filter contains conditions all exported recipes have to match.
+	filter recipeFilter
+
+	// fields contains all exported recipe fields. All fields are exported if it's nil.
+	fields []string
+
+	// representations contains all media types recipes can be exported in.
+	representations []string
+
+	// mediaType is the negotiated media type of an export.
+	mediaType string
+
+	// Core service which handles recipe life circle.
+	recipeService core.RecipeService
+
+	// logger is a centralized log handler.
+	logger log.Logger
+}
+
+// batchRequest contains recipe ids of a batch request and limits to process them.
+type batchRequest struct {
+
+	// ids contains all recipe ids of a batch request, without duplicates.
+	ids []string
+
+	// maxSize is the max number of recipe ids in a single batch request.
+	maxSize int
+
+	// concurrency is the max number of recipes loaded in parallel by batch get requests. Deletes are never processed in parallel.
+	concurrency int
+}
+
+// batchResult is the result of a batch request for a single recipe id.
+type batchResult struct {
+
+	// Id of a requested recipe.
+	Id string `json:"id"`
+
+	// Status is one of found, deleted, not-found or error.
+	Status string `json:"status"`
+
+	// Recipe contains a found recipe of a batch get request.
+	Recipe json.RawMessage `json:"recipe,omitempty"`
+
+	// Error contains a message if a recipe couldn't be processed.
+	Error string `json:"error,omitempty"`
+}
+
+// batchResponse contains results for all recipe ids of a batch request.
+type batchResponse struct {
+
+	// Items contains results in the same order recipe ids have been requested.
+	Items []batchResult `json:"items"`
+}
+
+// apiGatewayBatchGetRequestHandler will handle POST requests to get multiple recipes at once.
+type apiGatewayBatchGetRequestHandler struct {
+
+	// batch contains requested recipe ids.
+	batch batchRequest
+
+	// fields contains all recipe fields which should be returned. All fields are returned if it's nil.
+	fields []string
+
+	// Core service which handles recipe life circle.
+	recipeService core.RecipeService
+
+	// logger is a centralized log handler.
+	logger log.Logger
+}
+
+// apiGatewayBatchDeleteRequestHandler will handle POST requests to delete multiple recipes at once.
+type apiGatewayBatchDeleteRequestHandler struct {
+
+	// batch contains recipe ids which should be deleted.
+	batch batchRequest
+
+	// Core service which handles recipe life circle.
+	recipeService core.RecipeService
+
+	// logger is a centralized log handler.
+	logger log.Logger
+}
+
+// documentTrash keeps deleted recipes in a document store.
+type documentTrash struct {
+
+	// store persists trashed recipes.
+	store documentStore
+}
+
+// trashedRecipe is a deleted recipe in the trash.
+type trashedRecipe struct {
+
+	// Recipe is the deleted recipe.
+	Recipe model.Recipe `json:"recipe"`
+
+	// DeletedAt is the time a recipe has been moved to the trash.
+	DeletedAt time.Time `json:"deletedat"`
+}
+
+// trashingRecipeService moves all recipes deleted by a recipe service to the trash.
+type trashingRecipeService struct {
+
+	// Core service which handles recipe life circle.
+	core.RecipeService
+
+	// trash keeps deleted recipes.
+	trash recipeTrash
+
+	// logger is a centralized log handler.
+	logger log.Logger
+}
+
+// trashResult is a single recipe in a trash list response.
+type trashResult struct {
+
+	// Recipe is the deleted recipe.
+	Recipe json.RawMessage `json:"recipe"`
+
+	// DeletedAt is the time a recipe has been moved to the trash.
+	DeletedAt time.Time `json:"deletedat"`
+
+	// PurgeAt is the time after which a recipe will be removed from the trash.
+	PurgeAt time.Time `json:"purgeat"`
+}
+
+// trashResponse is the response body for trash list requests.
+type trashResponse struct {
+
+	// Items contains all recipes in the trash, most recently deleted first.
+	Items []trashResult `json:"items"`
+}
+
+// trashPurgeResponse is the response body for purge requests.
+type trashPurgeResponse struct {
+
+	// Purged is the number of recipes which have been removed from the trash.
+	Purged int `json:"purged"`
+
+	// Ids contains the ids of all purged recipes.
+	Ids []string `json:"ids"`
+}
+
+// apiGatewayRestoreRequestHandler will handle POST requests to restore a recipe from the trash.
+type apiGatewayRestoreRequestHandler struct {
+
+	// recipeId is the id passed as path param.
+	recipeId *string
+
+	// etag is the entity tag of the restored recipe.
+	etag string
+
+	// trash keeps deleted recipes.
+	trash recipeTrash
+
+	// Core service which handles recipe life circle.
+	recipeService core.RecipeService
+
+	// logger is a centralized log handler.
+	logger log.Logger
+}
+
+// apiGatewayTrashListRequestHandler will handle GET requests to list all recipes in the trash.
+type apiGatewayTrashListRequestHandler struct {
+
+	// retention is the time deleted recipes are kept in the trash.
+	retention time.Duration
+
+	// trash keeps deleted recipes.
+	trash recipeTrash
+
+	// logger is a centralized log handler.
+	logger log.Logger
+}
+
+// apiGatewayTrashPurgeRequestHandler will handle POST requests to purge expired recipes from the trash.
+type apiGatewayTrashPurgeRequestHandler struct {
+
+	// retention is the time deleted recipes are kept in the trash.
+	retention time.Duration
+
+	// trash keeps deleted recipes.
+	trash recipeTrash
+
+	// logger is a centralized log handler.
+	logger log.Logger
+}
+
+// documentRevisionLog persists revisions of recipes in a document store.
+type documentRevisionLog struct {
+
+	// store persists revisions.
+	store documentStore
+
+	// mutex to synchronize appending revisions.
+	mutex sync.Mutex
+}
+
+// revisionHead contains the latest revision number of a recipe.
+type revisionHead struct {
+
+	// Id of a recipe.
+	Id string `json:"id"`
+
+	// Latest is the number of the latest revision. It can lag behind if revisions are written concurrently.
+	Latest int `json:"latest"`
+}
+
+// recipeRevision is a snapshot of a recipe after it has been created, updated or deleted.
+type recipeRevision struct {
+
+	// Rev is the number of a revision, starting with 1.
+	Rev int `json:"rev"`
+
+	// Action is one of created, updated or deleted.
+	Action string `json:"action"`
+
+	// Author is the identity of the caller who changed a recipe.
+	Author string `json:"author"`
+
+	// CreatedAt is the time a revision has been written.
+	CreatedAt time.Time `json:"createdat"`
+
+	// Recipe contains all values of a recipe.
+	Recipe model.Recipe `json:"recipe"`
+}
+
+// revisioningRecipeService writes a revision for each recipe created, updated or deleted by a recipe service.
+type revisioningRecipeService struct {
+
+	// Core service which handles recipe life circle.
+	core.RecipeService
+
+	// revisions is the log all revisions are written to.
+	revisions revisionLog
+
+	// author is the identity of the caller of current request.
+	author string
+
+	// logger is a centralized log handler.
+	logger log.Logger
+}
+
+// revisionResult is a single revision in a response.
+type revisionResult struct {
+
+	// Rev is the number of a revision.
+	Rev int `json:"rev"`
+
+	// Action is one of created, updated or deleted.
+	Action string `json:"action"`
+
+	// Author is the identity of the caller who changed a recipe.
+	Author string `json:"author"`
+
+	// CreatedAt is the time a revision has been written.
+	CreatedAt time.Time `json:"createdat"`
+
+	// Recipe contains all values of a recipe. It's only returned for single revisions.
+	Recipe json.RawMessage `json:"recipe,omitempty"`
+}
+
+// revisionListResponse is the response body for revision list requests.
+type revisionListResponse struct {
+
+	// Items contains all revisions of a recipe, oldest first.
+	Items []revisionResult `json:"items"`
+}
+
+// apiGatewayRevisionListRequestHandler will handle GET requests to list all revisions of a recipe.
+type apiGatewayRevisionListRequestHandler struct {
+
+	// recipeId is the id passed as path param.
+	recipeId *string
+
+	// revisions is the log of all recipe revisions.
+	revisions revisionLog
+
+	// Core service which handles recipe life circle.
+	recipeService core.RecipeService
+
+	// logger is a centralized log handler.
+	logger log.Logger
+}
+
+// apiGatewayRevisionGetRequestHandler will handle GET requests to get a single revision of a recipe.
+type apiGatewayRevisionGetRequestHandler struct {
+
+	// recipeId is the id passed as path param.
+	recipeId *string
+
+	// rev is the revision number passed as path param.
+	rev int
+
+	// revisions is the log of all recipe revisions.
+	revisions revisionLog
+
+	// logger is a centralized log handler.
+	logger log.Logger
+}
+
+// apiGatewayRollbackRequestHandler will handle POST requests to roll back a recipe to an older revision.
+type apiGatewayRollbackRequestHandler struct {
+
+	// recipeId is the id passed as path param.
+	recipeId *string
+
+	// rev is the revision number passed as query param.
+	rev int
+
+	// conditions contains conditional request headers.
+	conditions conditionalRequest
+
+	// etag is the entity tag of the rolled back recipe.
+	etag string
+
+	// revisions is the log of all recipe revisions.
+	revisions revisionLog
+
+	// Core service which handles recipe life circle.
+	recipeService core.RecipeService
+
+	// logger is a centralized log handler.
+	logger log.Logger
+}
+
+// ingredient is a single parsed line of a recipe's ingredients.
+type ingredient struct {
+
+	// Text is the original ingredient line.
+	Text string `json:"text"`
+
+	// Quantity is the amount of an ingredient, or the lower bound of a range. It's nil if there's no quantity.
+	Quantity *float64 `json:"quantity,omitempty"`
+
+	// QuantityMax is the upper bound of a quantity range, e.g. 3 for "2-3 eggs".
+	QuantityMax *float64 `json:"quantityMax,omitempty"`
+
+	// Unit is the canonical unit of a quantity, e.g. cup or g. It's empty for countable ingredients.
+	Unit string `json:"unit,omitempty"`
+
+	// Name of an ingredient.
+	Name string `json:"name"`
+
+	// Note contains additional preparation hints, e.g. sifted.
+	Note string `json:"note,omitempty"`
+
+	// Unscaled is set for ingredients of a scaled recipe which have been left untouched, because they have no quantity.
+	Unscaled bool `json:"unscaled,omitempty"`
+}
+
+// recipeScaling defines how quantities of a recipe should be scaled, either by a factor or to a number of servings.
+type recipeScaling struct {
+
+	// factor all quantities are multiplied with. It's nil if a recipe is scaled to a number of servings.
+	factor *float64
+
+	// servings is the requested number of servings.
+	servings int
+
+	// baseServings is the number of servings of the original recipe. It's read from a recipe if it's zero.
+	baseServings int
+}
+
+// scalingUnit is a unit which can be replaced by a larger or smaller unit of the same family after scaling.
+type scalingUnit struct {
+
+	// name is the canonical unit name.
+	name string
+
+	// size is the size of a unit in the smallest unit of it's family.
+	size float64
+
+	// minQuantity is the smallest quantity a unit is used for after scaling.
+	minQuantity float64
+}
+
+// conversionUnit is a unit ingredients can be converted to.
+type conversionUnit struct {
+
+	// name is the canonical unit name.
+	name string
+
+	// size is the size of a unit in milliliters for volumes and in grams for masses.
+	size float64
+
+	// minQuantity is the smallest quantity a unit is used for.
+	minQuantity float64
+
+	// step converted quantities are rounded to, e.g. 1/8 cup.
+	step float64
+}
+
+// shoppingListRecipe is a recipe of a shopping list with the factor it's ingredients are scaled with.
+type shoppingListRecipe struct {
+
+	// Id of a recipe.
+	Id string `json:"id"`
+
+	// Title of a recipe, it's set for loaded recipes.
+	Title string `json:"title,omitempty"`
+
+	// Scale factor all ingredients of a recipe are multiplied with.
+	Scale float64 `json:"scale"`
+}
+
+// shoppingList contains merged ingredients of multiple recipes, grouped by aisle category.
+type shoppingList struct {
+
+	// Recipes contains all recipes of a shopping list.
+	Recipes []shoppingListRecipe `json:"recipes"`
+
+	// Categories contains all aisle categories with at least one item, in shopping order.
+	Categories []shoppingListCategory `json:"categories"`
+}
+
+// shoppingListCategory is an aisle category with all items of a shopping list.
+type shoppingListCategory struct {
+
+	// Name of a category, e.g. produce or dairy.
+	Name string `json:"name"`
+
+	// Label of a category, e.g. Dairy & Eggs.
+	Label string `json:"label"`
+
+	// Items contains all items of a category, ordered by name.
+	Items []shoppingListItem `json:"items"`
+}
+
+// shoppingListItem is a single, merged ingredient of a shopping list.
+type shoppingListItem struct {
+
+	// Text is the item as single line, e.g. "500 g flour".
+	Text string `json:"text"`
+
+	// Quantity is the total amount of an item. It's missing for items like "Salt".
+	Quantity *float64 `json:"quantity,omitempty"`
+
+	// Unit is the canonical unit of an item.
+	Unit string `json:"unit,omitempty"`
+
+	// Name of an ingredient.
+	Name string `json:"name"`
+
+	// Recipes contains ids of all recipes which need an item.
+	Recipes []string `json:"recipes"`
+}
+
+// shoppingListEntry is used to merge ingredients of the same name and dimension while building a shopping list.
+type shoppingListEntry struct {
+
+	// key identifies an entry by normalized name and dimension.
+	key string
+
+	// name of the ingredient, as used in the first recipe.
+	name string
+
+	// dimension is mass, volume, a unit, e.g. "unit:clove", or none for ingredients without quantity.
+	dimension string
+
+	// unit of the first added ingredient.
+	unit string
+
+	// sameUnit is true as long as all added ingredients have the same unit.
+	sameUnit bool
+
+	// unitQuantity is the sum of all quantities, as long as they've the same unit.
+	unitQuantity float64
+
+	// amount is the total amount in grams for masses, in milliliters for volumes and in the unit for all others.
+	amount float64
+
+	// density is the weight of a cup of an ingredient in grams. It's zero if it's unknown.
+	density float64
+
+	// recipeIds contains all recipes an ingredient is used in.
+	recipeIds []string
+}
+
+// apiGatewayShoppingListRequestHandler will handle POST requests to create a shopping list for multiple recipes.
+type apiGatewayShoppingListRequestHandler struct {
+
+	// recipes contains all recipes of the requested shopping list.
+	recipes []shoppingListRecipe
+
+	// maxRecipes is the max number of recipes of a shopping list.
+	maxRecipes int
+
+	// units is the unit system all quantities are converted to. They're not converted if it's empty.
+	units string
+
+	// mediaType is the negotiated media type the shopping list is rendered in.
+	mediaType string
+
+	// Core service which handles recipe life circle.
+	recipeService core.RecipeService
+
+	// logger is a centralized log handler.
+	logger log.Logger
+}
+
+// documentMealPlanStore persists meal plans in a document store.
+type documentMealPlanStore struct {
+
+	// store persists meal plans.
+	store documentStore
+}
+
+// mealPlan assigns recipes to meal slots on each day of a week.
+type mealPlan struct {
+
+	// Week is an ISO 8601 week, e.g. 2021-W07.
+	Week string `json:"week"`
+
+	// Meals contains all planned meals, ordered by date and slot.
+	Meals []plannedMeal `json:"meals"`
+
+	// UpdatedAt is the time a meal plan has been created or updated.
+	UpdatedAt time.Time `json:"updatedat"`
+}
+
+// plannedMeal is a recipe planned for a meal slot on a single day.
+type plannedMeal struct {
+
+	// Date of a meal, e.g. 2021-02-15.
+	Date string `json:"date"`
+
+	// Slot is one of breakfast, lunch, dinner or snack.
+	Slot string `json:"slot"`
+
+	// RecipeId is the id of the planned recipe.
+	RecipeId string `json:"recipeid"`
+
+	// Scale is an optional factor the recipe is scaled by on the shopping list.
+	Scale *float64 `json:"scale,omitempty"`
+}
+
+// mealPlanListResponse is the response body for meal plan list requests.
+type mealPlanListResponse struct {
+
+	// Items contains all meal plans, most recent week first.
+	Items []mealPlan `json:"items"`
+}
+
+// apiGatewayMealPlanListRequestHandler will handle GET requests to list all meal plans.
+type apiGatewayMealPlanListRequestHandler struct {
+
+	// mealPlans persists meal plans.
+	mealPlans mealPlanStore
+
+	// logger is a centralized log handler.
+	logger log.Logger
+}
+
+// apiGatewayMealPlanPostRequestHandler will handle POST requests to create a meal plan.
+type apiGatewayMealPlanPostRequestHandler struct {
+
+	// plan is the meal plan passed in request body.
+	plan *mealPlan
+
+	// maxMeals is the max number of meals of a meal plan.
+	maxMeals int
+
+	// mealPlans persists meal plans.
+	mealPlans mealPlanStore
+
+	// Core service which handles recipe life circle.
+	recipeService core.RecipeService
+
+	// logger is a centralized log handler.
+	logger log.Logger
+}
+
+// apiGatewayMealPlanGetRequestHandler will handle GET requests to get the meal plan of a week.
+type apiGatewayMealPlanGetRequestHandler struct {
+
+	// week is the week passed as path param.
+	week string
+
+	// mealPlans persists meal plans.
+	mealPlans mealPlanStore
+
+	// logger is a centralized log handler.
+	logger log.Logger
+}
+
+// apiGatewayMealPlanPutRequestHandler will handle PUT requests to update the meal plan of a week.
+type apiGatewayMealPlanPutRequestHandler struct {
+
+	// plan is the meal plan passed in request body.
+	plan *mealPlan
+
+	// maxMeals is the max number of meals of a meal plan.
+	maxMeals int
+
+	// mealPlans persists meal plans.
+	mealPlans mealPlanStore
+
+	// Core service which handles recipe life circle.
+	recipeService core.RecipeService
+
+	// logger is a centralized log handler.
+	logger log.Logger
+}
+
+// apiGatewayMealPlanDeleteRequestHandler will handle DELETE requests to remove the meal plan of a week.
+type apiGatewayMealPlanDeleteRequestHandler struct {
+
+	// week is the week passed as path param.
+	week string
+
+	// mealPlans persists meal plans.
+	mealPlans mealPlanStore
+
+	// logger is a centralized log handler.
+	logger log.Logger
+}
+
+// apiGatewayMealPlanShoppingListRequestHandler will handle GET requests to get a shopping list for all meals of a week.
+type apiGatewayMealPlanShoppingListRequestHandler struct {
+
+	// week is the week passed as path param.
+	week string
+
+	// units is the unit system all quantities are converted to. They're not converted if it's empty.
+	units string
+
+	// mediaType is the negotiated media type the shopping list is rendered in.
+	mediaType string
+
+	// maxRecipes is the max number of recipes of a shopping list.
+	maxRecipes int
+
+	// mealPlans persists meal plans.
+	mealPlans mealPlanStore
+
 	// Core service which handles recipe life circle.
 	recipeService core.RecipeService
 
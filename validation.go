@@ -0,0 +1,257 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	model "github.com/tommzn/recipeboard-core/model"
+)
+
+// recipeTypeEnum contains all values for recipe types, ordered by their value in model.RecipeType.
+var recipeTypeEnum = []string{"cooking", "baking"}
+
+// ingredientsRule accepts ingredients as text, one per line, or as list of ingredient lines and structured ingredients.
+var ingredientsRule = propertyRule{oneOf: []propertyRule{
+	{jsonType: "string", maxLength: 5000},
+	{jsonType: "array", items: &propertyRule{oneOf: []propertyRule{{jsonType: "string"}, {jsonType: "object", ref: "Ingredient"}}}},
+}}
+
+// newRecipeSchema is used to validate request bodies to create a new recipe.
+var newRecipeSchema = recipeSchema{
+	name:     "NewRecipe",
+	required: []string{"type", "title"},
+	properties: map[string]propertyRule{
+		"id":          {jsonType: "string"},
+		"type":        {jsonType: "string", enum: recipeTypeEnum},
+		"title":       {jsonType: "string", minLength: 1, maxLength: 200},
+		"ingredients": ingredientsRule,
+		"description": {jsonType: "string", maxLength: 20000},
+		"createdat":   {jsonType: "string", format: "date-time"},
+	},
+}
+
+// existingRecipeSchema is used to validate request bodies to update an existing recipe.
+var existingRecipeSchema = recipeSchema{
+	name:     "Recipe",
+	required: []string{"id", "type", "title"},
+	properties: map[string]propertyRule{
+		"id":          {jsonType: "string"},
+		"type":        {jsonType: "string", enum: recipeTypeEnum},
+		"title":       {jsonType: "string", minLength: 1, maxLength: 200},
+		"ingredients": ingredientsRule,
+		"description": {jsonType: "string", maxLength: 20000},
+		"createdat":   {jsonType: "string", format: "date-time"},
+	},
+}
+
+// decodeRequestBody parses passed request body as a JSON object. Property names are converted to lower case,
+// because JSON encoding of model.Recipe uses capitalized field names.
+func decodeRequestBody(requestBody string) (map[string]interface{}, error) {
+
+	rawValues := make(map[string]interface{})
+	if err := decodeJson(requestBody, &rawValues); err != nil {
+		return nil, newBadRequestError("Request body is not a valid JSON object", err)
+	}
+	return normalizeRequestValues(rawValues)
+}
+
+// normalizeRequestValues converts all property names of passed values to lower case and removes null values.
+func normalizeRequestValues(rawValues map[string]interface{}) (map[string]interface{}, error) {
+
+	values := make(map[string]interface{})
+	for key, value := range rawValues {
+		name := strings.ToLower(key)
+		if _, ok := values[name]; ok {
+			return nil, newFieldValidationError([]fieldError{{Field: name, Message: "is defined multiple times"}})
+		}
+		if value != nil {
+			values[name] = value
+		}
+	}
+	return values, nil
+}
+
+// validateRequestBody checks passed request body values against given schema.
+// It returns a validation error with all invalid fields.
+func validateRequestBody(values map[string]interface{}, schema recipeSchema) error {
+
+	fieldErrors := []fieldError{}
+	for _, name := range schema.required {
+		if _, ok := values[name]; !ok {
+			fieldErrors = append(fieldErrors, fieldError{Field: name, Message: "is required"})
+		}
+	}
+
+	names := []string{}
+	for name := range values {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		rule, ok := schema.properties[name]
+		if !ok {
+			fieldErrors = append(fieldErrors, fieldError{Field: name, Message: "is not supported"})
+			continue
+		}
+		if message := validateProperty(values[name], rule); message != nil {
+			fieldErrors = append(fieldErrors, fieldError{Field: name, Message: *message})
+		}
+	}
+
+	if len(fieldErrors) > 0 {
+		return newFieldValidationError(fieldErrors)
+	}
+	return nil
+}
+
+// validateProperty checks a single value against passed rule and returns a message if it's invalid.
+// Enum values can be passed by their ordinal as well, because it's used by JSON encoding of model.RecipeType.
+func validateProperty(value interface{}, rule propertyRule) *string {
+
+	if len(rule.oneOf) > 0 {
+		return validateAlternatives(value, rule.oneOf)
+	}
+
+	switch rule.jsonType {
+	case "array":
+		items, ok := value.([]interface{})
+		if !ok {
+			return validationMessage("must be an array")
+		}
+		if rule.items == nil {
+			return nil
+		}
+		for idx, item := range items {
+			if message := validateProperty(item, *rule.items); message != nil {
+				return validationMessage("item %d %s", idx, *message)
+			}
+		}
+		return nil
+	case "object":
+		if _, ok := value.(map[string]interface{}); !ok {
+			return validationMessage("must be an object")
+		}
+		return nil
+	}
+
+	if number, ok := value.(json.Number); ok && len(rule.enum) > 0 {
+		if ordinal, err := number.Int64(); err == nil && ordinal >= 0 && ordinal < int64(len(rule.enum)) {
+			return nil
+		}
+		return validationMessage("must be one of: %s", strings.Join(rule.enum, ", "))
+	}
+
+	stringValue, ok := value.(string)
+	if !ok {
+		return validationMessage("must be a %s", rule.jsonType)
+	}
+
+	if len(rule.enum) > 0 && !containsString(rule.enum, strings.ToLower(stringValue)) {
+		return validationMessage("must be one of: %s", strings.Join(rule.enum, ", "))
+	}
+
+	length := utf8.RuneCountInString(strings.TrimSpace(stringValue))
+	if length < rule.minLength {
+		return validationMessage("must have at least %d characters", rule.minLength)
+	}
+	if rule.maxLength > 0 && utf8.RuneCountInString(stringValue) > rule.maxLength {
+		return validationMessage("must not exceed %d characters", rule.maxLength)
+	}
+
+	if rule.format == "date-time" {
+		if _, err := time.Parse(time.RFC3339, stringValue); err != nil {
+			return validationMessage("must be a RFC 3339 date-time")
+		}
+	}
+	return nil
+}
+
+// validateAlternatives checks if passed value matches at least one of given rules. If there's a rule for the
+// JSON type of this value, it's message is returned. Otherwise the message lists all supported types.
+func validateAlternatives(value interface{}, rules []propertyRule) *string {
+
+	jsonTypes := []string{}
+	var typeMessage *string
+	for _, rule := range rules {
+		message := validateProperty(value, rule)
+		if message == nil {
+			return nil
+		}
+		if typeMessage == nil && rule.jsonType == jsonTypeOf(value) {
+			typeMessage = message
+		}
+		jsonTypes = append(jsonTypes, rule.jsonType)
+	}
+	if typeMessage != nil {
+		return typeMessage
+	}
+	return validationMessage("must be a %s", strings.Join(jsonTypes, " or "))
+}
+
+// jsonTypeOf returns the JSON type of a decoded value, e.g. string or array.
+func jsonTypeOf(value interface{}) string {
+	switch value.(type) {
+	case string:
+		return "string"
+	case json.Number:
+		return "number"
+	case bool:
+		return "boolean"
+	case []interface{}:
+		return "array"
+	case map[string]interface{}:
+		return "object"
+	default:
+		return "null"
+	}
+}
+
+// recipeFromRequestBody validates passed request body values and converts them to a recipe.
+// Structured ingredients are converted to the ingredients text before.
+func recipeFromRequestBody(values map[string]interface{}, schema recipeSchema) (*model.Recipe, error) {
+
+	if err := normalizeIngredients(values); err != nil {
+		return nil, err
+	}
+	if err := validateRequestBody(values, schema); err != nil {
+		return nil, err
+	}
+
+	recipe := &model.Recipe{}
+	recipe.Id, _ = values["id"].(string)
+	switch recipeType := values["type"].(type) {
+	case json.Number:
+		ordinal, _ := recipeType.Int64()
+		recipe.Type = model.RecipeType(ordinal)
+	case string:
+		parsedType, _ := toRecipeType(recipeType)
+		recipe.Type = *parsedType
+	}
+	recipe.Title, _ = values["title"].(string)
+	recipe.Ingredients, _ = values["ingredients"].(string)
+	recipe.Description, _ = values["description"].(string)
+	if createdAt, ok := values["createdat"].(string); ok {
+		recipe.CreatedAt, _ = time.Parse(time.RFC3339, createdAt)
+	}
+	return recipe, nil
+}
+
+// validationMessage returns a formatted validation message.
+func validationMessage(format string, args ...interface{}) *string {
+	message := fmt.Sprintf(format, args...)
+	return &message
+}
+
+// containsString returns true if passed value is an element of given list.
+func containsString(list []string, value string) bool {
+	for _, element := range list {
+		if element == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,185 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+	utils "github.com/tommzn/go-utils"
+	model "github.com/tommzn/recipeboard-core/model"
+	yaml "gopkg.in/yaml.v2"
+)
+
+// Test suite for request body validation.
+type ValidationTestSuite struct {
+	suite.Suite
+}
+
+func TestValidationTestSuite(t *testing.T) {
+	suite.Run(t, new(ValidationTestSuite))
+}
+
+// Test validation rules are in sync with schemas defined in OpenApi spec.
+func (suite *ValidationTestSuite) TestSchemasMatchOpenApiSpec() {
+
+	specFile, err := ioutil.ReadFile("aws/openapi.yml")
+	suite.Nil(err)
+	spec := openApiSpecForTest{}
+	suite.Nil(yaml.Unmarshal(specFile, &spec))
+
+	for _, schema := range []recipeSchema{newRecipeSchema, existingRecipeSchema} {
+
+		specSchema, ok := spec.Components.Schemas[schema.name]
+		suite.True(ok, schema.name)
+
+		required := append([]string{}, schema.required...)
+		sort.Strings(required)
+		sort.Strings(specSchema.Required)
+		suite.Equal(specSchema.Required, required, schema.name)
+
+		suite.Len(schema.properties, len(specSchema.Properties), schema.name)
+		for name, specProperty := range specSchema.Properties {
+			rule, ok := schema.properties[name]
+			suite.True(ok, schema.name+"."+name)
+			suite.assertPropertyRule(spec, specProperty, rule, schema.name+"."+name)
+		}
+	}
+}
+
+// assertPropertyRule asserts passed rule matches given property of OpenApi spec, including alternatives and array items.
+// Type of referenced schemas is read from the spec.
+func (suite *ValidationTestSuite) assertPropertyRule(spec openApiSpecForTest, specProperty openApiPropertyForTest, rule propertyRule, path string) {
+
+	specType := specProperty.Type
+	if specProperty.Ref != "" {
+		specType = spec.Components.Schemas[strings.TrimPrefix(specProperty.Ref, refForTest(""))].Type
+	}
+	suite.Equal(specType, rule.jsonType, path)
+	suite.Equal(specProperty.Format, rule.format, path)
+	suite.Equal(specProperty.Enum, rule.enum, path)
+	suite.Equal(specProperty.MinLength, rule.minLength, path)
+	suite.Equal(specProperty.MaxLength, rule.maxLength, path)
+	if rule.ref != "" {
+		suite.Equal(specProperty.Ref, refForTest(rule.ref), path)
+	} else {
+		suite.Empty(specProperty.Ref, path)
+	}
+
+	if suite.Len(rule.oneOf, len(specProperty.OneOf), path+".oneOf") {
+		for idx := range specProperty.OneOf {
+			suite.assertPropertyRule(spec, specProperty.OneOf[idx], rule.oneOf[idx], fmt.Sprintf("%s.oneOf[%d]", path, idx))
+		}
+	}
+	if suite.Equal(specProperty.Items == nil, rule.items == nil, path+".items") && rule.items != nil {
+		suite.assertPropertyRule(spec, *specProperty.Items, *rule.items, path+".items")
+	}
+}
+
+// Test validation of valid request bodies.
+func (suite *ValidationTestSuite) TestValidRequestBody() {
+
+	values, err := decodeRequestBody(`{"type": "baking", "title": "Bake a Cake", "ingredients": "100g Mehl"}`)
+	suite.Nil(err)
+	recipe, err := recipeFromRequestBody(values, newRecipeSchema)
+	suite.Nil(err)
+	suite.Equal(model.BakingRecipe, recipe.Type)
+	suite.Equal("Bake a Cake", recipe.Title)
+	suite.Equal("100g Mehl", recipe.Ingredients)
+
+	id := utils.NewId()
+	values2, err := decodeRequestBody(`{"Id": "` + id + `", "Type": 0, "Title": "Cook", "Description": null, "CreatedAt": "2021-06-20T10:11:12Z"}`)
+	suite.Nil(err)
+	recipe2, err := recipeFromRequestBody(values2, existingRecipeSchema)
+	suite.Nil(err)
+	suite.Equal(id, recipe2.Id)
+	suite.Equal(model.CookingRecipe, recipe2.Type)
+	suite.Equal("", recipe2.Description)
+	suite.Equal(2021, recipe2.CreatedAt.Year())
+}
+
+// Test validation errors for invalid request bodies.
+func (suite *ValidationTestSuite) TestInvalidRequestBody() {
+
+	_, err := decodeRequestBody("xxx")
+	suite.IsType(&badRequestError{}, err)
+
+	values, err := decodeRequestBody("{}")
+	suite.Nil(err)
+	_, err = recipeFromRequestBody(values, newRecipeSchema)
+	suite.assertFieldErrors(err, "title", "type")
+
+	values2, err := decodeRequestBody(`{"type": "frying", "title": " ", "titel": "Cook", "createdat": "yesterday"}`)
+	suite.Nil(err)
+	_, err = recipeFromRequestBody(values2, newRecipeSchema)
+	suite.assertFieldErrors(err, "createdat", "titel", "title", "type")
+
+	values3, err := decodeRequestBody(`{"type": 5, "title": "` + strings.Repeat("x", 201) + `", "ingredients": 3}`)
+	suite.Nil(err)
+	_, err = recipeFromRequestBody(values3, newRecipeSchema)
+	suite.assertFieldErrors(err, "ingredients", "title", "type")
+
+	_, err = recipeFromRequestBody(map[string]interface{}{"type": "baking", "title": "Cake"}, existingRecipeSchema)
+	suite.assertFieldErrors(err, "id")
+
+	_, err = decodeRequestBody(`{"title": "Cake", "Title": "Cake"}`)
+	suite.assertFieldErrors(err, "title")
+}
+
+// Test validation of properties with alternative types and array items.
+func (suite *ValidationTestSuite) TestValidateAlternatives() {
+
+	suite.Nil(validateProperty("100g Mehl", ingredientsRule))
+	suite.Nil(validateProperty([]interface{}{"100g Mehl", map[string]interface{}{"name": "Zucker"}}, ingredientsRule))
+
+	suite.Equal("must not exceed 5000 characters", *validateProperty(strings.Repeat("x", 5001), ingredientsRule))
+	suite.Equal("item 1 must be a string or object", *validateProperty([]interface{}{"100g Mehl", json.Number("3")}, ingredientsRule))
+	suite.Equal("must be a string or array", *validateProperty(json.Number("3"), ingredientsRule))
+}
+
+// assertFieldErrors asserts passed error is a validation error for given fields.
+func (suite *ValidationTestSuite) assertFieldErrors(err error, expectedFields ...string) {
+
+	suite.IsType(&validationError{}, err)
+	validationErr, ok := err.(*validationError)
+	if !ok {
+		return
+	}
+	fields := []string{}
+	for _, fieldErr := range validationErr.fieldErrors {
+		fields = append(fields, fieldErr.Field)
+	}
+	sort.Strings(fields)
+	suite.Equal(expectedFields, fields)
+}
+
+// openApiSpecForTest is used to read schemas from OpenApi spec.
+type openApiSpecForTest struct {
+	Components struct {
+		Schemas map[string]struct {
+			Type       string                            `yaml:"type"`
+			Required   []string                          `yaml:"required"`
+			Properties map[string]openApiPropertyForTest `yaml:"properties"`
+		} `yaml:"schemas"`
+	} `yaml:"components"`
+}
+
+// openApiPropertyForTest is used to read a property of a schema from OpenApi spec.
+type openApiPropertyForTest struct {
+	Type      string                   `yaml:"type"`
+	Format    string                   `yaml:"format"`
+	Enum      []string                 `yaml:"enum"`
+	MinLength int                      `yaml:"minLength"`
+	MaxLength int                      `yaml:"maxLength"`
+	Ref       string                   `yaml:"$ref"`
+	OneOf     []openApiPropertyForTest `yaml:"oneOf"`
+	Items     *openApiPropertyForTest  `yaml:"items"`
+}
+
+// refForTest returns the reference to a schema with passed name, as it's used in OpenApi spec.
+func refForTest(schemaName string) string {
+	return "#/components/schemas/" + schemaName
+}